@@ -0,0 +1,269 @@
+package bytecode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/dani-gouken/nomad/tokenizer"
+	"github.com/dani-gouken/nomad/vm"
+)
+
+// VERSION is bumped every time the instruction set or the layout changes,
+// files produced by another version are rejected.
+const VERSION = 12
+
+const EXTENSION = ".ndc"
+
+var MAGIC = []byte("NDBC")
+
+var ErrIncompatibleVersion = errors.New("incompatible bytecode version")
+
+// A bytecode file is laid out as follows, every integer being a varint:
+//
+//	header        magic, version
+//	constant pool count, then length-prefixed strings
+//	debug tokens  count, then kind, content (pool indexes), line, start, end
+//	instructions  count, then code, arg1, arg2 (pool indexes), debug token index
+type encoder struct {
+	buf     []byte
+	strings map[string]int
+	pool    []string
+	tokens  map[tokenizer.Token]int
+	table   []tokenizer.Token
+}
+
+func (e *encoder) str(s string) int {
+	index, ok := e.strings[s]
+	if !ok {
+		index = len(e.pool)
+		e.strings[s] = index
+		e.pool = append(e.pool, s)
+	}
+	return index
+}
+
+func (e *encoder) token(t tokenizer.Token) int {
+	index, ok := e.tokens[t]
+	if !ok {
+		index = len(e.table)
+		e.tokens[t] = index
+		e.table = append(e.table, t)
+	}
+	return index
+}
+
+func (e *encoder) uint(v int) {
+	e.buf = binary.AppendUvarint(e.buf, uint64(v))
+}
+
+func (e *encoder) int(v int) {
+	e.buf = binary.AppendVarint(e.buf, int64(v))
+}
+
+// Encode serializes the instructions produced by the compiler.
+func Encode(instructions []vm.Instruction) []byte {
+	e := &encoder{
+		strings: make(map[string]int),
+		tokens:  make(map[tokenizer.Token]int),
+	}
+	type operands struct{ code, arg1, arg2, token int }
+	compiled := make([]operands, len(instructions))
+	for i, instruction := range instructions {
+		compiled[i] = operands{
+			code:  e.str(instruction.Code),
+			arg1:  e.str(instruction.Arg1),
+			arg2:  e.str(instruction.Arg2),
+			token: e.token(instruction.DebugToken),
+		}
+	}
+	type tokenOperands struct{ kind, content int }
+	tokens := make([]tokenOperands, len(e.table))
+	for i, t := range e.table {
+		tokens[i] = tokenOperands{kind: e.str(t.Kind), content: e.str(t.Content)}
+	}
+
+	e.buf = append(e.buf, MAGIC...)
+	e.uint(VERSION)
+	e.uint(len(e.pool))
+	for _, s := range e.pool {
+		e.uint(len(s))
+		e.buf = append(e.buf, s...)
+	}
+	e.uint(len(e.table))
+	for i, t := range e.table {
+		e.uint(tokens[i].kind)
+		e.uint(tokens[i].content)
+		e.int(t.Loc.Line)
+		e.int(t.Loc.Start)
+		e.int(t.Loc.End)
+	}
+	e.uint(len(compiled))
+	for _, c := range compiled {
+		e.uint(c.code)
+		e.uint(c.arg1)
+		e.uint(c.arg2)
+		e.uint(c.token)
+	}
+	return e.buf
+}
+
+func Write(w io.Writer, instructions []vm.Instruction) error {
+	_, err := w.Write(Encode(instructions))
+	return err
+}
+
+type decoder struct {
+	r    *bytes.Reader
+	pool []string
+}
+
+func (d *decoder) uint() (int, error) {
+	v, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return 0, err
+	}
+	if v > math.MaxInt32 {
+		return 0, fmt.Errorf("value %d out of range", v)
+	}
+	return int(v), nil
+}
+
+// count reads the number of items of a section, each item taking at least
+// size bytes, so a count cannot exceed what is left in the input.
+func (d *decoder) count(size int) (int, error) {
+	count, err := d.uint()
+	if err != nil {
+		return 0, err
+	}
+	if count > d.r.Len()/size {
+		return 0, fmt.Errorf("%d items expected, %d bytes left", count, d.r.Len())
+	}
+	return count, nil
+}
+
+func (d *decoder) int() (int, error) {
+	v, err := binary.ReadVarint(d.r)
+	return int(v), err
+}
+
+func (d *decoder) str() (string, error) {
+	index, err := d.uint()
+	if err != nil {
+		return "", err
+	}
+	if index >= len(d.pool) {
+		return "", fmt.Errorf("constant %d out of range", index)
+	}
+	return d.pool[index], nil
+}
+
+func Read(r io.Reader) ([]vm.Instruction, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(b)
+}
+
+// Decode reads instructions serialized by Encode. The counts and lengths
+// are checked against the size of b before anything is allocated.
+func Decode(b []byte) ([]vm.Instruction, error) {
+	d := &decoder{r: bytes.NewReader(b)}
+	magic := make([]byte, len(MAGIC))
+	_, err := io.ReadFull(d.r, magic)
+	if err != nil || !bytes.Equal(magic, MAGIC) {
+		return nil, fmt.Errorf("not a nomad bytecode file")
+	}
+	version, err := d.uint()
+	if err != nil {
+		return nil, corrupted(err)
+	}
+	if version != VERSION {
+		return nil, fmt.Errorf("%w: compiled with version %d, version %d expected", ErrIncompatibleVersion, version, VERSION)
+	}
+
+	// a string takes at least the byte of its length
+	count, err := d.count(1)
+	if err != nil {
+		return nil, corrupted(err)
+	}
+	d.pool = make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		length, err := d.uint()
+		if err != nil {
+			return nil, corrupted(err)
+		}
+		if length > d.r.Len() {
+			return nil, corrupted(fmt.Errorf("string of %d bytes expected, %d bytes left", length, d.r.Len()))
+		}
+		s := make([]byte, length)
+		_, err = io.ReadFull(d.r, s)
+		if err != nil {
+			return nil, corrupted(err)
+		}
+		d.pool = append(d.pool, string(s))
+	}
+
+	// a token takes at least a byte for each of its 5 fields
+	count, err = d.count(5)
+	if err != nil {
+		return nil, corrupted(err)
+	}
+	tokens := make([]tokenizer.Token, 0, count)
+	for i := 0; i < count; i++ {
+		t := tokenizer.Token{}
+		if t.Kind, err = d.str(); err != nil {
+			return nil, corrupted(err)
+		}
+		if t.Content, err = d.str(); err != nil {
+			return nil, corrupted(err)
+		}
+		if t.Loc.Line, err = d.int(); err != nil {
+			return nil, corrupted(err)
+		}
+		if t.Loc.Start, err = d.int(); err != nil {
+			return nil, corrupted(err)
+		}
+		if t.Loc.End, err = d.int(); err != nil {
+			return nil, corrupted(err)
+		}
+		tokens = append(tokens, t)
+	}
+
+	// an instruction takes at least a byte for each of its 4 operands
+	count, err = d.count(4)
+	if err != nil {
+		return nil, corrupted(err)
+	}
+	instructions := make([]vm.Instruction, 0, count)
+	for i := 0; i < count; i++ {
+		instruction := vm.Instruction{}
+		if instruction.Code, err = d.str(); err != nil {
+			return nil, corrupted(err)
+		}
+		if instruction.Arg1, err = d.str(); err != nil {
+			return nil, corrupted(err)
+		}
+		if instruction.Arg2, err = d.str(); err != nil {
+			return nil, corrupted(err)
+		}
+		index, err := d.uint()
+		if err != nil {
+			return nil, corrupted(err)
+		}
+		if index >= len(tokens) {
+			return nil, corrupted(fmt.Errorf("debug token %d out of range", index))
+		}
+		instruction.DebugToken = tokens[index]
+		instructions = append(instructions, instruction)
+	}
+	return instructions, nil
+}
+
+func corrupted(err error) error {
+	return fmt.Errorf("corrupted bytecode file: %s", err.Error())
+}
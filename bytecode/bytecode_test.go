@@ -0,0 +1,81 @@
+package bytecode_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/dani-gouken/nomad/bytecode"
+	"github.com/dani-gouken/nomad/interpreter"
+	"github.com/dani-gouken/nomad/vm"
+	"github.com/stretchr/testify/assert"
+)
+
+const program = `
+auto greet :: func(string name, string greeting :: "hello") string {
+    return greeting + " " + name
+}
+auto message :: greet("world")
+auto negative :: -1
+`
+
+func TestRoundTrip(t *testing.T) {
+	i := interpreter.NewInterpreter()
+	instructions, err := i.Compile(program)
+	assert.NoError(t, err)
+
+	decoded, err := bytecode.Decode(bytecode.Encode(instructions))
+	assert.NoError(t, err)
+	assert.Equal(t, instructions, decoded)
+
+	instance := vm.New()
+	assert.NoError(t, instance.Interpret(decoded))
+	message, err := instance.GetGlobal("message")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello world", message.Value)
+}
+
+func TestRejectInvalidFiles(t *testing.T) {
+	i := interpreter.NewInterpreter()
+	instructions, err := i.Compile(program)
+	assert.NoError(t, err)
+	encoded := bytecode.Encode(instructions)
+
+	_, err = bytecode.Decode([]byte("#!/bin/sh"))
+	assert.ErrorContains(t, err, "not a nomad bytecode file")
+
+	future := append([]byte{}, bytecode.MAGIC...)
+	future = binary.AppendUvarint(future, bytecode.VERSION+1)
+	future = append(future, encoded[len(bytecode.MAGIC)+1:]...)
+	_, err = bytecode.Decode(future)
+	assert.True(t, errors.Is(err, bytecode.ErrIncompatibleVersion))
+
+	_, err = bytecode.Decode(encoded[:len(encoded)/2])
+	assert.ErrorContains(t, err, "corrupted bytecode file")
+}
+
+func TestRejectCorruptedHeaders(t *testing.T) {
+	header := func(values ...uint64) []byte {
+		b := append([]byte{}, bytecode.MAGIC...)
+		b = binary.AppendUvarint(b, bytecode.VERSION)
+		for _, v := range values {
+			b = binary.AppendUvarint(b, v)
+		}
+		return b
+	}
+	huge := uint64(1) << 62
+	for name, b := range map[string][]byte{
+		"truncated before the constant pool": header(),
+		"truncated constant pool":            header(3, 1),
+		"truncated string":                   append(header(1, 10), "abc"...),
+		"oversized constant pool":            header(huge),
+		"oversized string":                   header(1, huge),
+		"string longer than the file":        append(header(1, 100), "abc"...),
+		"oversized debug token table":        header(0, huge),
+		"oversized instruction count":        header(0, 0, huge),
+		"constant out of range":              header(0, 1, huge, 0, 0, 0, 0),
+	} {
+		_, err := bytecode.Decode(b)
+		assert.ErrorContains(t, err, "corrupted bytecode file", name)
+	}
+}
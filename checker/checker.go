@@ -0,0 +1,392 @@
+package checker
+
+import (
+	"errors"
+	"fmt"
+
+	nomadError "github.com/dani-gouken/nomad/errors"
+	"github.com/dani-gouken/nomad/parser"
+	"github.com/dani-gouken/nomad/runtime/data"
+	"github.com/dani-gouken/nomad/runtime/types"
+	"github.com/dani-gouken/nomad/tokenizer"
+)
+
+// Checker verifies the types of a program before it is compiled. The
+// members of the imported files are only known by the vm, which checks
+// them at runtime.
+type Checker struct {
+	types         types.Registrar
+	env           Environment
+	stringMethods data.StringMethods
+	scope         *scope
+	// pending holds the names declared at the top level of the program.
+	pending map[string]bool
+	// pendingMethods holds the method declarations of the program, by
+	// receiver type name and method name.
+	pendingMethods map[string]map[string]*parser.Stmt
+	// methods holds the signatures of the methods declared by the program,
+	// the types of the environment being owned by the vm.
+	methods map[*types.ObjectType]map[string]*signature
+	// returnTypes holds the return types of the functions being checked,
+	// the innermost last.
+	returnTypes []types.RuntimeType
+	// typeParams holds the type parameters of the generic functions whose
+	// signature is being resolved, the innermost last.
+	typeParams []map[string]*types.TypeVar
+	// blocks counts the blocks enclosing the statement being checked, it
+	// is 0 at the top level of the program.
+	blocks int
+	errors []error
+}
+
+type param struct {
+	name       string
+	t          types.RuntimeType
+	hasDefault bool
+}
+
+type signature struct {
+	params     []param
+	ret        types.RuntimeType
+	typeParams []*types.TypeVar
+	// named is false when the signature comes from a function type,
+	// which does not carry the name of the parameters.
+	named bool
+}
+
+// typed is the static type of an expression, a nil type being unknown.
+type typed struct {
+	t   types.RuntimeType
+	sig *signature
+	// module is the native module bound to a namespace by an import.
+	module string
+}
+
+type scope struct {
+	symbols map[string]typed
+	parent  *scope
+}
+
+func newScope(parent *scope) *scope {
+	return &scope{
+		symbols: map[string]typed{},
+		parent:  parent,
+	}
+}
+
+func (s *scope) lookup(name string) (typed, bool) {
+	for current := s; current != nil; current = current.parent {
+		symbol, ok := current.symbols[name]
+		if ok {
+			return symbol, true
+		}
+	}
+	return typed{}, false
+}
+
+func NewChecker(env Environment) *Checker {
+	registrar := types.NewRegistrar()
+	registrar.Add(types.MakeErrorType(nil), tokenizer.Token{})
+	c := &Checker{
+		types:          registrar,
+		env:            env,
+		stringMethods:  data.NewStringMethods(registrar),
+		scope:          newScope(nil),
+		pending:        map[string]bool{},
+		pendingMethods: map[string]map[string]*parser.Stmt{},
+		methods:        map[*types.ObjectType]map[string]*signature{},
+	}
+	c.declareGlobals()
+	return c
+}
+
+// Check reports every type error found in program, run in env.
+func Check(program *parser.Program, env Environment) error {
+	return NewChecker(env).Check(program.Stmts)
+}
+
+func (c *Checker) Check(stmts []*parser.Stmt) error {
+	c.declarePending(stmts)
+	for _, stmt := range stmts {
+		c.checkStmt(stmt)
+	}
+	return errors.Join(c.errors...)
+}
+
+func (c *Checker) error(token tokenizer.Token, format string, args ...any) {
+	c.errors = append(c.errors, nomadError.TypeError(fmt.Sprintf(format, args...), token))
+}
+
+func (c *Checker) checkStmts(stmts []*parser.Stmt) {
+	c.blocks++
+	defer func() { c.blocks-- }()
+	for _, stmt := range stmts {
+		c.checkStmt(stmt)
+	}
+}
+
+func (c *Checker) checkStmt(stmt *parser.Stmt) {
+	switch stmt.Kind {
+	case parser.STMT_KIND_IMPLICIT_RETURN, parser.STMT_KIND_DEBUG_PRINT:
+		c.checkExpr(stmt.Expr)
+	case parser.STMT_KIND_IF, parser.STMT_KIND_ELIF, parser.STMT_KIND_FOR, parser.STMT_KIND_WHILE:
+		c.expectBool(c.checkExpr(stmt.Expr), stmt.Expr.Token, "condition")
+		c.checkStmts(stmt.Children)
+	case parser.STMT_KIND_ELSE:
+		c.checkStmts(stmt.Children)
+	case parser.STMT_KIND_ASSIGNMENT:
+		name := stmt.Data[0]
+		value := c.checkExpr(stmt.Expr)
+		variable, ok := c.lookup(name)
+		if !ok {
+			return
+		}
+		if err := assignable(variable.t, value.t); err != nil {
+			c.error(stmt.Expr.Token, "cannot assign value of type %s to variable %s of type %s", value.t.GetName(), name.Content, variable.t.GetName())
+		}
+	case parser.STMT_KIND_ARR_ASSIGNMENT:
+		target := stmt.Expr.Children[0]
+		item := c.checkExpr(target)
+		value := c.checkExpr(stmt.Expr.Children[1])
+		if err := assignable(item.t, value.t); err != nil {
+			c.error(stmt.Expr.Children[1].Token, "cannot assign value of type %s to item of type %s", value.t.GetName(), item.t.GetName())
+		}
+	case parser.STMT_KIND_VAR_DECLARATION, parser.STMT_KIND_CONST_DECLARATION:
+		c.checkDeclaration(stmt)
+	case parser.STMT_KIND_TYPE_DECLARATION:
+		c.checkTypeDeclaration(stmt)
+	case parser.STMT_KIND_ENUM_DECLARATION:
+		c.checkEnumDeclaration(stmt)
+	case parser.STMT_KIND_METHOD_DECLARATION:
+		c.checkMethodDeclaration(stmt)
+	case parser.STMT_KIND_IMPORT:
+		c.checkImport(stmt)
+	case parser.STMT_KIND_MATCH:
+		c.checkMatch(stmt)
+	case parser.STMT_KIND_FOR_IN:
+		c.checkForIn(stmt)
+	case parser.STMT_KIND_TRY:
+		c.checkTry(stmt)
+	case parser.STMT_KIND_THROW:
+		c.checkThrow(stmt)
+	case parser.STMT_KIND_RETURN:
+		value := c.checkExpr(stmt.Expr)
+		if len(c.returnTypes) == 0 {
+			return
+		}
+		returnType := c.returnTypes[len(c.returnTypes)-1]
+		if err := assignable(returnType, value.t); err != nil {
+			c.error(stmt.Expr.Token, "cannot return value of type %s from function returning %s", value.t.GetName(), returnType.GetName())
+		}
+	}
+}
+
+func (c *Checker) checkDeclaration(stmt *parser.Stmt) {
+	name := stmt.Data[0].Content
+	valueExpr := stmt.Expr.Children[0]
+	typeExpr := stmt.Expr.Children[1]
+
+	// functions are declared before their body is checked so they can call themselves
+	if valueExpr.Kind == parser.EXPR_KIND_FUNC {
+		sig := c.funcSignature(valueExpr)
+		value := typed{t: sig.asType(), sig: sig}
+		declared := value
+		if typeExpr.Kind != parser.EXPR_KIND_TYPE_AUTO {
+			declared = typed{t: c.resolveType(typeExpr)}
+			c.checkAssignable(declared.t, value.t, name, valueExpr.Token)
+		}
+		c.scope.symbols[name] = declared
+		c.checkFuncBody(valueExpr, sig)
+		return
+	}
+
+	value := c.checkExpr(valueExpr)
+	if typeExpr.Kind == parser.EXPR_KIND_TYPE_AUTO {
+		c.scope.symbols[name] = value
+		return
+	}
+	declared := c.resolveType(typeExpr)
+	c.checkAssignable(declared, value.t, name, valueExpr.Token)
+	c.scope.symbols[name] = typed{t: declared}
+}
+
+func (c *Checker) checkAssignable(declared types.RuntimeType, value types.RuntimeType, name string, token tokenizer.Token) {
+	if err := assignable(declared, value); err != nil {
+		c.error(token, "cannot assign value of type %s to variable %s declared as %s", value.GetName(), name, declared.GetName())
+	}
+}
+
+func (c *Checker) checkTypeDeclaration(stmt *parser.Stmt) {
+	name := stmt.Data[0]
+	t := c.resolveType(stmt.Expr)
+	if t == nil {
+		return
+	}
+	objectType, err := types.ToObjectType(t)
+	if err != nil || !objectType.IsAnonymous() {
+		c.error(stmt.Expr.Token, "cannot declare type %s, only object types can be declared", name.Content)
+		return
+	}
+	if c.types.Has(name.Content) {
+		c.error(name, "cannot redeclare type %s", name.Content)
+		return
+	}
+	objectType.SetName(name.Content)
+	c.types.Add(objectType, name)
+}
+
+// resolveType returns the type described by a type expression,
+// nil when it cannot be known statically.
+func (c *Checker) resolveType(expr parser.Expr) types.RuntimeType {
+	switch expr.Kind {
+	case parser.EXPR_KIND_TYPE:
+		return c.lookupType(expr.Token)
+	case parser.EXPR_KIND_TYPE_ARRAY:
+		subtype := c.resolveType(expr.Children[0])
+		if subtype == nil {
+			return nil
+		}
+		return types.NewArrayType(subtype)
+	case parser.EXPR_KIND_TYPE_MAP:
+		keyType := c.resolveType(expr.Children[0])
+		valueType := c.resolveType(expr.Children[1])
+		if keyType == nil || valueType == nil {
+			return nil
+		}
+		mapType, err := types.NewMapType(keyType, valueType)
+		if err != nil {
+			c.error(expr.Children[0].Token, err.Error())
+			return nil
+		}
+		return mapType
+	case parser.EXPR_KIND_TYPE_OPTIONAL:
+		subtype := c.resolveType(expr.Children[0])
+		if subtype == nil {
+			return nil
+		}
+		optionalType, err := types.NewOptionalType(subtype)
+		if err != nil {
+			c.error(expr.Token, err.Error())
+			return nil
+		}
+		return optionalType
+	case parser.EXPR_KIND_TYPE_FUNC:
+		funcType := types.NewFuncType()
+		if len(expr.Children) != 2 {
+			return funcType
+		}
+		for _, paramExpr := range expr.Children[0].Children {
+			paramType := c.resolveType(paramExpr)
+			if paramType == nil {
+				return nil
+			}
+			funcType.AddParam(paramType)
+		}
+		returnType := c.resolveType(expr.Children[1])
+		if returnType == nil {
+			return nil
+		}
+		funcType.SetRet(returnType)
+		return funcType
+	case parser.EXPR_KIND_TYPE_OBJ:
+		objectType := types.NewObjectType()
+		for _, field := range expr.Children {
+			fieldType := c.resolveType(field.Children[0])
+			value := c.checkExpr(field.Children[1])
+			if err := match(fieldType, value.t); err != nil {
+				c.error(field.Children[1].Token, "cannot use value of type %s as default of field %s of type %s", value.t.GetName(), field.Token.Content, fieldType.GetName())
+			}
+			if err := objectType.AddField(field.Token.Content, fieldType, nil); err != nil {
+				c.error(field.Token, err.Error())
+			}
+		}
+		return objectType
+	}
+	return nil
+}
+
+func (c *Checker) expectBool(value typed, token tokenizer.Token, what string) {
+	if value.t == nil {
+		return
+	}
+	if err := types.ExpectedBoolType(value.t); err != nil {
+		c.error(token, "%s should be a bool, got %s", what, value.t.GetName())
+	}
+}
+
+// assignable is match allowing an int where a float is expected, the vm
+// promoting the value on assignment, return and call.
+func assignable(expected types.RuntimeType, actual types.RuntimeType) error {
+	if expected != nil && actual != nil && types.ExpectedIntType(actual) == nil {
+		target := expected
+		optionalType, err := types.ToOptionalType(expected)
+		if err == nil {
+			target = optionalType.GetSubtype()
+		}
+		if types.ExpectedFloatType(target) == nil {
+			return nil
+		}
+	}
+	return match(expected, actual)
+}
+
+// match is types.RuntimeType.Match tolerating types unknown statically.
+func match(expected types.RuntimeType, actual types.RuntimeType) error {
+	if expected == nil || actual == nil {
+		return nil
+	}
+	expectedArray, ok := expected.(*types.ArrayType)
+	if ok {
+		actualArray, err := types.ToArrayType(actual)
+		if err != nil {
+			return err
+		}
+		return match(expectedArray.GetSubtype(), actualArray.GetSubtype())
+	}
+	expectedOptional, ok := expected.(*types.OptionalType)
+	if ok {
+		if types.IsNoneType(actual) {
+			return nil
+		}
+		actualOptional, err := types.ToOptionalType(actual)
+		if err == nil {
+			actual = actualOptional.GetSubtype()
+		}
+		return match(expectedOptional.GetSubtype(), actual)
+	}
+	expectedMap, ok := expected.(*types.MapType)
+	if ok {
+		actualMap, err := types.ToMapType(actual)
+		if err != nil {
+			return err
+		}
+		if err := match(expectedMap.GetKeyType(), actualMap.GetKeyType()); err != nil {
+			return err
+		}
+		return match(expectedMap.GetValueType(), actualMap.GetValueType())
+	}
+	expectedFunc, ok := expected.(*types.FuncType)
+	if ok {
+		actualFunc, err := types.ToFuncType(actual)
+		if err != nil {
+			return err
+		}
+		// a bare func type accepts any function
+		if expectedFunc.GetRet() == nil {
+			return nil
+		}
+		if expectedFunc.IsGeneric() || actualFunc.IsGeneric() {
+			return expectedFunc.Match(actualFunc)
+		}
+		if len(expectedFunc.GetParams()) != len(actualFunc.GetParams()) {
+			return fmt.Errorf("parameter length mismatch")
+		}
+		for i, param := range expectedFunc.GetParams() {
+			if err := match(param, actualFunc.GetParams()[i]); err != nil {
+				return err
+			}
+		}
+		return match(expectedFunc.GetRet(), actualFunc.GetRet())
+	}
+	return expected.Match(actual)
+}
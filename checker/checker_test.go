@@ -0,0 +1,558 @@
+package checker_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dani-gouken/nomad/checker"
+	"github.com/dani-gouken/nomad/parser"
+	"github.com/dani-gouken/nomad/tokenizer"
+	"github.com/dani-gouken/nomad/vm"
+	"github.com/stretchr/testify/assert"
+)
+
+func check(t *testing.T, code string) error {
+	tokens, err := tokenizer.Tokenize(code)
+	assert.NoError(t, err)
+	program, err := parser.Parse(tokens)
+	assert.NoError(t, err)
+	return checker.Check(program, vm.New())
+}
+
+func TestCheckValidProgram(t *testing.T) {
+	err := check(t, `
+type Header :: {
+    string name :: ""
+    string value :: ""
+}
+auto fib :: func(int n) int {
+    if n < 2 {
+        return n
+    }
+    return fib(n - 1) + fib(n - 2)
+}
+auto greet :: func(string name, string greeting :: "hello") string {
+    return greeting + " " + name
+}
+auto header :: new Header{ name :: "accept" }
+[string] names :: [string]{ header.name, greet("nomad", greeting: "bye") }
+int total :: fib(10) + len names
+auto apply :: func(func(int) -> (int) f, int value) int {
+    return f(value)
+}
+apply(fib, 3)
+`)
+	assert.NoError(t, err)
+}
+
+func TestCheckUnknownNames(t *testing.T) {
+	err := check(t, `print y`)
+	assert.ErrorContains(t, err, "undeclared variable [y]")
+
+	err = check(t, `strng x :: 1`)
+	assert.ErrorContains(t, err, "unknown type [strng]")
+
+	err = check(t, `T y :: 5`)
+	assert.ErrorContains(t, err, "unknown type [T]")
+
+	err = check(t, `auto f :: func() int {
+    return y
+}`)
+	assert.ErrorContains(t, err, "undeclared variable [y]")
+
+	err = check(t, "import \"math\"\nprint math.sqrt(\"x\")")
+	assert.ErrorContains(t, err, "type mismatch for parameter x, expected float, got string")
+
+	err = check(t, "import \"math\"\nprint math.nope(1)")
+	assert.ErrorContains(t, err, "type module math has no field [nope]")
+
+	err = check(t, "import \"http\"\nhttp.Request request :: new http.Requst{}")
+	assert.ErrorContains(t, err, "unknown type [http.Requst]")
+}
+
+func TestCheckKnownNames(t *testing.T) {
+	err := check(t, `
+import "math"
+import "lib/numbers"
+auto range :: new numbers.Range{ min :: 0.0 }
+print numbers.clamp(range, math.sqrt(3.0))
+print len [int]{ 1 }
+auto next :: func() int {
+    return count + 1
+}
+int count :: 1
+auto first :: func[T]([T] items) T {
+    T item :: items[0]
+    return item
+}
+`)
+	assert.NoError(t, err)
+}
+
+func TestCheckDeclarations(t *testing.T) {
+	err := check(t, `int a :: "text"`)
+	assert.ErrorContains(t, err, "/1:6:11: type error. cannot assign value of type string to variable a declared as int")
+
+	err = check(t, "auto a :: 1\na :: 2.0")
+	assert.ErrorContains(t, err, "cannot assign value of type float to variable a of type int")
+
+	err = check(t, "auto a :: 1.0\na++")
+	assert.ErrorContains(t, err, "cannot increment or decrement value of type float")
+}
+
+func TestCheckOperators(t *testing.T) {
+	err := check(t, `auto a :: 1 + "a"`)
+	assert.ErrorContains(t, err, "mismatched operand types for +, int and string")
+
+	err = check(t, `auto a :: "a" - "b"`)
+	assert.ErrorContains(t, err, "unsupported operand - for type string")
+
+	err = check(t, `if 1 { print 1 }`)
+	assert.ErrorContains(t, err, "condition should be a bool, got int")
+}
+
+func TestCheckNumericPromotion(t *testing.T) {
+	code := `
+float a :: 1
+auto b :: a * 2 + 1
+float c :: b
+auto half :: func(float value) float { return value / 2 }
+float d :: half(3)
+num e :: 1
+num f :: e + 2.5
+int g :: int(f)
+float h :: float(g)
+if g < 2.5 { print g }
+auto floats :: [float]{1, 2.5}
+floats[0] :: 3
+auto weights :: {string: ?float}{"a": 1, "b": none}
+weights["c"] :: 2
+`
+	assert.NoError(t, check(t, code))
+
+	err := check(t, `int a :: 1.0`)
+	assert.ErrorContains(t, err, "cannot assign value of type float to variable a declared as int")
+
+	err = check(t, "auto a :: 1 + 2.0\nint b :: a")
+	assert.ErrorContains(t, err, "cannot assign value of type float to variable b declared as int")
+
+	err = check(t, `int a :: int("1")`)
+	assert.ErrorContains(t, err, "type mismatch for parameter value, expected num, got string")
+
+	err = check(t, `auto ints :: [int]{1, 2.5}`)
+	assert.ErrorContains(t, err, "cannot push value of type float to array of int")
+}
+
+func TestCheckInterpolation(t *testing.T) {
+	err := check(t, "auto port :: 80\nstring message :: \"port {port + 1}\"")
+	assert.NoError(t, err)
+
+	err = check(t, "auto port :: 80\nint message :: \"port {port}\"")
+	assert.ErrorContains(t, err, "cannot assign value of type string to variable message declared as int")
+
+	err = check(t, "auto nothing :: func() void {}\nprint \"{nothing()}\"")
+	assert.ErrorContains(t, err, "cannot interpolate value of type void")
+}
+
+func TestCheckStringMethods(t *testing.T) {
+	err := check(t, "[string] parts :: \"a b\".trim().split(\" \")\nint i :: parts[0].index_of(\"a\")\nstring c :: parts[0][i]")
+	assert.NoError(t, err)
+
+	err = check(t, `print "a".reverse()`)
+	assert.ErrorContains(t, err, "type string has no method [reverse]")
+
+	err = check(t, `print "a".repeat("3")`)
+	assert.ErrorContains(t, err, "type mismatch for parameter count, expected int, got string")
+
+	err = check(t, `int length :: "a".upper()`)
+	assert.ErrorContains(t, err, "cannot assign value of type string to variable length declared as int")
+}
+
+func TestCheckCalls(t *testing.T) {
+	code := `
+auto greet :: func(string name, string greeting :: "hello") string {
+    return greeting + " " + name
+}
+`
+	err := check(t, code+`greet(1)`)
+	assert.ErrorContains(t, err, "type mismatch for parameter name, expected string, got int")
+
+	err = check(t, code+`greet()`)
+	assert.ErrorContains(t, err, "missing argument [name]")
+
+	err = check(t, code+`greet("a", "b", "c")`)
+	assert.ErrorContains(t, err, "too many arguments, 2 declared, 3 passed")
+
+	err = check(t, code+`greet("a", polite: true)`)
+	assert.ErrorContains(t, err, "unknown argument [polite]")
+
+	err = check(t, "auto a :: 1\na()")
+	assert.ErrorContains(t, err, "cannot call value of type int")
+}
+
+func TestCheckReturns(t *testing.T) {
+	err := check(t, `
+auto f :: func(int a) string {
+    if a > 0 {
+        return a
+    }
+    return "negative"
+}
+`)
+	assert.ErrorContains(t, err, "/4:7:7: type error. cannot return value of type int from function returning string")
+}
+
+func TestCheckObjects(t *testing.T) {
+	code := `
+type Header :: {
+    string name :: ""
+}
+auto header :: new Header{ name :: "accept" }
+`
+	err := check(t, code+`print header.value`)
+	assert.ErrorContains(t, err, "type Header has no field [value]")
+
+	err = check(t, code+`auto other :: new Header{ name :: 1 }`)
+	assert.ErrorContains(t, err, "cannot assign value of type int to field name of type string")
+
+	err = check(t, code+`print Header#value`)
+	assert.ErrorContains(t, err, "type Header has no field [value]")
+
+	err = check(t, code+`auto kind :: Header`)
+	assert.NoError(t, err)
+
+	err = check(t, code+`string kind :: Header`)
+	assert.ErrorContains(t, err, "cannot assign value of type type to variable kind declared as string")
+}
+
+func TestCheckReportsAllErrors(t *testing.T) {
+	err := check(t, `
+int a :: "a"
+auto f :: func() int {
+    return true
+}
+if f() { print a }
+`)
+	assert.ErrorContains(t, err, "cannot assign value of type string to variable a declared as int")
+	assert.ErrorContains(t, err, "cannot return value of type bool from function returning int")
+	assert.ErrorContains(t, err, "condition should be a bool, got int")
+}
+
+func TestCheckMaps(t *testing.T) {
+	err := check(t, `
+auto ages :: {string: int}{"alice": 31}
+ages["bob"] :: ages["alice"] + 1
+[string] names :: keys ages
+bool known :: "bob" in ages
+int count :: len ages
+`)
+	assert.NoError(t, err)
+
+	err = check(t, `auto ages :: {string: int}{1: "a"}`)
+	assert.ErrorContains(t, err, "invalid key, expected string, got int")
+	assert.ErrorContains(t, err, "invalid value, expected int, got string")
+
+	err = check(t, "auto ages :: {string: int}{}\nages[\"a\"] :: 1.0")
+	assert.ErrorContains(t, err, "cannot assign value of type float to item of type int")
+
+	err = check(t, `auto sets :: {[int]: bool}{}`)
+	assert.ErrorContains(t, err, "invalid map key type [int]")
+}
+
+func TestCheckEnums(t *testing.T) {
+	code := `
+enum Shape :: { Circle(float radius), Rect(float width, float height), Empty }
+auto shape :: Shape#Circle(1.0)
+`
+	err := check(t, code+`
+match shape {
+    Circle(r) { float radius :: r }
+    Rect(w, h) { print w * h }
+    Empty { print 0 }
+}
+match shape {
+    Circle { print 1 }
+    else { print 2 }
+}
+`)
+	assert.NoError(t, err)
+
+	err = check(t, code+"match shape {\n    Circle(r) { print r }\n}")
+	assert.ErrorContains(t, err, "non-exhaustive match on Shape, missing variants: Rect, Empty")
+
+	err = check(t, code+"match shape {\n    Circle(r) { int radius :: r }\n    else { print 1 }\n}")
+	assert.ErrorContains(t, err, "cannot assign value of type float to variable radius declared as int")
+
+	err = check(t, code+"match shape {\n    Circle { print 1 }\n    Circle { print 2 }\n    else { print 3 }\n}")
+	assert.ErrorContains(t, err, "duplicate arm for variant Circle")
+
+	err = check(t, code+`auto other :: Shape#Square`)
+	assert.ErrorContains(t, err, "enum Shape has no variant [Square]")
+
+	err = check(t, code+`auto other :: Shape#Rect(1.0, "a")`)
+	assert.ErrorContains(t, err, "type mismatch for parameter height, expected float, got string")
+
+	err = check(t, code+`int other :: Shape#Empty`)
+	assert.ErrorContains(t, err, "cannot assign value of type Shape to variable other declared as int")
+}
+
+func TestCheckOptionals(t *testing.T) {
+	err := check(t, `
+?int a :: none
+a :: 1
+?int b :: a
+int c :: unwrap b
+if Some(b) { print c }
+auto f :: func(?string name) string {
+    if Some(name) {
+        return unwrap name
+    }
+    return "anonymous"
+}
+f(none)
+f("a")
+`)
+	assert.NoError(t, err)
+
+	err = check(t, "?int a :: 1\nint b :: a")
+	assert.ErrorContains(t, err, "cannot assign value of type ?int to variable b declared as int")
+
+	err = check(t, "int a :: 1\nprint unwrap a")
+	assert.ErrorContains(t, err, "cannot unwrap value of type int, optional expected")
+
+	err = check(t, "int a :: 1\nprint Some(a)")
+	assert.ErrorContains(t, err, "Some expects an optional value, got int")
+
+	err = check(t, "?string a :: 1")
+	assert.ErrorContains(t, err, "cannot assign value of type int to variable a declared as ?string")
+}
+
+func TestCheckTryCatch(t *testing.T) {
+	err := check(t, `
+auto f :: func(int a) int {
+    if a < 0 {
+        throw new Error{ message :: "negative" }
+    }
+    return a
+}
+try {
+    f(1)
+} catch e {
+    string message :: e.message
+}
+`)
+	assert.NoError(t, err)
+
+	err = check(t, "throw \"boom\"")
+	assert.ErrorContains(t, err, "cannot throw value of type string, Error expected")
+
+	err = check(t, "try { print 1 } catch e { int a :: e }")
+	assert.ErrorContains(t, err, "cannot assign value of type Error to variable a declared as int")
+}
+
+func TestCheckForIn(t *testing.T) {
+	err := check(t, `
+auto names :: [string]{"a"}
+for i, name in names {
+    string n :: name
+    int index :: i
+}
+for char in "abc" {
+    string c :: char
+}
+for key in {string: int}{"a": 1} {
+    string k :: key
+}
+for key, value in {string: int}{"a": 1} {
+    string k :: key
+    int v :: value
+}
+`)
+	assert.NoError(t, err)
+
+	err = check(t, "for key, value in {string: int}{\"a\": 1} {\n    string v :: value\n}")
+	assert.ErrorContains(t, err, "cannot assign value of type int to variable v declared as string")
+
+	err = check(t, "for name in [string]{\"a\"} {\n    int n :: name\n}")
+	assert.ErrorContains(t, err, "cannot assign value of type string to variable n declared as int")
+
+	err = check(t, "for item in true {\n    print item\n}")
+	assert.ErrorContains(t, err, "cannot iterate over value of type bool")
+
+	err = check(t, "for 1 {\n    print 1\n}")
+	assert.ErrorContains(t, err, "condition")
+}
+
+func TestCheckBitwiseOperators(t *testing.T) {
+	err := check(t, `
+int a :: 7 % 2 + (1 << 3) - (8 >> 1) + (6 & 3 | 1 ^ 2) + ~0
+bool b :: true & false | (a > 2) && a < 10 || false
+`)
+	assert.NoError(t, err)
+
+	err = check(t, "print 1.5 % 2.0")
+	assert.ErrorContains(t, err, "unsupported operand % for type float")
+
+	err = check(t, "print true ^ false")
+	assert.ErrorContains(t, err, "unsupported operand ^ for type bool")
+
+	err = check(t, "print 1 & true")
+	assert.ErrorContains(t, err, "mismatched operand types for &, int and bool")
+
+	err = check(t, "print ~true")
+	assert.ErrorContains(t, err, "unsupported operand bitwise not(~) on type bool")
+
+	err = check(t, "print 1 && true")
+	assert.ErrorContains(t, err, "operand of && should be a bool, got int")
+}
+
+func TestCheckGenerics(t *testing.T) {
+	code := `
+auto max :: func[T](T a, T b) T {
+    if a > b {
+        return a
+    }
+    return b
+}
+auto pair :: func[K, V](K key, V value) {K: V} {
+    return {K: V}{key: value}
+}
+`
+	valid := code + `
+int a :: max(1, 2)
+float b :: max(1, 2.5)
+float c :: max[float](1, 2)
+{string: [int]} d :: pair("a", [int]{1})
+{string: int} e :: pair[string, int]("a", 1)
+auto apply :: func(func(int) -> int f, int x) int {
+    return f(x)
+}
+auto identity :: func[T](T x) T {
+    return x
+}
+int f :: apply(identity, 1)
+int g :: apply(identity[int], 1)
+`
+	assert.NoError(t, check(t, valid))
+
+	err := check(t, code+`string s :: max(1, 2)`)
+	assert.ErrorContains(t, err, "cannot assign value of type int to variable s declared as string")
+
+	err = check(t, code+`auto s :: max(1, "a")`)
+	assert.ErrorContains(t, err, "type mismatch for parameter b, expected int, got string")
+
+	err = check(t, code+`auto s :: max[int]("a", 1)`)
+	assert.ErrorContains(t, err, "type mismatch for parameter a, expected int, got string")
+
+	err = check(t, code+`auto s :: pair[string](1, 2)`)
+	assert.ErrorContains(t, err, "expected 2 type arguments, got 1")
+
+	err = check(t, code+`auto apply :: func(func(int) -> int f) int { return f(1) }
+auto s :: apply(max)`)
+	assert.ErrorContains(t, err, "type mismatch for parameter f")
+
+	err = check(t, `auto f :: func[T, T](T a) T { return a }`)
+	assert.ErrorContains(t, err, "cannot redeclare type parameter T")
+
+	empty := `
+auto empty :: func[T]() [T] {
+    return [T]{}
+}
+`
+	err = check(t, empty+`auto s :: empty()`)
+	assert.ErrorContains(t, err, "cannot infer type parameter T")
+	assert.NoError(t, check(t, empty+`[int] s :: empty[int]()`))
+
+	// the arguments of unknown type may bind the type parameters at runtime
+	err = check(t, "import \"lib\"\n"+code+`auto s :: max(lib.value, lib.value)`)
+	assert.NoError(t, err)
+}
+
+func TestCheckMethods(t *testing.T) {
+	code := `
+type Rect :: {
+    float width :: 0.0
+    float height :: 0.0
+}
+func (Rect r) area() float {
+    return r.width * r.height
+}
+func (Rect r) scale(float factor :: 2.0) Rect {
+    return new Rect{ width :: r.width * factor, height :: r.height * factor }
+}
+auto rect :: new Rect{ width :: 2.0, height :: 3.0 }
+`
+	valid := code + `
+float a :: rect.area()
+float b :: rect.scale(factor: 0.5).area()
+func(float) -> Rect scale :: rect.scale
+`
+	assert.NoError(t, check(t, valid))
+
+	err := check(t, code+`string s :: rect.area()`)
+	assert.ErrorContains(t, err, "cannot assign value of type float to variable s declared as string")
+
+	err = check(t, code+`auto s :: rect.scale("a")`)
+	assert.ErrorContains(t, err, "type mismatch for parameter factor, expected float, got string")
+
+	err = check(t, code+`auto s :: rect.scale(size: 1.0)`)
+	assert.ErrorContains(t, err, "unknown argument [size]")
+
+	err = check(t, code+`auto s :: rect.perimeter()`)
+	assert.ErrorContains(t, err, "type Rect has no field [perimeter]")
+
+	err = check(t, code+`func (Rect r) width() float { return r.width }`)
+	assert.ErrorContains(t, err, "cannot declare method width, type Rect has a field named width")
+
+	err = check(t, code+`func (Rect r) area() float { return 0.0 }`)
+	assert.ErrorContains(t, err, "cannot redeclare method area of type Rect")
+
+	err = check(t, code+`func (int i) double() int { return i * 2 }`)
+	assert.ErrorContains(t, err, "cannot declare method double on type int, only object types can have methods")
+
+	err = check(t, code+`func (Rect r) name() string { return r.width }`)
+	assert.ErrorContains(t, err, "cannot return value of type float from function returning string")
+
+	for _, nested := range []string{
+		"auto f :: func() int {\nfunc (Rect r) double() Rect { return r.scale() }\nreturn 1\n}",
+		"if true {\nfunc (Rect r) double() Rect { return r.scale() }\n}",
+		"for int i :: 0; i < 2; i++ {\nfunc (Rect r) double() Rect { return r.scale() }\n}",
+	} {
+		err = check(t, code+nested)
+		assert.ErrorContains(t, err, "method double should be declared at the top level", nested)
+	}
+}
+
+func TestCheckMethodsCallLaterMethods(t *testing.T) {
+	code := `
+type Node :: {
+    int value :: 0
+}
+func (Node n) total() int {
+    return n.helper(2)
+}
+func (Node n) helper(int factor) int {
+    return n.value * factor
+}
+int total :: new Node{ value :: 3 }.total()
+`
+	assert.NoError(t, check(t, code))
+
+	err := check(t, strings.Replace(code, "n.helper(2)", `n.helper("2")`, 1))
+	assert.ErrorContains(t, err, "type mismatch for parameter factor, expected int, got string")
+
+	err = check(t, strings.Replace(code, "return n.helper(2)", "string s :: n.helper(2)\n    return 1", 1))
+	assert.ErrorContains(t, err, "cannot assign value of type int to variable s declared as string")
+
+	// at the top level, a method is only known once declared
+	err = check(t, `
+type Node :: {
+    int value :: 0
+}
+int early :: new Node{}.helper(1)
+func (Node n) helper(int factor) int {
+    return n.value * factor
+}
+`)
+	assert.ErrorContains(t, err, "type Node has no field [helper]")
+}
@@ -0,0 +1,116 @@
+package checker
+
+import (
+	"strings"
+
+	"github.com/dani-gouken/nomad/parser"
+	"github.com/dani-gouken/nomad/runtime/types"
+	"github.com/dani-gouken/nomad/tokenizer"
+)
+
+func (c *Checker) checkEnumDeclaration(stmt *parser.Stmt) {
+	name := stmt.Data[0]
+	enumType := types.NewEnumType()
+	for _, variantExpr := range stmt.Expr.Children {
+		if err := enumType.AddVariant(variantExpr.Token.Content); err != nil {
+			c.error(variantExpr.Token, err.Error())
+			continue
+		}
+		for _, field := range variantExpr.Children {
+			if err := enumType.AddField(field.Token.Content, c.resolveType(field.Children[0])); err != nil {
+				c.error(field.Token, err.Error())
+			}
+		}
+	}
+	if c.types.Has(name.Content) {
+		c.error(name, "cannot redeclare type %s", name.Content)
+		return
+	}
+	enumType.SetName(name.Content)
+	c.types.Add(enumType, name)
+}
+
+// variantType is the type of Enum#Variant: the enum itself for variants
+// without payload, the constructor of the variant otherwise.
+func (c *Checker) variantType(enumType *types.EnumType, token tokenizer.Token) typed {
+	variant, err := enumType.GetVariant(token.Content)
+	if err != nil {
+		c.error(token, err.Error())
+		return typed{}
+	}
+	if len(variant.Fields) == 0 {
+		return typed{t: enumType}
+	}
+	sig := &signature{ret: enumType, named: true}
+	for _, field := range variant.Fields {
+		sig.params = append(sig.params, param{name: field.Name, t: field.Type})
+	}
+	return typed{t: sig.asType(), sig: sig}
+}
+
+// checkMatch checks the arms of a match statement, which should cover every
+// variant of the enum unless it ends with an else arm.
+func (c *Checker) checkMatch(stmt *parser.Stmt) {
+	subject := c.checkExpr(stmt.Expr)
+	var enumType *types.EnumType
+	if subject.t != nil {
+		var err error
+		enumType, err = types.ToEnumType(subject.t)
+		if err != nil {
+			c.error(stmt.Expr.Token, "cannot match value of type %s, enum expected", subject.t.GetName())
+		}
+	}
+	matched := map[string]bool{}
+	hasElse := false
+	for _, arm := range stmt.Children {
+		c.scope = newScope(c.scope)
+		if arm.Kind == parser.STMT_KIND_ELSE {
+			hasElse = true
+		} else {
+			c.checkArm(enumType, arm, matched)
+		}
+		c.checkStmts(arm.Children)
+		c.scope = c.scope.parent
+	}
+	if enumType == nil || hasElse {
+		return
+	}
+	missing := []string{}
+	for _, variant := range enumType.GetVariants() {
+		if !matched[variant.Name] {
+			missing = append(missing, variant.Name)
+		}
+	}
+	if len(missing) > 0 {
+		c.error(stmt.Data[0], "non-exhaustive match on %s, missing variants: %s", enumType.GetName(), strings.Join(missing, ", "))
+	}
+}
+
+// checkArm declares the variables bound by an arm in the current scope.
+func (c *Checker) checkArm(enumType *types.EnumType, arm *parser.Stmt, matched map[string]bool) {
+	variantToken := arm.Data[0]
+	bindings := arm.Data[1:]
+	var fields []types.EnumField
+	if enumType != nil {
+		variant, err := enumType.GetVariant(variantToken.Content)
+		if err != nil {
+			c.error(variantToken, err.Error())
+		} else {
+			if matched[variant.Name] {
+				c.error(variantToken, "duplicate arm for variant %s", variant.Name)
+			}
+			matched[variant.Name] = true
+			fields = variant.Fields
+			if len(bindings) > len(fields) {
+				c.error(variantToken, "too many bindings for variant %s, %d fields declared, %d bound", variant.Name, len(fields), len(bindings))
+			}
+		}
+	}
+	for i, binding := range bindings {
+		var t types.RuntimeType
+		if i < len(fields) {
+			t = fields[i].Type
+		}
+		c.scope.symbols[binding.Content] = typed{t: t}
+	}
+}
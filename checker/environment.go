@@ -0,0 +1,156 @@
+package checker
+
+import (
+	"strings"
+
+	"github.com/dani-gouken/nomad/parser"
+	"github.com/dani-gouken/nomad/runtime/data"
+	"github.com/dani-gouken/nomad/runtime/types"
+	"github.com/dani-gouken/nomad/tokenizer"
+)
+
+// Environment provides the names a program uses without declaring them:
+// the builtins, the functions, values and types registered by the host,
+// the globals of the programs already run and the native modules. It is
+// implemented by the vm running the program.
+type Environment interface {
+	Types() *types.Registrar
+	Globals() map[string]data.RuntimeValue
+	NativeModules() map[string]data.RuntimeValue
+}
+
+// declareGlobals declares the globals of the environment in the root scope.
+func (c *Checker) declareGlobals() {
+	namespaces := map[types.RuntimeType]string{}
+	for name, namespace := range c.env.NativeModules() {
+		namespaces[namespace.RuntimeType] = name
+	}
+	for name, value := range c.env.Globals() {
+		symbol := c.valueType(value)
+		symbol.module = namespaces[value.RuntimeType]
+		c.scope.symbols[name] = symbol
+	}
+}
+
+// declarePending records the names and the methods declared at the top
+// level of a program, which the functions can use before their declaration.
+func (c *Checker) declarePending(stmts []*parser.Stmt) {
+	for _, stmt := range stmts {
+		switch stmt.Kind {
+		case parser.STMT_KIND_VAR_DECLARATION, parser.STMT_KIND_CONST_DECLARATION,
+			parser.STMT_KIND_TYPE_DECLARATION, parser.STMT_KIND_ENUM_DECLARATION:
+			c.pending[stmt.Data[0].Content] = true
+		case parser.STMT_KIND_IMPORT:
+			_, namespace := parser.ImportNamespace(stmt)
+			c.pending[namespace] = true
+		case parser.STMT_KIND_METHOD_DECLARATION:
+			receiverType, methodName := stmt.Data[0].Content, stmt.Data[2].Content
+			if c.pendingMethods[receiverType] == nil {
+				c.pendingMethods[receiverType] = map[string]*parser.Stmt{}
+			}
+			if _, ok := c.pendingMethods[receiverType][methodName]; !ok {
+				c.pendingMethods[receiverType][methodName] = stmt
+			}
+		}
+	}
+}
+
+// checkImport binds the namespace of an import. The members of the native
+// modules are known, the ones of the files are left to the vm.
+func (c *Checker) checkImport(stmt *parser.Stmt) {
+	path, namespace := parser.ImportNamespace(stmt)
+	module, ok := c.env.NativeModules()[path]
+	if !ok {
+		c.scope.symbols[namespace] = typed{}
+		return
+	}
+	c.scope.symbols[namespace] = typed{t: module.RuntimeType, module: path}
+}
+
+// valueType returns the static type of a value provided by the environment,
+// the functions keeping the name of their parameters.
+func (c *Checker) valueType(value data.RuntimeValue) typed {
+	if f, ok := value.Value.(*data.RuntimeFunc); ok {
+		sig := nativeSignature(f.Signature)
+		return typed{t: sig.asType(), sig: sig}
+	}
+	return typed{t: known(value.RuntimeType)}
+}
+
+func nativeSignature(s data.FuncSignature) *signature {
+	sig := &signature{ret: known(s.ReturnType), typeParams: s.TypeParams, named: true}
+	for _, p := range s.Parameters {
+		sig.params = append(sig.params, param{name: p.Name, t: known(p.RuntimeType), hasDefault: p.HasDefault})
+	}
+	return sig
+}
+
+// known returns t, or nil when the values of t are only known at runtime.
+func known(t types.RuntimeType) types.RuntimeType {
+	if types.IsDynamicType(t) {
+		return nil
+	}
+	return t
+}
+
+// lookup returns the symbol name, an undeclared name being reported unless
+// it is declared later at the top level and used in a function.
+func (c *Checker) lookup(name tokenizer.Token) (typed, bool) {
+	symbol, ok := c.scope.lookup(name.Content)
+	if ok {
+		return symbol, true
+	}
+	if !c.isPending(name.Content) {
+		c.error(name, "undeclared variable [%s]", name.Content)
+	}
+	return typed{}, false
+}
+
+func (c *Checker) isPending(name string) bool {
+	return c.pending[name] && len(c.returnTypes) > 0
+}
+
+// isTypeName reports whether name can be used as a type value.
+func (c *Checker) isTypeName(name string) bool {
+	if _, ok := c.lookupTypeParam(name); ok {
+		return true
+	}
+	return c.types.Has(name) || c.env.Types().Has(name)
+}
+
+// lookupType returns the type named by token, nil when it is only known at
+// runtime. Unknown names are reported.
+func (c *Checker) lookupType(token tokenizer.Token) types.RuntimeType {
+	name := token.Content
+	if typeParam, ok := c.lookupTypeParam(name); ok {
+		if typeParam == nil {
+			return nil
+		}
+		return typeParam
+	}
+	// namespace.Type, the namespace being bound by an import
+	if namespace, typeName, found := strings.Cut(name, "."); found {
+		symbol, ok := c.scope.lookup(namespace)
+		if ok && symbol.module == "" {
+			return nil
+		}
+		if ok && c.env.Types().Has(symbol.module+"."+typeName) {
+			return c.env.Types().GetOrPanic(symbol.module + "." + typeName)
+		}
+		if !ok && c.isPending(namespace) {
+			return nil
+		}
+		c.error(token, "unknown type [%s]", name)
+		return nil
+	}
+	if c.types.Has(name) {
+		return c.types.GetOrPanic(name)
+	}
+	if c.env.Types().Has(name) {
+		return c.env.Types().GetOrPanic(name)
+	}
+	if !c.isPending(name) {
+		c.error(token, "unknown type [%s]", name)
+	}
+	return nil
+}
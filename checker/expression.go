@@ -0,0 +1,385 @@
+package checker
+
+import (
+	"strings"
+
+	"github.com/dani-gouken/nomad/parser"
+	"github.com/dani-gouken/nomad/runtime/data"
+	"github.com/dani-gouken/nomad/runtime/types"
+	"github.com/dani-gouken/nomad/tokenizer"
+)
+
+func (c *Checker) scalar(name string) typed {
+	return typed{t: c.types.GetOrPanic(name)}
+}
+
+func (c *Checker) checkExpr(expr parser.Expr) typed {
+	switch expr.Kind {
+	case parser.EXPR_KIND_CONSTANT:
+		switch expr.Token.Kind {
+		case tokenizer.TOKEN_KIND_TRUE, tokenizer.TOKEN_KIND_FALSE:
+			return c.scalar(types.BOOL_TYPE)
+		case tokenizer.TOKEN_KIND_STRING_LIT:
+			return c.scalar(types.STRING_TYPE)
+		case tokenizer.TOKEN_KIND_NONE:
+			return c.scalar(types.NONE_TYPE)
+		case tokenizer.TOKEN_KIND_NUM_LIT:
+			if strings.Contains(expr.Token.Content, ".") {
+				return c.scalar(types.FLOAT_TYPE)
+			}
+			return c.scalar(types.INT_TYPE)
+		}
+	case parser.EXPR_KIND_ID:
+		if _, ok := c.scope.lookup(expr.Token.Content); !ok && c.isTypeName(expr.Token.Content) {
+			return c.scalar(types.TYPE_TYPE)
+		}
+		symbol, _ := c.lookup(expr.Token)
+		return symbol
+	case parser.EXPR_KIND_NOT:
+		c.expectBool(c.checkExpr(expr.Children[0]), expr.Token, "operand of not(!)")
+		return c.scalar(types.BOOL_TYPE)
+	case parser.EXPR_KIND_NEGATIVE:
+		value := c.checkExpr(expr.Children[0])
+		if value.t != nil && !isNumber(value.t) {
+			c.error(expr.Token, "unsupported operand negative (-) on type %s", value.t.GetName())
+			return typed{}
+		}
+		return value
+	case parser.EXPR_KIND_LEN:
+		value := c.checkExpr(expr.Children[0])
+		if value.t != nil && !types.IsArrayType(value.t) && !types.IsMapType(value.t) && types.ExpectedStringType(value.t) != nil {
+			c.error(expr.Token, "unsupported operand len on type %s", value.t.GetName())
+		}
+		return c.scalar(types.INT_TYPE)
+	case parser.EXPR_KIND_ADDITION:
+		return c.checkArithmetic(expr, "+", true)
+	case parser.EXPR_KIND_SUBSTRACTION:
+		return c.checkArithmetic(expr, "-", false)
+	case parser.EXPR_KIND_MULTIPLICATION:
+		return c.checkArithmetic(expr, "*", false)
+	case parser.EXPR_KIND_DIVISION:
+		return c.checkArithmetic(expr, "/", false)
+	case parser.EXPR_KIND_MODULO, parser.EXPR_KIND_BIT_XOR, parser.EXPR_KIND_SHIFT_LEFT, parser.EXPR_KIND_SHIFT_RIGHT:
+		return c.checkBitwise(expr, false)
+	case parser.EXPR_KIND_BIT_AND, parser.EXPR_KIND_BIT_OR:
+		return c.checkBitwise(expr, true)
+	case parser.EXPR_KIND_INTERPOLATION:
+		for _, part := range expr.Children {
+			value := c.checkExpr(part)
+			if value.t != nil && value.t.GetName() == types.VOID_TYPE {
+				c.error(part.Token, "cannot interpolate value of type %s", value.t.GetName())
+			}
+		}
+		return c.scalar(types.STRING_TYPE)
+	case parser.EXPR_KIND_BIT_NOT:
+		value := c.checkExpr(expr.Children[0])
+		if value.t != nil && types.ExpectedIntType(value.t) != nil {
+			c.error(expr.Token, "unsupported operand bitwise not(~) on type %s", value.t.GetName())
+		}
+		return c.scalar(types.INT_TYPE)
+	case parser.EXPR_KIND_LESS_THAN, parser.EXPR_LESS_THAN_OR_EQ, parser.EXPR_KIND_MORE_THAN, parser.EXPR_KIND_MORE_THAN_OR_EQ:
+		c.checkArithmetic(expr, expr.Token.Content, false)
+		return c.scalar(types.BOOL_TYPE)
+	case parser.EXPR_KIND_EQ:
+		c.checkExpr(expr.Children[0])
+		c.checkExpr(expr.Children[1])
+		return c.scalar(types.BOOL_TYPE)
+	case parser.EXPR_KIND_AND, parser.EXPR_KIND_OR:
+		c.expectBool(c.checkExpr(expr.Children[0]), expr.Children[0].Token, "operand of "+expr.Token.Content)
+		c.expectBool(c.checkExpr(expr.Children[1]), expr.Children[1].Token, "operand of "+expr.Token.Content)
+		return c.scalar(types.BOOL_TYPE)
+	case parser.EXPR_KIND_RIGHT_INCREMENT, parser.EXPR_KIND_RIGHT_DECREMENT:
+		value := c.checkExpr(expr.Children[0])
+		if value.t != nil && types.ExpectedIntType(value.t) != nil {
+			c.error(expr.Token, "cannot increment or decrement value of type %s, int expected", value.t.GetName())
+		}
+		return c.scalar(types.INT_TYPE)
+	case parser.EXPR_KIND_ARRAY:
+		subtype := c.resolveType(expr.Children[0])
+		for _, item := range expr.Children[1].Children {
+			value := c.checkExpr(item)
+			if err := assignable(subtype, value.t); err != nil {
+				c.error(item.Token, "cannot push value of type %s to array of %s", value.t.GetName(), subtype.GetName())
+			}
+		}
+		if subtype == nil {
+			return typed{}
+		}
+		return typed{t: types.NewArrayType(subtype)}
+	case parser.EXPR_KIND_ARRAY_ACCESS:
+		return c.checkArrayAccess(expr)
+	case parser.EXPR_KIND_TYPE_ARG_LIST:
+		callee := c.checkExpr(expr.Children[0])
+		if callee.t != nil && !types.IsFuncType(callee.t) {
+			c.error(expr.Token, "cannot instantiate value of type %s, generic function expected", callee.t.GetName())
+			return typed{}
+		}
+		return c.checkInstantiation(callee, expr.Children[1:], expr)
+	case parser.EXPR_KIND_MAP:
+		return c.checkMap(expr)
+	case parser.EXPR_KIND_IN:
+		key := c.checkExpr(expr.Children[0])
+		container := c.checkExpr(expr.Children[1])
+		mapType := c.expectMap(container, expr.Token, "in")
+		if mapType != nil {
+			c.checkKey(mapType, key, expr.Children[0].Token)
+		}
+		return c.scalar(types.BOOL_TYPE)
+	case parser.EXPR_KIND_KEYS:
+		mapType := c.expectMap(c.checkExpr(expr.Children[0]), expr.Token, "keys")
+		if mapType == nil {
+			return typed{}
+		}
+		return typed{t: types.NewArrayType(mapType.GetKeyType())}
+	case parser.EXPR_KIND_SOME:
+		value := c.checkExpr(expr.Children[0])
+		if value.t != nil && !types.IsOptionalType(value.t) && !types.IsNoneType(value.t) {
+			c.error(expr.Token, "Some expects an optional value, got %s", value.t.GetName())
+		}
+		return c.scalar(types.BOOL_TYPE)
+	case parser.EXPR_KIND_UNWRAP:
+		value := c.checkExpr(expr.Children[0])
+		if value.t == nil {
+			return typed{}
+		}
+		optionalType, err := types.ToOptionalType(value.t)
+		if err != nil {
+			c.error(expr.Token, "cannot unwrap value of type %s, optional expected", value.t.GetName())
+			return typed{}
+		}
+		return typed{t: optionalType.GetSubtype()}
+	case parser.EXPR_KIND_FUNC:
+		sig := c.funcSignature(expr)
+		c.checkFuncBody(expr, sig)
+		return typed{t: sig.asType(), sig: sig}
+	case parser.EXPR_KIND_FUNC_CALL:
+		return c.checkCall(expr)
+	case parser.EXPR_KIND_OBJ:
+		return c.checkObject(expr)
+	case parser.EXPR_KIND_OBJ_ACCESS:
+		object := c.checkExpr(expr.Children[0])
+		return c.fieldType(object.t, expr.Token)
+	case parser.EXPR_KIND_OBJ_DEFAULT_ACCESS:
+		t := c.resolveType(expr.Children[0])
+		enumType, err := types.ToEnumType(t)
+		if err == nil {
+			return c.variantType(enumType, expr.Token)
+		}
+		return c.fieldType(t, expr.Token)
+	case parser.EXPR_KIND_TYPE, parser.EXPR_KIND_TYPE_ARRAY, parser.EXPR_KIND_TYPE_FUNC, parser.EXPR_KIND_TYPE_OBJ, parser.EXPR_KIND_TYPE_MAP, parser.EXPR_KIND_TYPE_OPTIONAL:
+		c.resolveType(expr)
+		return c.scalar(types.TYPE_TYPE)
+	}
+	return typed{}
+}
+
+func isNumber(t types.RuntimeType) bool {
+	return types.IsNumericType(t)
+}
+
+// numberType is the type of an arithmetic between two numbers: an int
+// operand is promoted to float when the other one is a float.
+func (c *Checker) numberType(lhs types.RuntimeType, rhs types.RuntimeType) types.RuntimeType {
+	for _, name := range []string{types.NUM_TYPE, types.FLOAT_TYPE} {
+		if lhs.GetName() == name || rhs.GetName() == name {
+			return c.types.GetOrPanic(name)
+		}
+	}
+	return lhs
+}
+
+// checkArithmetic checks the operands of a binary operator, which should
+// be numbers (or strings of the same type, for the concatenation).
+func (c *Checker) checkArithmetic(expr parser.Expr, symbol string, allowString bool) typed {
+	lhs := c.checkExpr(expr.Children[0])
+	rhs := c.checkExpr(expr.Children[1])
+	for _, operand := range []typed{lhs, rhs} {
+		if operand.t == nil {
+			continue
+		}
+		if !isNumber(operand.t) && !(allowString && types.ExpectedStringType(operand.t) == nil) {
+			c.error(expr.Token, "unsupported operand %s for type %s", symbol, operand.t.GetName())
+			return typed{}
+		}
+	}
+	if lhs.t == nil || rhs.t == nil {
+		return typed{}
+	}
+	if isNumber(lhs.t) && isNumber(rhs.t) {
+		return typed{t: c.numberType(lhs.t, rhs.t)}
+	}
+	if err := lhs.t.Match(rhs.t); err != nil {
+		c.error(expr.Token, "mismatched operand types for %s, %s and %s", symbol, lhs.t.GetName(), rhs.t.GetName())
+		return typed{}
+	}
+	return lhs
+}
+
+// checkBitwise checks the operators on integers, & and | being also
+// allowed between booleans when allowBool is set.
+func (c *Checker) checkBitwise(expr parser.Expr, allowBool bool) typed {
+	symbol := expr.Token.Content
+	lhs := c.checkExpr(expr.Children[0])
+	rhs := c.checkExpr(expr.Children[1])
+	for _, operand := range []typed{lhs, rhs} {
+		if operand.t == nil {
+			continue
+		}
+		if types.ExpectedIntType(operand.t) != nil && !(allowBool && types.ExpectedBoolType(operand.t) == nil) {
+			c.error(expr.Token, "unsupported operand %s for type %s", symbol, operand.t.GetName())
+			return typed{}
+		}
+	}
+	if lhs.t == nil || rhs.t == nil {
+		return typed{}
+	}
+	if err := lhs.t.Match(rhs.t); err != nil {
+		c.error(expr.Token, "mismatched operand types for %s, %s and %s", symbol, lhs.t.GetName(), rhs.t.GetName())
+		return typed{}
+	}
+	return lhs
+}
+
+func (c *Checker) checkArrayAccess(expr parser.Expr) typed {
+	container := c.checkExpr(expr.Children[0])
+	if funcType, err := types.ToFuncType(container.t); err == nil && funcType.IsGeneric() {
+		indexExpr := expr.Children[1]
+		if indexExpr.Kind == parser.EXPR_KIND_ID {
+			indexExpr = parser.Expr{Kind: parser.EXPR_KIND_TYPE, Token: indexExpr.Token}
+		}
+		return c.checkInstantiation(container, []parser.Expr{indexExpr}, expr)
+	}
+	index := c.checkExpr(expr.Children[1])
+	if container.t == nil {
+		return typed{}
+	}
+	mapType, err := types.ToMapType(container.t)
+	if err == nil {
+		c.checkKey(mapType, index, expr.Children[1].Token)
+		return typed{t: mapType.GetValueType()}
+	}
+	// strings are indexed by character, a character being a string
+	elementType := container.t
+	arrayType, err := types.ToArrayType(container.t)
+	if err == nil {
+		elementType = arrayType.GetSubtype()
+	} else if types.ExpectedStringType(container.t) != nil {
+		c.error(expr.Token, "cannot index value of type %s", container.t.GetName())
+		return typed{}
+	}
+	if index.t != nil && types.ExpectedIntType(index.t) != nil {
+		c.error(expr.Children[1].Token, "index should be an int, got %s", index.t.GetName())
+	}
+	return typed{t: elementType}
+}
+
+func (c *Checker) checkMap(expr parser.Expr) typed {
+	t := c.resolveType(expr.Children[0])
+	mapType, _ := t.(*types.MapType)
+	for _, entry := range expr.Children[1].Children {
+		key := c.checkExpr(entry.Children[0])
+		value := c.checkExpr(entry.Children[1])
+		if mapType == nil {
+			continue
+		}
+		c.checkKey(mapType, key, entry.Children[0].Token)
+		if err := assignable(mapType.GetValueType(), value.t); err != nil {
+			c.error(entry.Children[1].Token, "invalid value, expected %s, got %s", mapType.GetValueType().GetName(), value.t.GetName())
+		}
+	}
+	if mapType == nil {
+		return typed{}
+	}
+	return typed{t: mapType}
+}
+
+func (c *Checker) expectMap(value typed, token tokenizer.Token, operand string) *types.MapType {
+	if value.t == nil {
+		return nil
+	}
+	mapType, err := types.ToMapType(value.t)
+	if err != nil {
+		c.error(token, "unsupported operand %s on type %s", operand, value.t.GetName())
+		return nil
+	}
+	return mapType
+}
+
+func (c *Checker) checkKey(mapType *types.MapType, key typed, token tokenizer.Token) {
+	if err := match(mapType.GetKeyType(), key.t); err != nil {
+		c.error(token, "invalid key, expected %s, got %s", mapType.GetKeyType().GetName(), key.t.GetName())
+	}
+}
+
+func (c *Checker) checkObject(expr parser.Expr) typed {
+	t := c.resolveType(parser.Expr{Kind: parser.EXPR_KIND_TYPE, Token: expr.Token})
+	var objectType *types.ObjectType
+	if t != nil {
+		var err error
+		objectType, err = types.ToObjectType(t)
+		if err != nil {
+			c.error(expr.Token, "cannot instantiate %s, object type expected", t.GetName())
+		}
+	}
+	for _, field := range expr.Children {
+		value := c.checkExpr(field.Children[0])
+		if objectType == nil {
+			continue
+		}
+		fieldType, err := objectType.GetFieldType(field.Token.Content)
+		if err != nil {
+			c.error(field.Token, "type %s has no field [%s]", objectType.GetName(), field.Token.Content)
+			continue
+		}
+		if err := match(fieldType, value.t); err != nil {
+			c.error(field.Children[0].Token, "cannot assign value of type %s to field %s of type %s", value.t.GetName(), field.Token.Content, fieldType.GetName())
+		}
+	}
+	if objectType == nil {
+		return typed{}
+	}
+	return typed{t: objectType}
+}
+
+func (c *Checker) fieldType(t types.RuntimeType, field tokenizer.Token) typed {
+	if t == nil {
+		return typed{}
+	}
+	if types.ExpectedStringType(t) == nil {
+		return c.stringMethod(field)
+	}
+	objectType, err := types.ToObjectType(t)
+	if err != nil {
+		c.error(field, "cannot access field [%s] of value of type %s", field.Content, t.GetName())
+		return typed{}
+	}
+	fieldType, err := objectType.GetFieldType(field.Content)
+	if err != nil {
+		if m, ok := c.method(objectType, field.Content); ok {
+			return m
+		}
+		if m, ok := c.pendingMethod(objectType, field.Content); ok {
+			return m
+		}
+		c.error(field, "type %s has no field [%s]", objectType.GetName(), field.Content)
+		return typed{}
+	}
+	// the members of a native module keep the name of their parameters
+	if value, ok := objectType.GetDefaults()[field.Content].(data.RuntimeValue); ok {
+		if _, ok := value.Value.(*data.RuntimeFunc); ok {
+			return c.valueType(value)
+		}
+	}
+	return typed{t: known(fieldType)}
+}
+
+// stringMethod returns the signature of a method of the string scalar.
+func (c *Checker) stringMethod(name tokenizer.Token) typed {
+	method, err := c.stringMethods.Bind(data.RuntimeValue{}, name.Content)
+	if err != nil {
+		c.error(name, "%s", err.Error())
+		return typed{}
+	}
+	sig := nativeSignature(method.Signature)
+	return typed{t: sig.asType(), sig: sig}
+}
@@ -0,0 +1,167 @@
+package checker
+
+import (
+	"strconv"
+
+	"github.com/dani-gouken/nomad/parser"
+	"github.com/dani-gouken/nomad/runtime/types"
+)
+
+// asType returns the function type of the signature, nil when
+// one of its types is unknown.
+func (s *signature) asType() types.RuntimeType {
+	if s.ret == nil {
+		return nil
+	}
+	funcType := types.NewFuncType()
+	for _, p := range s.params {
+		if p.t == nil {
+			return nil
+		}
+		funcType.AddParam(p.t)
+	}
+	funcType.SetRet(s.ret)
+	funcType.SetTypeParams(s.typeParams)
+	return funcType
+}
+
+func signatureOf(value typed) *signature {
+	if value.sig != nil {
+		return value.sig
+	}
+	funcType, err := types.ToFuncType(value.t)
+	if err != nil || funcType.GetRet() == nil {
+		return nil
+	}
+	sig := &signature{ret: funcType.GetRet(), typeParams: funcType.GetTypeParams()}
+	for _, t := range funcType.GetParams() {
+		sig.params = append(sig.params, param{t: t})
+	}
+	return sig
+}
+
+// funcSignature resolves the parameters and the return type of a function expression.
+func (c *Checker) funcSignature(expr parser.Expr) *signature {
+	typeParams, end := c.declareTypeParams(expr)
+	defer end()
+	sig := &signature{
+		ret:        c.resolveType(expr.Children[1]),
+		typeParams: typeParams,
+		named:      true,
+	}
+	for _, paramExpr := range expr.Children[0].Children {
+		p := param{
+			name:       paramExpr.Token.Content,
+			t:          c.resolveType(paramExpr.Children[0]),
+			hasDefault: len(paramExpr.Children) == 2,
+		}
+		if p.hasDefault {
+			value := c.checkExpr(paramExpr.Children[1])
+			if err := assignable(p.t, value.t); err != nil {
+				c.error(paramExpr.Children[1].Token, "cannot use value of type %s as default of parameter %s of type %s", value.t.GetName(), p.name, p.t.GetName())
+			}
+		}
+		sig.params = append(sig.params, p)
+	}
+	return sig
+}
+
+func (c *Checker) checkFuncBody(expr parser.Expr, sig *signature) {
+	c.scope = newScope(c.scope)
+	// the values of a type parameter are unknown in the body
+	typeParams := map[string]*types.TypeVar{}
+	for _, typeParam := range sig.typeParams {
+		typeParams[typeParam.GetName()] = nil
+	}
+	c.typeParams = append(c.typeParams, typeParams)
+	defer func() {
+		c.typeParams = c.typeParams[:len(c.typeParams)-1]
+	}()
+	sig = sig.instantiate(types.Bindings{})
+	for _, p := range sig.params {
+		c.scope.symbols[p.name] = typed{t: p.t}
+	}
+	c.returnTypes = append(c.returnTypes, sig.ret)
+	c.checkStmts(expr.Block)
+	c.returnTypes = c.returnTypes[:len(c.returnTypes)-1]
+	c.scope = c.scope.parent
+}
+
+type argument struct {
+	expr  parser.Expr
+	value typed
+}
+
+// checkCall checks the arguments of a call, binding them to the parameters
+// the same way the vm does: by name first, then by position, then default.
+func (c *Checker) checkCall(expr parser.Expr) typed {
+	callee := c.checkExpr(expr.Children[0])
+	positional := []argument{}
+	named := map[string]argument{}
+	for _, argExpr := range expr.Children[1].Children {
+		arg := argument{expr: argExpr, value: c.checkExpr(argExpr.Children[0])}
+		if argExpr.Kind == parser.EXPR_KIND_FUNC_NAMED_ARG {
+			named[argExpr.Token.Content] = arg
+		} else {
+			positional = append(positional, arg)
+		}
+	}
+
+	if callee.t != nil && !types.IsFuncType(callee.t) {
+		c.error(expr.Token, "cannot call value of type %s", callee.t.GetName())
+		return typed{}
+	}
+	sig := signatureOf(callee)
+	if sig == nil {
+		return typed{}
+	}
+	// the return type of a generic function is unknown until it is instantiated
+	ret := sig.instantiate(types.Bindings{}).ret
+	if len(positional)+len(named) > len(sig.params) {
+		c.error(expr.Token, "too many arguments, %d declared, %d passed", len(sig.params), len(positional)+len(named))
+		return typed{t: ret}
+	}
+	if !sig.named && len(named) > 0 {
+		return typed{t: ret}
+	}
+	bound := map[int]argument{}
+	for i, p := range sig.params {
+		arg, ok := named[p.name]
+		if ok {
+			delete(named, p.name)
+		} else if len(positional) > 0 {
+			arg = positional[0]
+			positional = positional[1:]
+		} else {
+			if !p.hasDefault && sig.named {
+				c.error(expr.Token, "missing argument [%s]", p.name)
+			}
+			continue
+		}
+		bound[i] = arg
+	}
+	if len(sig.typeParams) > 0 {
+		var uninferred []*types.TypeVar
+		sig, uninferred = inferTypeArgs(sig, bound)
+		for _, typeParam := range uninferred {
+			c.error(expr.Token, "cannot infer type parameter %s", typeParam.GetName())
+		}
+	}
+	for i, p := range sig.params {
+		arg, ok := bound[i]
+		if !ok {
+			continue
+		}
+		if err := assignable(p.t, arg.value.t); err != nil {
+			name := p.name
+			if !sig.named {
+				name = strconv.Itoa(i)
+			}
+			c.error(arg.expr.Token, "type mismatch for parameter %s, expected %s, got %s", name, p.t.GetName(), arg.value.t.GetName())
+		}
+	}
+	for name, arg := range named {
+		c.error(arg.expr.Token, "unknown argument [%s]", name)
+	}
+	return typed{t: sig.ret}
+}
@@ -0,0 +1,118 @@
+package checker
+
+import (
+	"github.com/dani-gouken/nomad/parser"
+	"github.com/dani-gouken/nomad/runtime/types"
+)
+
+// declareTypeParams puts the type parameters of a generic function
+// expression in scope, until the returned function is called. They are only
+// bound in the signature: in the body, the values of a type parameter are
+// unknown and left to the vm.
+func (c *Checker) declareTypeParams(expr parser.Expr) ([]*types.TypeVar, func()) {
+	if len(expr.Children) < 3 {
+		return nil, func() {}
+	}
+	typeParams := []*types.TypeVar{}
+	names := map[string]*types.TypeVar{}
+	for _, typeParamExpr := range expr.Children[2].Children {
+		name := typeParamExpr.Token.Content
+		if _, ok := names[name]; ok {
+			c.error(typeParamExpr.Token, "cannot redeclare type parameter %s", name)
+			continue
+		}
+		typeParam := types.NewTypeVar(name)
+		names[name] = typeParam
+		typeParams = append(typeParams, typeParam)
+	}
+	c.typeParams = append(c.typeParams, names)
+	return typeParams, func() {
+		c.typeParams = c.typeParams[:len(c.typeParams)-1]
+	}
+}
+
+// lookupTypeParam returns the type parameter name, nil in the body of the
+// function declaring it.
+func (c *Checker) lookupTypeParam(name string) (*types.TypeVar, bool) {
+	for i := len(c.typeParams) - 1; i >= 0; i-- {
+		typeParam, ok := c.typeParams[i][name]
+		if ok {
+			return typeParam, true
+		}
+	}
+	return nil, false
+}
+
+// instantiate returns the signature of a generic function once its type
+// parameters are bound. Types still referring to a type parameter are unknown.
+func (s *signature) instantiate(bindings types.Bindings) *signature {
+	substitute := func(t types.RuntimeType) types.RuntimeType {
+		if t == nil {
+			return nil
+		}
+		t = types.Substitute(t, bindings)
+		if types.HasTypeVar(t) {
+			return nil
+		}
+		return t
+	}
+	instance := &signature{
+		ret:   substitute(s.ret),
+		named: s.named,
+	}
+	for _, p := range s.params {
+		p.t = substitute(p.t)
+		instance.params = append(instance.params, p)
+	}
+	return instance
+}
+
+// checkInstantiation checks the explicit instantiation of a generic
+// function, like max[int] or pair[string, int].
+func (c *Checker) checkInstantiation(callee typed, typeArgExprs []parser.Expr, expr parser.Expr) typed {
+	sig := signatureOf(callee)
+	if sig == nil {
+		return typed{}
+	}
+	if len(sig.typeParams) != len(typeArgExprs) {
+		c.error(expr.Token, "expected %d type arguments, got %d", len(sig.typeParams), len(typeArgExprs))
+		return typed{}
+	}
+	bindings := types.Bindings{}
+	for i, typeArgExpr := range typeArgExprs {
+		t := c.resolveType(typeArgExpr)
+		if t != nil {
+			bindings[sig.typeParams[i]] = t
+		}
+	}
+	instance := sig.instantiate(bindings)
+	return typed{t: instance.asType(), sig: instance}
+}
+
+// inferTypeArgs instantiates the signature of a generic function from the
+// types of the arguments bound to its parameters. It also returns the type
+// parameters bound by none of them, once every argument type is known.
+func inferTypeArgs(sig *signature, bound map[int]argument) (*signature, []*types.TypeVar) {
+	bindings := types.Bindings{}
+	known := true
+	// parameters are unified in order, like in the vm
+	for i, p := range sig.params {
+		arg, ok := bound[i]
+		if !ok || p.t == nil || arg.value.t == nil {
+			// the vm also infers from the defaults, whose types are unknown
+			known = known && p.t != nil && !types.HasTypeVar(p.t)
+			continue
+		}
+		// mismatches are reported against the instantiated parameters
+		if err := types.Unify(p.t, arg.value.t, bindings); err != nil {
+			known = false
+		}
+	}
+	uninferred := []*types.TypeVar{}
+	for _, typeParam := range sig.typeParams {
+		if _, ok := bindings[typeParam]; !ok && known {
+			uninferred = append(uninferred, typeParam)
+		}
+	}
+	return sig.instantiate(bindings), uninferred
+}
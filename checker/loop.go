@@ -0,0 +1,45 @@
+package checker
+
+import (
+	"github.com/dani-gouken/nomad/parser"
+	"github.com/dani-gouken/nomad/runtime/types"
+)
+
+// checkForIn checks a loop over a value, the item and the index being
+// visible in the body of the loop only. A loop binding two names over a map
+// binds its keys and its values.
+func (c *Checker) checkForIn(stmt *parser.Stmt) {
+	iterable := c.checkExpr(stmt.Expr)
+	itemType := c.itemType(iterable.t, stmt)
+	var indexType types.RuntimeType = c.types.GetOrPanic(types.INT_TYPE)
+	if iterable.t == nil {
+		indexType = nil
+	} else if mapType, err := types.ToMapType(iterable.t); err == nil && len(stmt.Data) > 1 {
+		itemType, indexType = mapType.GetValueType(), mapType.GetKeyType()
+	}
+	c.scope = newScope(c.scope)
+	c.scope.symbols[stmt.Data[0].Content] = typed{t: itemType}
+	if len(stmt.Data) > 1 {
+		c.scope.symbols[stmt.Data[1].Content] = typed{t: indexType}
+	}
+	c.checkStmts(stmt.Children)
+	c.scope = c.scope.parent
+}
+
+// itemType returns the type of the items produced by iterating over a value of type t.
+func (c *Checker) itemType(t types.RuntimeType, stmt *parser.Stmt) types.RuntimeType {
+	if t == nil {
+		return nil
+	}
+	if arrayType, err := types.ToArrayType(t); err == nil {
+		return arrayType.GetSubtype()
+	}
+	if mapType, err := types.ToMapType(t); err == nil {
+		return mapType.GetKeyType()
+	}
+	if scalarType, err := types.ToScalarType(t); err == nil && scalarType.IsString() {
+		return t
+	}
+	c.error(stmt.Expr.Token, "cannot iterate over value of type %s", t.GetName())
+	return nil
+}
@@ -0,0 +1,93 @@
+package checker
+
+import (
+	"fmt"
+
+	"github.com/dani-gouken/nomad/parser"
+	"github.com/dani-gouken/nomad/runtime/data"
+	"github.com/dani-gouken/nomad/runtime/types"
+)
+
+// checkMethodDeclaration declares the signature of a method before checking
+// its body, so the method can call itself. Methods are declared once, at the
+// top level of the program.
+func (c *Checker) checkMethodDeclaration(stmt *parser.Stmt) {
+	receiverType, receiverName, methodName := stmt.Data[0], stmt.Data[1], stmt.Data[2]
+	if c.blocks > 0 {
+		c.error(methodName, "method %s should be declared at the top level", methodName.Content)
+	}
+	sig := c.funcSignature(stmt.Expr)
+
+	var objectType *types.ObjectType
+	if t := c.lookupType(receiverType); t != nil {
+		var err error
+		objectType, err = types.ToObjectType(t)
+		if err != nil {
+			c.error(receiverType, "cannot declare method %s on type %s, only object types can have methods", methodName.Content, t.GetName())
+		} else if err := c.declareMethod(objectType, methodName.Content, sig); err != nil {
+			c.error(methodName, "%s", err.Error())
+		}
+	}
+
+	c.scope = newScope(c.scope)
+	receiver := typed{}
+	if objectType != nil {
+		receiver.t = objectType
+	}
+	c.scope.symbols[receiverName.Content] = receiver
+	c.checkFuncBody(stmt.Expr, sig)
+	c.scope = c.scope.parent
+}
+
+// declareMethod records a method of objectType, which is left untouched:
+// the vm attaches the method when the declaration runs.
+func (c *Checker) declareMethod(objectType *types.ObjectType, name string, sig *signature) error {
+	if _, err := objectType.GetFieldType(name); err == nil {
+		return fmt.Errorf("cannot declare method %s, type %s has a field named %s", name, objectType.GetName(), name)
+	}
+	if _, ok := c.method(objectType, name); ok {
+		return fmt.Errorf("cannot redeclare method %s of type %s", name, objectType.GetName())
+	}
+	if c.methods[objectType] == nil {
+		c.methods[objectType] = map[string]*signature{}
+	}
+	c.methods[objectType][name] = sig
+	return nil
+}
+
+// method returns the signature of a method of objectType, declared by the
+// program or by the programs already run.
+func (c *Checker) method(objectType *types.ObjectType, name string) (typed, bool) {
+	if sig, ok := c.methods[objectType][name]; ok {
+		return typed{t: sig.asType(), sig: sig}, true
+	}
+	m, ok := objectType.GetMethod(name)
+	if !ok {
+		return typed{}, false
+	}
+	f, ok := m.(*data.RuntimeFunc)
+	if !ok {
+		return typed{}, false
+	}
+	sig := nativeSignature(f.Signature)
+	return typed{t: sig.asType(), sig: sig}, true
+}
+
+// pendingMethod returns the signature of a method of objectType declared
+// later in the program, which functions can call before its declaration.
+func (c *Checker) pendingMethod(objectType *types.ObjectType, name string) (typed, bool) {
+	stmt, ok := c.pendingMethods[objectType.GetName()][name]
+	if !ok || len(c.returnTypes) == 0 {
+		return typed{}, false
+	}
+	// the signature is resolved at the top level, as it is when the method
+	// is declared, which is where its errors are reported
+	scope, typeParams, errs := c.scope, c.typeParams, len(c.errors)
+	for c.scope.parent != nil {
+		c.scope = c.scope.parent
+	}
+	c.typeParams = nil
+	sig := c.funcSignature(stmt.Expr)
+	c.scope, c.typeParams, c.errors = scope, typeParams, c.errors[:errs]
+	return typed{t: sig.asType(), sig: sig}, true
+}
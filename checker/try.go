@@ -0,0 +1,26 @@
+package checker
+
+import (
+	"github.com/dani-gouken/nomad/parser"
+	"github.com/dani-gouken/nomad/runtime/types"
+)
+
+// checkTry checks both blocks of a try statement, the caught error being
+// visible in the catch block only.
+func (c *Checker) checkTry(stmt *parser.Stmt) {
+	tryBlock, catchBlock := stmt.Children[0], stmt.Children[1]
+	c.checkStmts(tryBlock.Children)
+	c.scope = newScope(c.scope)
+	if len(catchBlock.Data) > 1 {
+		c.scope.symbols[catchBlock.Data[1].Content] = typed{t: c.types.GetOrPanic(types.ERROR_TYPE)}
+	}
+	c.checkStmts(catchBlock.Children)
+	c.scope = c.scope.parent
+}
+
+func (c *Checker) checkThrow(stmt *parser.Stmt) {
+	value := c.checkExpr(stmt.Expr)
+	if err := match(c.types.GetOrPanic(types.ERROR_TYPE), value.t); err != nil {
+		c.error(stmt.Expr.Token, "cannot throw value of type %s, Error expected", value.t.GetName())
+	}
+}
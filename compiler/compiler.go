@@ -17,6 +17,30 @@ type Compiler struct {
 	stmts        []*parser.Stmt
 	instructions []vm.Instruction
 	cursor       int
+	// constants maps the constants visible from the chunk to their declaration
+	constants map[string]tokenizer.Token
+	// loops are the loops enclosing the chunk, the innermost being the last
+	loops []loop
+	// tries counts the try blocks enclosing the chunk
+	tries int
+	// imports maps the namespaces bound by the imports to their path
+	imports map[string]tokenizer.Token
+}
+
+var bitwiseOpcodes = map[string]string{
+	parser.EXPR_KIND_MODULO:      vm.OP_MOD,
+	parser.EXPR_KIND_BIT_AND:     vm.OP_BIT_AND,
+	parser.EXPR_KIND_BIT_OR:      vm.OP_BIT_OR,
+	parser.EXPR_KIND_BIT_XOR:     vm.OP_BIT_XOR,
+	parser.EXPR_KIND_SHIFT_LEFT:  vm.OP_SHL,
+	parser.EXPR_KIND_SHIFT_RIGHT: vm.OP_SHR,
+}
+
+// loop holds the labels targeted by break and continue statements.
+type loop struct {
+	breakLabel    string
+	continueLabel string
+	tries         int
 }
 
 func CompileExpr(expr parser.Expr) ([]vm.Instruction, error) {
@@ -51,6 +75,27 @@ func CompileExpr(expr parser.Expr) ([]vm.Instruction, error) {
 			instructions = append(instructions, compiled...)
 		}
 		return instructions, nil
+	case parser.EXPR_KIND_INTERPOLATION:
+		return compileInterpolation(expr)
+	case parser.EXPR_KIND_BIT_NOT:
+		compiled, err := CompileExpr(expr.Children[0])
+		if err != nil {
+			return instructions, err
+		}
+		instructions = append(instructions, compiled...)
+		return append(instructions, vm.Instruction{
+			Code:       vm.OP_BIT_NOT,
+			DebugToken: expr.Token,
+		}), nil
+	case parser.EXPR_KIND_MODULO, parser.EXPR_KIND_BIT_AND, parser.EXPR_KIND_BIT_OR, parser.EXPR_KIND_BIT_XOR, parser.EXPR_KIND_SHIFT_LEFT, parser.EXPR_KIND_SHIFT_RIGHT:
+		instructions, err := CompileBinaryExpr(expr)
+		if err != nil {
+			return instructions, err
+		}
+		return append(instructions, vm.Instruction{
+			Code:       bitwiseOpcodes[expr.Kind],
+			DebugToken: expr.Token,
+		}), nil
 	case parser.EXPR_KIND_NEGATIVE:
 		compiled, err := CompileExpr(expr.Children[0])
 		if err != nil {
@@ -110,6 +155,14 @@ func CompileExpr(expr parser.Expr) ([]vm.Instruction, error) {
 					DebugToken: expr.Token,
 				},
 			}, nil
+		case tokenizer.TOKEN_KIND_NONE:
+			return []vm.Instruction{
+				{
+					Code:       vm.OP_PUSH_CONST,
+					Arg1:       types.NONE_TYPE,
+					DebugToken: expr.Token,
+				},
+			}, nil
 
 		}
 	case parser.EXPR_KIND_ADDITION:
@@ -318,7 +371,11 @@ func CompileExpr(expr parser.Expr) ([]vm.Instruction, error) {
 				return instructions, err
 			}
 			instructions = append(instructions, argumentInsts...)
-
+		}
+		// the arguments are all evaluated before being pushed, so calls made
+		// while evaluating them do not consume the arguments of this call
+		for i := len(argListExpr.Children) - 1; i >= 0; i-- {
+			argumentExpr := argListExpr.Children[i]
 			if argumentExpr.Kind == parser.EXPR_KIND_FUNC_NAMED_ARG {
 				instructions = append(instructions, vm.Instruction{
 					Code:       vm.OP_PUSH_NAMED_ARG,
@@ -380,9 +437,23 @@ func CompileExpr(expr parser.Expr) ([]vm.Instruction, error) {
 			Arg1:       funcLabel,
 			DebugToken: expr.Token,
 		})
-		if len(expr.Children) != 2 {
+		if len(expr.Children) < 2 {
 			return instructions, fmt.Errorf("expected parameter list and return type expression")
 		}
+		// the type parameters of a generic function are in scope while its
+		// signature is built
+		generic := len(expr.Children) == 3
+		if generic {
+			typeParams := []string{}
+			for _, typeParamExpr := range expr.Children[2].Children {
+				typeParams = append(typeParams, typeParamExpr.Token.Content)
+			}
+			instructions = append(instructions, vm.Instruction{
+				Code:       vm.OP_FUNC_TYPE_PARAMS,
+				Arg1:       strings.Join(typeParams, ","),
+				DebugToken: expr.Token,
+			})
+		}
 		retTypeExpr := expr.Children[1]
 		retTypeExprInsts, err := CompileExpr(retTypeExpr)
 		if err != nil {
@@ -399,6 +470,12 @@ func CompileExpr(expr parser.Expr) ([]vm.Instruction, error) {
 			return instructions, err
 		}
 		instructions = append(instructions, parameterListExprInsts...)
+		if generic {
+			instructions = append(instructions, vm.Instruction{
+				Code:       vm.OP_FUNC_TYPE_PARAMS_END,
+				DebugToken: expr.Token,
+			})
+		}
 		instructions = append(instructions, vm.Instruction{
 			Code: vm.OP_JUMP,
 			Arg1: funcDeclEndLabel,
@@ -457,32 +534,139 @@ func CompileExpr(expr parser.Expr) ([]vm.Instruction, error) {
 		})
 		return instructions, nil
 
+	case parser.EXPR_KIND_TYPE_ARG_LIST:
+		for _, child := range expr.Children {
+			childInsts, err := CompileExpr(child)
+			if err != nil {
+				return instructions, err
+			}
+			instructions = append(instructions, childInsts...)
+		}
+		return append(instructions, vm.Instruction{
+			Code:       vm.OP_FUNC_INSTANTIATE,
+			Arg1:       strconv.Itoa(len(expr.Children) - 1),
+			DebugToken: expr.Token,
+		}), nil
 	case parser.EXPR_KIND_ARRAY_ACCESS:
-		arrayExpr := expr.Children[0]
-		arrayInst, err := CompileExpr(arrayExpr)
+		instructions, err := CompileBinaryExpr(expr)
 		if err != nil {
 			return instructions, err
 		}
-		instructions = append(instructions, arrayInst...)
-		if expr.Token.Kind == tokenizer.TOKEN_KIND_NUM_LIT {
-			instructions = append(instructions, vm.Instruction{
-				Code:       vm.OP_PUSH_CONST,
-				Arg1:       types.INT_TYPE,
-				Arg2:       expr.Token.Content,
-				DebugToken: expr.Token,
-			})
-		} else {
+		// an identifier index is resolved as a type when instantiating a
+		// generic function, like in max[float]
+		typeName := ""
+		if expr.Children[1].Kind == parser.EXPR_KIND_ID {
+			typeName = expr.Children[1].Token.Content
+		}
+		return append(instructions, vm.Instruction{
+			Code:       vm.OP_ARR_LOAD,
+			Arg1:       typeName,
+			DebugToken: expr.Token,
+		}), nil
+	case parser.EXPR_KIND_TYPE_OPTIONAL:
+		compiled, err := CompileExpr(expr.Children[0])
+		if err != nil {
+			return instructions, err
+		}
+		instructions = append(instructions, compiled...)
+		return append(instructions, vm.Instruction{
+			Code:       vm.OP_OPTIONAL_TYPE,
+			DebugToken: expr.Token,
+		}), nil
+	case parser.EXPR_KIND_SOME:
+		compiled, err := CompileExpr(expr.Children[0])
+		if err != nil {
+			return instructions, err
+		}
+		instructions = append(instructions, compiled...)
+		return append(instructions, vm.Instruction{
+			Code:       vm.OP_IS_SOME,
+			DebugToken: expr.Token,
+		}), nil
+	case parser.EXPR_KIND_UNWRAP:
+		compiled, err := CompileExpr(expr.Children[0])
+		if err != nil {
+			return instructions, err
+		}
+		instructions = append(instructions, compiled...)
+		return append(instructions, vm.Instruction{
+			Code:       vm.OP_UNWRAP,
+			DebugToken: expr.Token,
+		}), nil
+	case parser.EXPR_KIND_TYPE_MAP:
+		instructions, err := CompileBinaryExpr(expr)
+		if err != nil {
+			return instructions, err
+		}
+		return append(instructions, vm.Instruction{
+			Code:       vm.OP_MAP_TYPE,
+			DebugToken: expr.Token,
+		}), nil
+	case parser.EXPR_KIND_MAP:
+		typeInstr, err := CompileExpr(expr.Children[0])
+		if err != nil {
+			return instructions, err
+		}
+		instructions = append(instructions, typeInstr...)
+		instructions = append(instructions, vm.Instruction{
+			Code:       vm.OP_MAP_INIT,
+			DebugToken: expr.Token,
+		})
+		for _, entry := range expr.Children[1].Children {
+			entryInstr, err := CompileBinaryExpr(entry)
+			if err != nil {
+				return instructions, err
+			}
+			instructions = append(instructions, entryInstr...)
 			instructions = append(instructions, vm.Instruction{
-				Code:       vm.OP_LOAD_VAR,
-				Arg1:       expr.Token.Content,
-				DebugToken: expr.Token,
+				Code:       vm.OP_MAP_SET,
+				DebugToken: entry.Token,
 			})
-
 		}
+		return instructions, nil
+	case parser.EXPR_KIND_IN:
+		instructions, err := CompileBinaryExpr(expr)
+		if err != nil {
+			return instructions, err
+		}
+		return append(instructions, vm.Instruction{
+			Code:       vm.OP_MAP_HAS,
+			DebugToken: expr.Token,
+		}), nil
+	case parser.EXPR_KIND_KEYS:
+		compiled, err := CompileExpr(expr.Children[0])
+		if err != nil {
+			return instructions, err
+		}
+		instructions = append(instructions, compiled...)
+		return append(instructions, vm.Instruction{
+			Code:       vm.OP_MAP_KEYS,
+			DebugToken: expr.Token,
+		}), nil
+	case parser.EXPR_KIND_TYPE_ENUM:
 		instructions = append(instructions, vm.Instruction{
-			Code:       vm.OP_ARR_LOAD,
+			Code:       vm.OP_ENUM_TYPE,
 			DebugToken: expr.Token,
 		})
+		for _, variant := range expr.Children {
+			instructions = append(instructions, vm.Instruction{
+				Code:       vm.OP_ENUM_TYPE_ADD_VARIANT,
+				Arg1:       variant.Token.Content,
+				DebugToken: variant.Token,
+			})
+			for _, field := range variant.Children {
+				typeInstr, err := CompileExpr(field.Children[0])
+				if err != nil {
+					return instructions, err
+				}
+				instructions = append(instructions, typeInstr...)
+				instructions = append(instructions, vm.Instruction{
+					Code:       vm.OP_ENUM_TYPE_SET_FIELD,
+					Arg1:       field.Token.Content,
+					DebugToken: field.Token,
+				})
+			}
+		}
 		return instructions, nil
 	case parser.EXPR_KIND_TYPE_OBJ:
 		instructions = append(instructions, vm.Instruction{
@@ -602,14 +786,49 @@ func CompileBinaryExpr(expr parser.Expr) ([]vm.Instruction, error) {
 	return instructions, nil
 }
 
+// compileInterpolation lowers an interpolated string into the concatenation
+// of its parts, the expressions being converted to strings.
+func compileInterpolation(expr parser.Expr) ([]vm.Instruction, error) {
+	instructions := []vm.Instruction{}
+	for i, part := range expr.Children {
+		compiled, err := CompileExpr(part)
+		if err != nil {
+			return instructions, err
+		}
+		instructions = append(instructions, compiled...)
+		if part.Kind != parser.EXPR_KIND_CONSTANT || part.Token.Kind != tokenizer.TOKEN_KIND_STRING_LIT {
+			instructions = append(instructions, vm.Instruction{
+				Code:       vm.OP_TO_STRING,
+				DebugToken: part.Token,
+			})
+		}
+		if i > 0 {
+			instructions = append(instructions, vm.Instruction{
+				Code:       vm.OP_ADD,
+				DebugToken: expr.Token,
+			})
+		}
+	}
+	return instructions, nil
+}
+
 func (c *Compiler) label(name string, stmt *parser.Stmt) string {
-	return "__" + name + "_" + strconv.Itoa(c.cursor) + strconv.Itoa(stmt.Expr.Token.Loc.Line) + strconv.Itoa(stmt.Expr.Token.Loc.Start) + strconv.Itoa(stmt.Expr.Token.Loc.End)
+	token := stmt.Expr.Token
+	// statements without expression are located by their keyword
+	if token.Kind == "" && len(stmt.Data) > 0 {
+		token = stmt.Data[0]
+	}
+	return "__" + name + "_" + strconv.Itoa(c.cursor) + strconv.Itoa(token.Loc.Line) + strconv.Itoa(token.Loc.Start) + strconv.Itoa(token.Loc.End)
 }
 
 func (c *Compiler) CompileStmt() error {
 	stmt := c.peek()
 	if stmt == nil {
-		return nomadErrors.CompilationError("EOF")
+		return nomadErrors.CompilationError("EOF", tokenizer.Token{})
+	}
+	err := c.checkConstantAssignment(stmt)
+	if err != nil {
+		return err
 	}
 	switch stmt.Kind {
 	case parser.STMT_KIND_IMPLICIT_RETURN:
@@ -659,7 +878,7 @@ func (c *Compiler) CompileStmt() error {
 					Arg1: nextLabel,
 				})
 			}
-			branchStmts, err := CompileChunk(branch.Children)
+			branchStmts, err := c.compileBlock(branch.Children)
 			if err != nil {
 				return err
 			}
@@ -675,10 +894,11 @@ func (c *Compiler) CompileStmt() error {
 			Arg1: exitIfLabel,
 		})
 		return nil
-	case parser.STMT_KIND_FOR:
+	case parser.STMT_KIND_FOR, parser.STMT_KIND_WHILE:
 		c.consume()
 		endForLabel := c.label("END_FOR", stmt)
 		forTestLabel := c.label("FOR_TEST", stmt)
+		forIterLabel := c.label("FOR_ITER", stmt)
 		c.instructions = append(c.instructions, vm.Instruction{
 			Code: vm.OP_LABEL,
 			Arg1: forTestLabel,
@@ -700,11 +920,27 @@ func (c *Compiler) CompileStmt() error {
 			Code: vm.OP_JUMP_NOT,
 			Arg1: endForLabel,
 		})
-		forOperationsInstructions, err := CompileChunk(stmt.Children)
+		body, iteration := stmt.Children, []*parser.Stmt{}
+		if stmt.Kind == parser.STMT_KIND_FOR {
+			// the iteration statement is the last child of the loop
+			body, iteration = stmt.Children[:len(stmt.Children)-1], stmt.Children[len(stmt.Children)-1:]
+		}
+		c.loops = append(c.loops, loop{breakLabel: endForLabel, continueLabel: forIterLabel, tries: c.tries})
+		forOperationsInstructions, err := c.compileBlock(body)
+		c.loops = c.loops[:len(c.loops)-1]
 		if err != nil {
 			return err
 		}
 		c.instructions = append(c.instructions, forOperationsInstructions...)
+		c.instructions = append(c.instructions, vm.Instruction{
+			Code: vm.OP_LABEL,
+			Arg1: forIterLabel,
+		})
+		iterationInstructions, err := c.compileBlock(iteration)
+		if err != nil {
+			return err
+		}
+		c.instructions = append(c.instructions, iterationInstructions...)
 		c.instructions = append(c.instructions, vm.Instruction{
 			Code: vm.OP_JUMP,
 			Arg1: forTestLabel,
@@ -714,6 +950,205 @@ func (c *Compiler) CompileStmt() error {
 			Arg1: endForLabel,
 		})
 		return err
+	case parser.STMT_KIND_FOR_IN:
+		c.consume()
+		endForLabel := c.label("END_FOR", stmt)
+		forNextLabel := c.label("FOR_NEXT", stmt)
+		// the iterator is held by a variable no script can name
+		iterator := c.label("ITERATOR", stmt)
+		iterableInstructions, err := CompileExpr(stmt.Expr)
+		if err != nil {
+			return err
+		}
+		c.instructions = append(c.instructions, iterableInstructions...)
+		c.instructions = append(c.instructions, vm.Instruction{
+			Code:       vm.OP_ITER_INIT,
+			Arg1:       iterator,
+			Arg2:       strconv.FormatBool(len(stmt.Data) > 1),
+			DebugToken: stmt.Expr.Token,
+		})
+		c.instructions = append(c.instructions, vm.Instruction{
+			Code: vm.OP_LABEL,
+			Arg1: forNextLabel,
+		})
+		c.instructions = append(c.instructions, vm.Instruction{
+			Code: vm.OP_ITER_NEXT,
+			Arg1: iterator,
+		})
+		c.instructions = append(c.instructions, vm.Instruction{
+			Code: vm.OP_JUMP_NOT,
+			Arg1: endForLabel,
+		})
+		c.instructions = append(c.instructions, vm.Instruction{
+			Code:       vm.OP_ITER_ITEM,
+			Arg1:       iterator,
+			Arg2:       stmt.Data[0].Content,
+			DebugToken: stmt.Data[0],
+		})
+		if len(stmt.Data) > 1 {
+			c.instructions = append(c.instructions, vm.Instruction{
+				Code:       vm.OP_ITER_INDEX,
+				Arg1:       iterator,
+				Arg2:       stmt.Data[1].Content,
+				DebugToken: stmt.Data[1],
+			})
+		}
+		c.loops = append(c.loops, loop{breakLabel: endForLabel, continueLabel: forNextLabel, tries: c.tries})
+		bodyInstructions, err := c.compileBlock(stmt.Children)
+		c.loops = c.loops[:len(c.loops)-1]
+		if err != nil {
+			return err
+		}
+		c.instructions = append(c.instructions, bodyInstructions...)
+		c.instructions = append(c.instructions, vm.Instruction{
+			Code: vm.OP_JUMP,
+			Arg1: forNextLabel,
+		})
+		c.instructions = append(c.instructions, vm.Instruction{
+			Code: vm.OP_LABEL,
+			Arg1: endForLabel,
+		})
+		return nil
+	case parser.STMT_KIND_MATCH:
+		c.consume()
+		subjectInstructions, err := CompileExpr(stmt.Expr)
+		if err != nil {
+			return err
+		}
+		c.instructions = append(c.instructions, subjectInstructions...)
+		endMatchLabel := c.label("END_MATCH", stmt)
+		for i, arm := range stmt.Children {
+			nextArmLabel := c.label("MATCH_ARM_"+strconv.Itoa(i), stmt)
+			if arm.Kind == parser.STMT_KIND_MATCH_ARM {
+				variant := arm.Data[0]
+				c.instructions = append(c.instructions, vm.Instruction{
+					Code:       vm.OP_ENUM_IS,
+					Arg1:       variant.Content,
+					DebugToken: variant,
+				})
+				c.instructions = append(c.instructions, vm.Instruction{
+					Code: vm.OP_JUMP_NOT,
+					Arg1: nextArmLabel,
+				})
+				for index, binding := range arm.Data[1:] {
+					c.instructions = append(c.instructions, vm.Instruction{
+						Code:       vm.OP_ENUM_BIND,
+						Arg1:       binding.Content,
+						Arg2:       strconv.Itoa(index),
+						DebugToken: binding,
+					})
+				}
+			}
+			// the subject is no longer needed once the arm is selected
+			c.instructions = append(c.instructions, vm.Instruction{
+				Code: vm.OP_POP_CONST,
+			})
+			armInstructions, err := c.compileBlock(arm.Children)
+			if err != nil {
+				return err
+			}
+			c.instructions = append(c.instructions, armInstructions...)
+			c.instructions = append(c.instructions, vm.Instruction{
+				Code: vm.OP_JUMP,
+				Arg1: endMatchLabel,
+			})
+			c.instructions = append(c.instructions, vm.Instruction{
+				Code: vm.OP_LABEL,
+				Arg1: nextArmLabel,
+			})
+		}
+		c.instructions = append(c.instructions, vm.Instruction{
+			Code:       vm.OP_MATCH_FAIL,
+			DebugToken: stmt.Data[0],
+		})
+		c.instructions = append(c.instructions, vm.Instruction{
+			Code: vm.OP_LABEL,
+			Arg1: endMatchLabel,
+		})
+		return nil
+	case parser.STMT_KIND_TRY:
+		c.consume()
+		tryBlock, catchBlock := stmt.Children[0], stmt.Children[1]
+		catchLabel := c.label("CATCH", stmt)
+		endTryLabel := c.label("END_TRY", stmt)
+		c.instructions = append(c.instructions, vm.Instruction{
+			Code:       vm.OP_TRY,
+			Arg1:       catchLabel,
+			DebugToken: stmt.Data[0],
+		})
+		c.tries++
+		tryInstructions, err := c.compileBlock(tryBlock.Children)
+		c.tries--
+		if err != nil {
+			return err
+		}
+		c.instructions = append(c.instructions, tryInstructions...)
+		c.instructions = append(c.instructions, vm.Instruction{
+			Code: vm.OP_TRY_END,
+		})
+		c.instructions = append(c.instructions, vm.Instruction{
+			Code: vm.OP_JUMP,
+			Arg1: endTryLabel,
+		})
+		c.instructions = append(c.instructions, vm.Instruction{
+			Code: vm.OP_LABEL,
+			Arg1: catchLabel,
+		})
+		// the caught error is pushed on the stack before jumping to the catch block
+		catch := vm.Instruction{
+			Code:       vm.OP_CATCH,
+			DebugToken: catchBlock.Data[0],
+		}
+		if len(catchBlock.Data) > 1 {
+			catch.Arg1 = catchBlock.Data[1].Content
+			catch.DebugToken = catchBlock.Data[1]
+		}
+		c.instructions = append(c.instructions, catch)
+		catchInstructions, err := c.compileBlock(catchBlock.Children)
+		if err != nil {
+			return err
+		}
+		c.instructions = append(c.instructions, catchInstructions...)
+		c.instructions = append(c.instructions, vm.Instruction{
+			Code: vm.OP_LABEL,
+			Arg1: endTryLabel,
+		})
+		return nil
+	case parser.STMT_KIND_BREAK, parser.STMT_KIND_CONTINUE:
+		c.consume()
+		keyword := stmt.Data[0]
+		if len(c.loops) == 0 {
+			return nomadErrors.CompilationError(fmt.Sprintf("%s used outside of a loop", keyword.Content), keyword)
+		}
+		current := c.loops[len(c.loops)-1]
+		// leaving the try blocks entered inside the loop
+		for i := current.tries; i < c.tries; i++ {
+			c.instructions = append(c.instructions, vm.Instruction{
+				Code: vm.OP_TRY_END,
+			})
+		}
+		target := current.breakLabel
+		if stmt.Kind == parser.STMT_KIND_CONTINUE {
+			target = current.continueLabel
+		}
+		c.instructions = append(c.instructions, vm.Instruction{
+			Code:       vm.OP_JUMP,
+			Arg1:       target,
+			DebugToken: keyword,
+		})
+		return nil
+	case parser.STMT_KIND_THROW:
+		c.consume()
+		compiled, err := CompileExpr(stmt.Expr)
+		if err != nil {
+			return err
+		}
+		c.instructions = append(c.instructions, compiled...)
+		c.instructions = append(c.instructions, vm.Instruction{
+			Code:       vm.OP_THROW,
+			DebugToken: stmt.Data[0],
+		})
+		return nil
 	case parser.STMT_KIND_ASSIGNMENT:
 		varName := stmt.Data[0].Content
 		compiled, err := CompileExpr(stmt.Expr)
@@ -721,7 +1156,7 @@ func (c *Compiler) CompileStmt() error {
 		c.instructions = append(c.instructions, vm.Instruction{
 			Code:       vm.OP_SET_VAR,
 			Arg1:       varName,
-			DebugToken: stmt.Expr.Token,
+			DebugToken: stmt.Data[0],
 		})
 		c.instructions = append(c.instructions, vm.Instruction{
 			Code:       vm.OP_POP_CONST,
@@ -729,6 +1164,24 @@ func (c *Compiler) CompileStmt() error {
 		})
 		c.consume()
 		return err
+	case parser.STMT_KIND_ARR_ASSIGNMENT:
+		target := stmt.Expr.Children[0]
+		compiled, err := CompileBinaryExpr(target)
+		if err != nil {
+			return err
+		}
+		c.instructions = append(c.instructions, compiled...)
+		compiled, err = CompileExpr(stmt.Expr.Children[1])
+		if err != nil {
+			return err
+		}
+		c.instructions = append(c.instructions, compiled...)
+		c.instructions = append(c.instructions, vm.Instruction{
+			Code:       vm.OP_ARR_STORE,
+			DebugToken: target.Token,
+		})
+		c.consume()
+		return nil
 	case parser.STMT_KIND_DEBUG_PRINT:
 		compiled, err := CompileExpr(stmt.Expr)
 		c.instructions = append(c.instructions, compiled...)
@@ -745,7 +1198,7 @@ func (c *Compiler) CompileStmt() error {
 		})
 		c.consume()
 		return err
-	case parser.STMT_KIND_TYPE_DECLARATION:
+	case parser.STMT_KIND_TYPE_DECLARATION, parser.STMT_KIND_ENUM_DECLARATION:
 		typeName := stmt.Data[0].Content
 		compiled, err := CompileExpr(stmt.Expr)
 		if err != nil {
@@ -759,8 +1212,29 @@ func (c *Compiler) CompileStmt() error {
 		})
 		c.consume()
 		return err
+	case parser.STMT_KIND_METHOD_DECLARATION:
+		receiverType, receiverName, methodName := stmt.Data[0], stmt.Data[1], stmt.Data[2]
+		compiled, err := CompileExpr(stmt.Expr)
+		if err != nil {
+			return err
+		}
+		c.instructions = append(c.instructions, compiled...)
+		c.instructions = append(c.instructions, vm.Instruction{
+			Code:       vm.OP_LOAD_TYPE,
+			Arg1:       receiverType.Content,
+			DebugToken: receiverType,
+		})
+		c.instructions = append(c.instructions, vm.Instruction{
+			Code:       vm.OP_DECL_METHOD,
+			Arg1:       receiverName.Content,
+			Arg2:       methodName.Content,
+			DebugToken: methodName,
+		})
+		c.consume()
+		return nil
 	case parser.STMT_KIND_VAR_DECLARATION:
 		varName := stmt.Data[0].Content
+		delete(c.constants, varName)
 		compiled, err := CompileExpr(stmt.Expr)
 		c.instructions = append(c.instructions, compiled...)
 		c.instructions = append(c.instructions, vm.Instruction{
@@ -776,12 +1250,16 @@ func (c *Compiler) CompileStmt() error {
 		return err
 	case parser.STMT_KIND_CONST_DECLARATION:
 		varName := stmt.Data[0].Content
+		if c.constants == nil {
+			c.constants = map[string]tokenizer.Token{}
+		}
+		c.constants[varName] = stmt.Data[0]
 		compiled, err := CompileExpr(stmt.Expr)
 		c.instructions = append(c.instructions, compiled...)
 		c.instructions = append(c.instructions, vm.Instruction{
 			Code:       vm.OP_DECL_CONST,
 			Arg2:       varName,
-			DebugToken: stmt.Expr.Token,
+			DebugToken: stmt.Data[0],
 		})
 		c.instructions = append(c.instructions, vm.Instruction{
 			Code:       vm.OP_POP_CONST,
@@ -789,10 +1267,69 @@ func (c *Compiler) CompileStmt() error {
 		})
 		c.consume()
 		return err
+	case parser.STMT_KIND_IMPORT:
+		pathToken := stmt.Data[0]
+		path, namespace := parser.ImportNamespace(stmt)
+		if len(stmt.Data) == 1 && !isIdentifier(namespace) {
+			return nomadErrors.CompilationError(fmt.Sprintf("invalid module name [%s], module names should be valid identifiers", namespace), pathToken)
+		}
+		if previous, ok := c.imports[namespace]; ok {
+			return nomadErrors.CompilationError(fmt.Sprintf("namespace [%s] is already bound by the import of %s at %d:%d, use an alias: import %s as name", namespace, previous.Content, previous.Loc.Line, previous.Loc.Start, pathToken.Content), pathToken)
+		}
+		if c.imports == nil {
+			c.imports = map[string]tokenizer.Token{}
+		}
+		c.imports[namespace] = pathToken
+		c.instructions = append(c.instructions, vm.Instruction{
+			Code:       vm.OP_IMPORT,
+			Arg1:       path,
+			Arg2:       namespace,
+			DebugToken: pathToken,
+		})
+		c.consume()
+		return nil
 	default:
 		return fmt.Errorf("unable to compile statement [%s]", stmt.Kind)
 	}
 }
+
+// compileBlock compiles the statements of a nested block, which sees
+// the constants declared so far.
+func (c *Compiler) compileBlock(stmts []*parser.Stmt) ([]vm.Instruction, error) {
+	block := Compiler{
+		constants: map[string]tokenizer.Token{},
+		loops:     c.loops,
+		tries:     c.tries,
+		imports:   c.imports,
+	}
+	for name, declaration := range c.constants {
+		block.constants[name] = declaration
+	}
+	return block.CompileChunk(stmts)
+}
+
+func (c *Compiler) checkConstantAssignment(stmt *parser.Stmt) error {
+	var target tokenizer.Token
+	switch stmt.Kind {
+	case parser.STMT_KIND_ASSIGNMENT:
+		target = stmt.Data[0]
+	case parser.STMT_KIND_IMPLICIT_RETURN:
+		switch stmt.Expr.Kind {
+		case parser.EXPR_KIND_RIGHT_INCREMENT, parser.EXPR_KIND_RIGHT_DECREMENT:
+			target = stmt.Expr.Token
+		default:
+			return nil
+		}
+	default:
+		return nil
+	}
+	declaration, ok := c.constants[target.Content]
+	if !ok {
+		return nil
+	}
+	return nomadErrors.CompilationError(fmt.Sprintf("cannot assign to constant %s declared at %d:%d", target.Content, declaration.Loc.Line, declaration.Loc.Start), target)
+}
+
 func (c *Compiler) GetInstructions() []vm.Instruction {
 	return c.instructions
 }
@@ -817,7 +1354,7 @@ func RemoveLabels(instructions []vm.Instruction) ([]vm.Instruction, error) {
 
 	for i := 0; i < len(instructions); i++ {
 		instruction := &instructions[i]
-		if instruction.Code == vm.OP_JUMP || instruction.Code == vm.OP_JUMP_NOT || instruction.Code == vm.OP_FUNC_INIT {
+		if vm.HasAddressArg(instruction.Code) {
 			instruction.Arg1 = strconv.Itoa(labels[instruction.Arg1])
 		}
 	}
@@ -840,6 +1377,11 @@ func CompileChunk(program []*parser.Stmt) ([]vm.Instruction, error) {
 	return compiler.CompileChunk(program)
 }
 
+func isIdentifier(name string) bool {
+	tokens, err := tokenizer.Tokenize(name)
+	return err == nil && len(tokens) == 1 && tokens[0].Kind == tokenizer.TOKEN_KIND_ID
+}
+
 func (c *Compiler) peek() *parser.Stmt {
 	if c.cursor >= len(c.stmts) {
 		return nil
@@ -860,11 +1402,3 @@ func (c *Compiler) consume() {
 func (c *Compiler) rollback(position int) {
 	c.cursor = position
 }
-
-func DebugPrintOpCode(instructions []vm.Instruction) {
-	for i := 0; i < len(instructions); i++ {
-		instruction := instructions[i]
-		fmt.Printf("%s    %s %s", instruction.Code, instruction.Arg1, instruction.Arg2)
-		fmt.Println()
-	}
-}
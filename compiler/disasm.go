@@ -0,0 +1,115 @@
+package compiler
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/dani-gouken/nomad/vm"
+)
+
+// Disassemble writes a numbered listing of the instructions to w.
+// Jump targets are resolved, function bodies are delimited and indented,
+// and when source is given each new source line is printed before the
+// instructions compiled from it.
+func Disassemble(w io.Writer, instructions []vm.Instruction, source string) error {
+	lines := strings.Split(source, "\n")
+	names := functionNames(instructions)
+	width := len(strconv.Itoa(len(instructions)))
+	depth := 0
+	lastLine := 0
+	for i, instruction := range instructions {
+		indent := strings.Repeat("    ", depth)
+		line := instruction.DebugToken.Loc.Line
+		if line > 0 && line != lastLine {
+			lastLine = line
+			text := instruction.DebugToken.Content
+			if line <= len(lines) && source != "" {
+				text = strings.TrimSpace(lines[line-1])
+			}
+			if _, err := fmt.Fprintf(w, "%s; %d: %s\n", indent, line, text); err != nil {
+				return err
+			}
+		}
+		if instruction.Code == vm.OP_FUNC_BEGIN {
+			name, ok := names[i]
+			if !ok {
+				name = "<anonymous>"
+			}
+			if _, err := fmt.Fprintf(w, "%s; func %s\n", indent, name); err != nil {
+				return err
+			}
+			depth++
+		}
+		if instruction.Code == vm.OP_FUNC_END && depth > 0 {
+			depth--
+			indent = strings.Repeat("    ", depth)
+		}
+		if _, err := fmt.Fprintf(w, "%s%0*d  %s\n", indent, width, i, formatInstruction(instructions, instruction)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatInstruction(instructions []vm.Instruction, instruction vm.Instruction) string {
+	args := []string{}
+	if vm.HasAddressArg(instruction.Code) {
+		target := "-> " + instruction.Arg1
+		addr, err := strconv.Atoi(instruction.Arg1)
+		if err == nil && addr > 0 && addr <= len(instructions) && instructions[addr-1].Code == vm.OP_LABEL {
+			target += " (" + instructions[addr-1].Arg1 + ")"
+		}
+		args = append(args, target)
+	} else if instruction.Arg1 != "" {
+		args = append(args, strconv.Quote(instruction.Arg1))
+	}
+	if instruction.Arg2 != "" {
+		args = append(args, strconv.Quote(instruction.Arg2))
+	}
+	return strings.TrimSpace(fmt.Sprintf("%-28s %s", instruction.Code, strings.Join(args, " ")))
+}
+
+// functionNames maps the address of each FUNC_BEGIN to the name of the
+// variable the function is declared into, when there is one.
+func functionNames(instructions []vm.Instruction) map[int]string {
+	names := map[int]string{}
+	for _, instruction := range instructions {
+		if instruction.Code != vm.OP_FUNC_INIT {
+			continue
+		}
+		begin, err := strconv.Atoi(instruction.Arg1)
+		if err != nil {
+			continue
+		}
+		nesting := 0
+		for j := begin; j < len(instructions); j++ {
+			code := instructions[j].Code
+			if code == vm.OP_FUNC_BEGIN {
+				nesting++
+			}
+			if code == vm.OP_FUNC_END {
+				nesting--
+			}
+			if nesting > 0 || j <= begin {
+				continue
+			}
+			if code == vm.OP_DECL_VAR || code == vm.OP_DECL_CONST {
+				names[begin] = instructions[j].Arg2
+			}
+			if code == vm.OP_DECL_METHOD {
+				names[begin] = instructions[j-1].Arg1 + "." + instructions[j].Arg2
+			}
+			if code != vm.OP_LABEL && code != vm.OP_LOAD_TYPE && code != vm.OP_LOAD_TYPE_INFER && code != vm.OP_FUNC_END {
+				break
+			}
+		}
+	}
+	return names
+}
+
+func DebugPrintOpCode(instructions []vm.Instruction) {
+	Disassemble(os.Stdout, instructions, "")
+}
@@ -0,0 +1,46 @@
+package compiler_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dani-gouken/nomad/compiler"
+	"github.com/dani-gouken/nomad/interpreter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisassemble(t *testing.T) {
+	source := `auto double :: func(int n) int {
+    return n * 2
+}
+print double(2)`
+	i := interpreter.NewInterpreter()
+	instructions, err := i.Compile(source)
+	assert.NoError(t, err)
+
+	var listing strings.Builder
+	assert.NoError(t, compiler.Disassemble(&listing, instructions, source))
+	lines := strings.Split(listing.String(), "\n")
+
+	assert.Equal(t, "; 1: auto double :: func(int n) int {", lines[0])
+	assert.Regexp(t, `^00 +FUNC_INIT +-> 7 \(__func_\d+\)$`, lines[1])
+	assert.Contains(t, listing.String(), "; func double\n07  FUNC_BEGIN\n    ; 2: return n * 2\n    08  LOAD_VAR")
+	assert.Regexp(t, `\n12  FUNC_END\n`, listing.String())
+	assert.Contains(t, listing.String(), "; 4: print double(2)\n")
+	assert.Regexp(t, `JUMP +-> 14 \(__func_\d+_decl_end\)`, listing.String())
+}
+
+func TestDisassembleMethod(t *testing.T) {
+	source := `type Rect :: { float width :: 0.0 }
+func (Rect r) width_twice() float {
+    return r.width * 2
+}`
+	i := interpreter.NewInterpreter()
+	instructions, err := i.Compile(source)
+	assert.NoError(t, err)
+
+	var listing strings.Builder
+	assert.NoError(t, compiler.Disassemble(&listing, instructions, source))
+	assert.Contains(t, listing.String(), "; func Rect.width_twice\n")
+	assert.Regexp(t, `DECL_METHOD +"r" "width_twice"`, listing.String())
+}
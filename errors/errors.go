@@ -1,30 +1,72 @@
 package error
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/dani-gouken/nomad/tokenizer"
 )
 
-type ParseError struct {
-	crash   bool
+// SourceError is an error located at a token of a source file. The path
+// of the file is only known once the error reaches the loader, see WithPath.
+type SourceError struct {
+	kind    string
 	message string
+	token   tokenizer.Token
+	path    string
+}
+
+func (e *SourceError) Error() string {
+	return fmt.Sprintf("%s: %s error. %s", e.Location(), e.kind, e.message)
 }
 
-func (e *ParseError) Error() string {
+func (e *SourceError) Message() string {
 	return e.message
 }
 
+// Location formats the position of the error as path:line:col, or as
+// /line:start:end while the file is unknown.
+func (e *SourceError) Location() string {
+	if e.path == "" {
+		return DebugToken(e.token)
+	}
+	return fmt.Sprintf("%s:%d:%d", e.path, e.token.Loc.Line, e.token.Loc.Start)
+}
+
+func (e *SourceError) source() *SourceError {
+	return e
+}
+
+type ParseError struct {
+	*SourceError
+	crash bool
+}
+
 func (e *ParseError) ShouldCrash() bool {
 	return e.crash
 }
 
+// Fatal returns a copy of e stopping the parsing.
+func (e *ParseError) Fatal() *ParseError {
+	return &ParseError{SourceError: e.SourceError, crash: true}
+}
+
+// ExecutionError is raised by the vm while running a script. Scripts can
+// catch it, in which case only its message is exposed.
+type ExecutionError struct {
+	*SourceError
+}
+
 func RuntimeError(message string, debugToken tokenizer.Token) error {
-	return fmt.Errorf("%s: runtime error. %s", DebugToken(debugToken), message)
+	return &ExecutionError{&SourceError{kind: "runtime", message: message, token: debugToken}}
+}
+
+func TypeError(message string, debugToken tokenizer.Token) error {
+	return &SourceError{kind: "type", message: message, token: debugToken}
 }
 
-func CompilationError(message string) error {
-	return fmt.Errorf("compilation error. %s", message)
+func CompilationError(message string, debugToken tokenizer.Token) error {
+	return &SourceError{kind: "compilation", message: message, token: debugToken}
 }
 
 func RuntimeErrorUnsupportedOperand(operand string, typeName string, debugToken tokenizer.Token) error {
@@ -32,11 +74,7 @@ func RuntimeErrorUnsupportedOperand(operand string, typeName string, debugToken
 }
 
 func NewParseError(message string, debugToken tokenizer.Token, crash bool) *ParseError {
-	return &ParseError{message: fmt.Sprintf("%s: parse error. %s", DebugToken(debugToken), message), crash: crash}
-}
-
-func NewParseErrorFromMessage(message string, crash bool) *ParseError {
-	return &ParseError{message: message, crash: crash}
+	return &ParseError{SourceError: &SourceError{kind: "parse", message: message, token: debugToken}, crash: crash}
 }
 
 func FatalParseError(message string, debugToken tokenizer.Token) *ParseError {
@@ -50,3 +88,37 @@ func NonFatalParseError(message string, debugToken tokenizer.Token) *ParseError
 func DebugToken(token tokenizer.Token) string {
 	return fmt.Sprintf("/%d:%d:%d", token.Loc.Line, token.Loc.Start, token.Loc.End)
 }
+
+// WithPath sets the file the errors of err originate from. Errors located
+// in another file keep their path, errors without location are prefixed
+// with path.
+func WithPath(err error, path string) error {
+	if err == nil || path == "" {
+		return err
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs := []error{}
+		for _, e := range joined.Unwrap() {
+			errs = append(errs, WithPath(e, path))
+		}
+		return errors.Join(errs...)
+	}
+	var located interface{ source() *SourceError }
+	if errors.As(err, &located) {
+		source := located.source()
+		if source.path == "" {
+			source.path = path
+		}
+		return err
+	}
+	var syntaxError *tokenizer.SyntaxError
+	if errors.As(err, &syntaxError) {
+		return &SourceError{
+			kind:    "parse",
+			message: syntaxError.Message,
+			token:   tokenizer.Token{Loc: syntaxError.Loc},
+			path:    path,
+		}
+	}
+	return fmt.Errorf("%s: %w", path, err)
+}
@@ -2,11 +2,14 @@ module github.com/dani-gouken/nomad
 
 go 1.21.1
 
+require (
+	github.com/makeworld-the-better-one/go-isemoji v1.3.0
+	github.com/stretchr/testify v1.8.4
+)
+
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/makeworld-the-better-one/go-isemoji v1.3.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/stretchr/objx v0.5.0 // indirect
-	github.com/stretchr/testify v1.8.4 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -1,7 +1,13 @@
 package interpreter
 
 import (
+	"os"
+	"path/filepath"
+
+	"github.com/dani-gouken/nomad/bytecode"
+	"github.com/dani-gouken/nomad/checker"
 	"github.com/dani-gouken/nomad/compiler"
+	nomadError "github.com/dani-gouken/nomad/errors"
 	"github.com/dani-gouken/nomad/parser"
 	"github.com/dani-gouken/nomad/tokenizer"
 	"github.com/dani-gouken/nomad/vm"
@@ -12,21 +18,70 @@ type Interpreter struct{}
 func NewInterpreter() Interpreter {
 	return Interpreter{}
 }
-func (p *Interpreter) Interpret(code string, instance *vm.Vm) error {
+
+// Compile compiles code, to be run by a new vm.
+func (p *Interpreter) Compile(code string) ([]vm.Instruction, error) {
+	return p.compile(code, vm.New())
+}
+
+// compile compiles code, the names it does not declare being resolved
+// in env.
+func (p *Interpreter) compile(code string, env checker.Environment) ([]vm.Instruction, error) {
 	tokens, err := tokenizer.Tokenize(code)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	program, err := parser.Parse(tokens)
 	// parser.DebugPrintParseTree(program.Stmts, 0)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	err = checker.Check(program, env)
+	if err != nil {
+		return nil, err
 	}
 	opCode, err := compiler.Compile(program.Stmts)
 	//vm.DebugPrintOpCode(opCode)
+	if err != nil {
+		return nil, err
+	}
+	return opCode, nil
+}
+
+func (p *Interpreter) Interpret(code string, instance *vm.Vm) error {
+	opCode, err := p.compile(code, instance)
 	if err != nil {
 		return err
 	}
 	return instance.Interpret(opCode)
+}
 
+// InterpretFile runs the program stored at path, either a source file or
+// a precompiled one, resolving its imports relatively to its location.
+func (p *Interpreter) InterpretFile(path string, instance *vm.Vm) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	loader := NewFileModuleLoader(instance)
+	opCode, err := loader.Load(absPath)
+	if err != nil {
+		return err
+	}
+	instance.SetEntryPath(absPath)
+	instance.SetModuleLoader(loader)
+	return instance.Interpret(opCode)
+}
+
+// BuildFile compiles the source file at path and writes its bytecode to output.
+func (p *Interpreter) BuildFile(path string, output string) error {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	opCode, err := p.Compile(string(source))
+	if err != nil {
+		return nomadError.WithPath(err, path)
+	}
+	return os.WriteFile(output, bytecode.Encode(opCode), 0o644)
 }
@@ -0,0 +1,82 @@
+package interpreter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/dani-gouken/nomad/bytecode"
+	"github.com/dani-gouken/nomad/checker"
+	nomadError "github.com/dani-gouken/nomad/errors"
+	"github.com/dani-gouken/nomad/vm"
+)
+
+const MODULE_EXTENSION = ".nd"
+
+// FileModuleLoader loads modules from the file system. Paths are resolved
+// relatively to the importing file. When the extension is omitted the source
+// file is preferred over its precompiled (.ndc) counterpart. Source files are
+// checked against env, the vm running them.
+type FileModuleLoader struct {
+	interpreter Interpreter
+	env         checker.Environment
+}
+
+func NewFileModuleLoader(env checker.Environment) *FileModuleLoader {
+	return &FileModuleLoader{
+		interpreter: NewInterpreter(),
+		env:         env,
+	}
+}
+
+func (l *FileModuleLoader) Resolve(path string, importer string) (string, error) {
+	if filepath.Ext(path) == "" {
+		_, err := os.Stat(l.join(path+MODULE_EXTENSION, importer))
+		_, compiledErr := os.Stat(l.join(path+bytecode.EXTENSION, importer))
+		if err != nil && compiledErr == nil {
+			path += bytecode.EXTENSION
+		} else {
+			path += MODULE_EXTENSION
+		}
+	}
+	path = l.join(path, importer)
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(absPath)
+	if err != nil || info.IsDir() {
+		return "", fmt.Errorf("module not found [%s]", path)
+	}
+	return absPath, nil
+}
+
+func (l *FileModuleLoader) join(path string, importer string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	base := "."
+	if importer != "" {
+		base = filepath.Dir(importer)
+	}
+	return filepath.Join(base, path)
+}
+
+func (l *FileModuleLoader) Load(path string) ([]vm.Instruction, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if filepath.Ext(path) == bytecode.EXTENSION {
+		instructions, err := bytecode.Decode(bytes)
+		if err != nil {
+			return nil, nomadError.WithPath(err, path)
+		}
+		return instructions, nil
+	}
+	instructions, err := l.interpreter.compile(string(bytes), l.env)
+	if err != nil {
+		return nil, nomadError.WithPath(err, path)
+	}
+	return instructions, nil
+}
@@ -0,0 +1,184 @@
+package interpreter_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dani-gouken/nomad/interpreter"
+	"github.com/dani-gouken/nomad/vm"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestImport(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.nd": `
+import "lib/geometry"
+import "lib/counter.nd"
+auto p :: new geometry.Point{ x :: 3 }
+auto doubled :: geometry.double(p)
+auto loads :: counter.loads
+`,
+		"lib/geometry.nd": `
+import "counter"
+type Point :: {
+    int x :: 0
+    int y :: 0
+}
+auto factor :: 2
+auto double :: func(Point p) Point {
+    return new Point{ x :: p.x * factor, y :: p.y * factor }
+}
+`,
+		"lib/counter.nd": `
+record(1)
+auto loads :: 1
+`,
+	})
+	instance := vm.New()
+	loads := 0
+	assert.NoError(t, instance.Bind("record", func(n int) { loads += n }))
+
+	i := interpreter.NewInterpreter()
+	err := i.InterpretFile(filepath.Join(dir, "main.nd"), instance)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, loads)
+
+	doubled, err := instance.GetGlobal("doubled")
+	assert.NoError(t, err)
+	assert.Equal(t, "geometry.Point", doubled.RuntimeType.GetName())
+	_, err = instance.GetGlobal("factor")
+	assert.Error(t, err)
+	assert.NoError(t, i.Interpret("auto x :: doubled.x", instance))
+	x, err := instance.GetGlobal("x")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), x.Value)
+}
+
+func TestImportErrors(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"cycle.nd":   `import "a"`,
+		"a.nd":       `import "b"`,
+		"b.nd":       `import "a"`,
+		"missing.nd": `import "nowhere"`,
+		"broken.nd":  `import "syntax"`,
+		"syntax.nd":  `auto x :: (1`,
+		"name.nd":    `import "my-module"`,
+	})
+	i := interpreter.NewInterpreter()
+
+	err := i.InterpretFile(filepath.Join(dir, "cycle.nd"), vm.New())
+	assert.ErrorContains(t, err, "import cycle detected")
+	assert.ErrorContains(t, err, filepath.Join(dir, "a.nd")+" -> "+filepath.Join(dir, "b.nd")+" -> "+filepath.Join(dir, "a.nd"))
+
+	err = i.InterpretFile(filepath.Join(dir, "missing.nd"), vm.New())
+	assert.ErrorContains(t, err, "module not found")
+
+	err = i.InterpretFile(filepath.Join(dir, "broken.nd"), vm.New())
+	assert.ErrorContains(t, err, filepath.Join(dir, "syntax.nd")+":1:")
+
+	err = i.InterpretFile(filepath.Join(dir, "name.nd"), vm.New())
+	assert.ErrorContains(t, err, "invalid module name")
+
+	err = i.Interpret(`import "a"`, vm.New())
+	assert.ErrorContains(t, err, "no module loader")
+}
+
+func TestErrorsAreLocatedInTheirFile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.nd":    `import "runtime"`,
+		"runtime.nd": "auto xs :: [int]{1}\nauto y :: xs[3]",
+		"syntax.nd":  `auto s :: "{}"`,
+		"type.nd":    `int x :: "a"`,
+		"loop.nd":    `break`,
+		"throw.nd":   "auto x :: 1\nthrow new Error{ message :: \"top\" }",
+	})
+	i := interpreter.NewInterpreter()
+
+	err := i.InterpretFile(filepath.Join(dir, "main.nd"), vm.New())
+	assert.EqualError(t, err, filepath.Join(dir, "runtime.nd")+":2:11: runtime error. index 3 out of range, array length is 1")
+
+	err = i.InterpretFile(filepath.Join(dir, "syntax.nd"), vm.New())
+	assert.ErrorContains(t, err, filepath.Join(dir, "syntax.nd")+":1:")
+	assert.ErrorContains(t, err, "parse error. empty string interpolation")
+
+	err = i.InterpretFile(filepath.Join(dir, "type.nd"), vm.New())
+	assert.ErrorContains(t, err, filepath.Join(dir, "type.nd")+":1:")
+	assert.ErrorContains(t, err, "type error.")
+
+	err = i.InterpretFile(filepath.Join(dir, "loop.nd"), vm.New())
+	assert.EqualError(t, err, filepath.Join(dir, "loop.nd")+":1:0: compilation error. break used outside of a loop")
+
+	err = i.InterpretFile(filepath.Join(dir, "throw.nd"), vm.New())
+	assert.EqualError(t, err, filepath.Join(dir, "throw.nd")+":2:1: runtime error. uncaught error: top")
+}
+
+func TestImportModulesWithTheSameName(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.nd": `
+import "a/util"
+import "b/util" as other
+auto first :: new util.Point{}
+auto second :: other.make()
+other.Point third :: new other.Point{}
+`,
+		"duplicate.nd": `
+import "a/util"
+import "b/util"
+`,
+		"a/util.nd": `type Point :: { int x :: 1 }`,
+		"b/util.nd": `
+type Point :: { int x :: 2 }
+auto make :: func() Point { return new Point{} }
+`,
+	})
+	i := interpreter.NewInterpreter()
+	instance := vm.New()
+	err := i.InterpretFile(filepath.Join(dir, "main.nd"), instance)
+	assert.NoError(t, err)
+
+	first, _ := instance.GetGlobal("first")
+	second, _ := instance.GetGlobal("second")
+	third, _ := instance.GetGlobal("third")
+	assert.Equal(t, "util.Point", first.RuntimeType.GetName())
+	assert.Equal(t, "util#2.Point", second.RuntimeType.GetName())
+	assert.Equal(t, second.RuntimeType, third.RuntimeType)
+	assert.Error(t, first.RuntimeType.Match(second.RuntimeType))
+
+	err = i.InterpretFile(filepath.Join(dir, "duplicate.nd"), vm.New())
+	assert.ErrorContains(t, err, `namespace [util] is already bound by the import of "a/util"`)
+}
+
+func TestFailedGenericDeclarationDoesNotLeakTypeParams(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.nd": `
+import "lib"
+auto first :: func[T](T a, lib.Missing b) T {
+    return a
+}
+`,
+		"lib.nd": `type Point :: { int x :: 0 }`,
+	})
+	instance := vm.New()
+	i := interpreter.NewInterpreter()
+	err := i.InterpretFile(filepath.Join(dir, "main.nd"), instance)
+	assert.ErrorContains(t, err, "Missing")
+
+	// a leaked type parameter T would shadow the type T
+	err = i.Interpret(`
+type T :: { int v :: 1 }
+auto t :: new T{}
+int v :: t.v
+`, instance)
+	assert.NoError(t, err)
+}
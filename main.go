@@ -2,7 +2,11 @@ package main
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/dani-gouken/nomad/bytecode"
+	"github.com/dani-gouken/nomad/compiler"
 	"github.com/dani-gouken/nomad/interpreter"
 	"github.com/dani-gouken/nomad/repl"
 	"github.com/dani-gouken/nomad/vm"
@@ -17,17 +21,83 @@ func main() {
 		repl.Start()
 		return
 	}
-	bytes, err := os.ReadFile(sourceFile)
-	if err != nil {
-		panic(err)
+	if sourceFile == "build" {
+		err := build(os.Args[2:])
+		if err != nil {
+			println(err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	if sourceFile == "disasm" {
+		err := disasm(os.Args[2:])
+		if err != nil {
+			println(err.Error())
+			os.Exit(1)
+		}
+		return
 	}
 
 	instance := vm.New()
+	instance.SetFsPermission(vm.FS_PERMISSION_READ_WRITE)
 
 	interpreter := interpreter.NewInterpreter()
-	err = interpreter.Interpret(string(bytes), instance)
+	err := interpreter.InterpretFile(sourceFile, instance)
 	if err != nil {
 		println(err.Error())
 	}
 
 }
+
+// build handles `nomad build file.nd [-o file.ndc]`
+func build(args []string) error {
+	sourceFile := ""
+	output := ""
+	for i := 0; i < len(args); i++ {
+		if args[i] == "-o" && i+1 < len(args) {
+			output = args[i+1]
+			i++
+			continue
+		}
+		sourceFile = args[i]
+	}
+	if sourceFile == "" {
+		return errUsage("nomad build file.nd [-o file.ndc]")
+	}
+	if output == "" {
+		output = strings.TrimSuffix(sourceFile, filepath.Ext(sourceFile)) + bytecode.EXTENSION
+	}
+	interpreter := interpreter.NewInterpreter()
+	return interpreter.BuildFile(sourceFile, output)
+}
+
+// disasm handles `nomad disasm file.nd`
+func disasm(args []string) error {
+	if len(args) != 1 {
+		return errUsage("nomad disasm file.nd")
+	}
+	path, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+	instructions, err := interpreter.NewFileModuleLoader(vm.New()).Load(path)
+	if err != nil {
+		return err
+	}
+	source := ""
+	if filepath.Ext(path) != bytecode.EXTENSION {
+		bytes, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		source = string(bytes)
+	}
+	return compiler.Disassemble(os.Stdout, instructions, source)
+}
+
+type errUsage string
+
+func (e errUsage) Error() string {
+	return "usage: " + string(e)
+}
@@ -10,6 +10,8 @@ import (
 const (
 	OPERATOR_PRECEDENCE_INVALID = iota
 	OPERATOR_PRECEDENCE_MINIMUM
+	OPERATOR_PRECEDENCE_LOGICAL_OR
+	OPERATOR_PRECEDENCE_LOGICAL_AND
 	OPERATOR_PRECEDENCE_LOW
 	OPERATOR_PRECEDENCE_REGULAR
 	OPERATOR_PRECEDENCE_HIGH
@@ -29,7 +31,18 @@ func (p *Parser) parseFuncExpr() (Expr, *nomadError.ParseError) {
 	if err != nil {
 		return Expr{}, err
 	}
+	funcToken, _ := p.peek()
 	p.consume()
+	return p.parseFuncLiteral(funcToken)
+}
+
+// parseFuncLiteral parses what follows the func keyword: the type
+// parameters, the parameters, the return type and the body.
+func (p *Parser) parseFuncLiteral(funcToken tokenizer.Token) (Expr, *nomadError.ParseError) {
+	typeParamListExpr, err := p.parseTypeParamListExpr()
+	if err != nil {
+		return Expr{}, err
+	}
 	err = p.expectF(tokenizer.TOKEN_KIND_LEFT_BRACKET, "opening bracket")
 	if err != nil {
 		return Expr{}, err
@@ -55,18 +68,55 @@ func (p *Parser) parseFuncExpr() (Expr, *nomadError.ParseError) {
 
 	block, err := p.parseBlock()
 	if err != nil {
-		return Expr{}, nomadError.NewParseErrorFromMessage(err.Error(), true)
+		return Expr{}, err.Fatal()
+	}
+	children := []Expr{paramListExpr, retTypeExpr}
+	if len(typeParamListExpr.Children) > 0 {
+		children = append(children, typeParamListExpr)
 	}
 	return Expr{
-		Kind: EXPR_KIND_FUNC,
-		Children: []Expr{
-			paramListExpr,
-			retTypeExpr,
-		},
-		Block: block,
+		Kind:     EXPR_KIND_FUNC,
+		Token:    funcToken,
+		Children: children,
+		Block:    block,
 	}, nil
 
 }
+
+// parseTypeParamListExpr parses the type parameters of a generic function:
+// func[T, U](T a, U b) T
+func (p *Parser) parseTypeParamListExpr() (Expr, *nomadError.ParseError) {
+	expr := Expr{
+		Kind:     EXPR_KIND_TYPE_PARAM_LIST,
+		Children: []Expr{},
+	}
+	err := p.expectNF(tokenizer.TOKEN_KIND_LEFT_SQUARE_BRACKET, "opening bracket ([)")
+	if err != nil {
+		return expr, nil
+	}
+	p.consume()
+	for {
+		err := p.expectF(tokenizer.TOKEN_KIND_ID, "type parameter name")
+		if err != nil {
+			return expr, err
+		}
+		name, _ := p.peek()
+		p.consume()
+		expr.Children = append(expr.Children, Expr{
+			Kind:  EXPR_KIND_TYPE_PARAM,
+			Token: name,
+		})
+		t, _ := p.peek()
+		if t.Kind == tokenizer.TOKEN_KIND_RIGHT_SQUARE_BRACKET {
+			p.consume()
+			return expr, nil
+		}
+		if t.Kind != tokenizer.TOKEN_KIND_COMMA {
+			return expr, nomadError.FatalParseError("expected end of type parameter list or closing bracket (])", t)
+		}
+		p.consume()
+	}
+}
 func (p *Parser) parseFuncParamListExpr() (Expr, *nomadError.ParseError) {
 	expr := Expr{
 		Kind:     EXPR_KIND_FUNC_PARAM_LIST,
@@ -101,6 +151,12 @@ func (p *Parser) parseArgumentList() (Expr, *nomadError.ParseError) {
 	for {
 		p.cleanupNewLines()
 		t, _ := p.peek()
+		if len(args) == 0 && t.Kind == tokenizer.TOKEN_KIND_RIGHT_BRACKET {
+			return Expr{
+				Kind:     EXPR_KIND_FUNC_ARG_LIST,
+				Children: args,
+			}, nil
+		}
 		argExpr, err := p.parseArgument()
 		if hasNamedArgument && argExpr.Kind == EXPR_KIND_FUNC_ARG {
 			return Expr{}, nomadError.FatalParseError("positional arguments are not allowed after named argument", argExpr.Token)
@@ -213,7 +269,7 @@ func (p *Parser) parseFuncParamExpr() (Expr, *nomadError.ParseError) {
 	p.consume()
 	defaultValueExpr, err := p.parseBasePrimaryExpr()
 	if err != nil {
-		return expr, nomadError.NewParseErrorFromMessage(err.Error(), true)
+		return expr, err.Fatal()
 	}
 
 	expr.Children = append(expr.Children, defaultValueExpr)
@@ -252,6 +308,20 @@ func (p *Parser) parseUnaryOperatorExpr() (Expr, *nomadError.ParseError) {
 				expr,
 			},
 		}, nil
+	case tokenizer.TOKEN_KIND_TILDE:
+		p.consume()
+		expr, err := p.parsePrimaryExpr()
+		if err != nil {
+			p.spit()
+			return Expr{}, err
+		}
+		return Expr{
+			Kind:  EXPR_KIND_BIT_NOT,
+			Token: t,
+			Children: []Expr{
+				expr,
+			},
+		}, nil
 	case tokenizer.TOKEN_KIND_MINUS:
 		p.consume()
 		expr, err := p.parsePrimaryExpr()
@@ -266,21 +336,45 @@ func (p *Parser) parseUnaryOperatorExpr() (Expr, *nomadError.ParseError) {
 				expr,
 			},
 		}, nil
-	case tokenizer.TOKEN_KIND_LEN:
+	case tokenizer.TOKEN_KIND_LEN, tokenizer.TOKEN_KIND_KEYS, tokenizer.TOKEN_KIND_UNWRAP:
 		p.consume()
 		expr, err := p.parsePrimaryExpr()
 		if err != nil {
 			p.spit()
 			return Expr{}, err
 		}
+		kind := EXPR_KIND_LEN
+		if t.Kind == tokenizer.TOKEN_KIND_KEYS {
+			kind = EXPR_KIND_KEYS
+		}
+		if t.Kind == tokenizer.TOKEN_KIND_UNWRAP {
+			kind = EXPR_KIND_UNWRAP
+		}
 		return Expr{
-			Kind:  EXPR_KIND_LEN,
+			Kind:  kind,
 			Token: t,
 			Children: []Expr{
 				expr,
 			},
 		}, nil
 
+	case tokenizer.TOKEN_KIND_SOME:
+		p.consume()
+		err := p.expectF(tokenizer.TOKEN_KIND_LEFT_BRACKET, "opening bracket (()")
+		if err != nil {
+			return Expr{}, err
+		}
+		expr, err := p.parseBracketExpr(p.parseExpr)
+		if err != nil {
+			return Expr{}, err
+		}
+		return Expr{
+			Kind:  EXPR_KIND_SOME,
+			Token: t,
+			Children: []Expr{
+				expr,
+			},
+		}, nil
 	case tokenizer.TOKEN_KIND_DB_MINUS:
 		p.consume()
 		expr, err := p.parseIdExpr()
@@ -346,25 +440,45 @@ func isBinaryOperatorToken(t tokenizer.Token) bool {
 }
 func getBinaryOperatorPrecedence(t tokenizer.Token) uint {
 	switch t.Kind {
-	case tokenizer.TOKEN_KIND_EQUAL:
-		return OPERATOR_PRECEDENCE_LOW
-	case tokenizer.TOKEN_KIND_PLUS,
-		tokenizer.TOKEN_KIND_MINUS,
-		tokenizer.TOKEN_KIND_SLASH,
+	case tokenizer.TOKEN_KIND_DB_BAR:
+		return OPERATOR_PRECEDENCE_LOGICAL_OR
+	case tokenizer.TOKEN_KIND_DB_AND:
+		return OPERATOR_PRECEDENCE_LOGICAL_AND
+	case tokenizer.TOKEN_KIND_EQUAL,
 		tokenizer.TOKEN_KIND_INFERIOR_SIGN,
 		tokenizer.TOKEN_KIND_INFERIOR_OR_EQ_SIGN,
 		tokenizer.TOKEN_KIND_SUPERIOR_SIGN,
 		tokenizer.TOKEN_KIND_SUPERIOR_OR_EQ_SIGN,
-		tokenizer.TOKEN_KIND_AND,
-		tokenizer.TOKEN_KIND_BAR:
+		tokenizer.TOKEN_KIND_IN:
+		return OPERATOR_PRECEDENCE_LOW
+	case tokenizer.TOKEN_KIND_PLUS,
+		tokenizer.TOKEN_KIND_MINUS,
+		tokenizer.TOKEN_KIND_BAR,
+		tokenizer.TOKEN_KIND_CARET:
 		return OPERATOR_PRECEDENCE_REGULAR
-	case tokenizer.TOKEN_KIND_STAR:
+	case tokenizer.TOKEN_KIND_STAR,
+		tokenizer.TOKEN_KIND_SLASH,
+		tokenizer.TOKEN_KIND_PERCENTAGE,
+		tokenizer.TOKEN_KIND_SHIFT_LEFT,
+		tokenizer.TOKEN_KIND_SHIFT_RIGHT,
+		tokenizer.TOKEN_KIND_AND:
 		return OPERATOR_PRECEDENCE_HIGH
 	default:
 		return OPERATOR_PRECEDENCE_INVALID
 	}
 }
 
+// bitwiseExprKinds maps the operators working on the bits of integers to their expression.
+// & and | also act as non short-circuiting logical operators on booleans.
+var bitwiseExprKinds = map[string]string{
+	tokenizer.TOKEN_KIND_PERCENTAGE:  EXPR_KIND_MODULO,
+	tokenizer.TOKEN_KIND_AND:         EXPR_KIND_BIT_AND,
+	tokenizer.TOKEN_KIND_BAR:         EXPR_KIND_BIT_OR,
+	tokenizer.TOKEN_KIND_CARET:       EXPR_KIND_BIT_XOR,
+	tokenizer.TOKEN_KIND_SHIFT_LEFT:  EXPR_KIND_SHIFT_LEFT,
+	tokenizer.TOKEN_KIND_SHIFT_RIGHT: EXPR_KIND_SHIFT_RIGHT,
+}
+
 func buildBinaryOpExpr(op tokenizer.Token, lhs Expr, rhs Expr) (Expr, *nomadError.ParseError) {
 	switch op.Kind {
 	case tokenizer.TOKEN_KIND_PLUS:
@@ -439,7 +553,7 @@ func buildBinaryOpExpr(op tokenizer.Token, lhs Expr, rhs Expr) (Expr, *nomadErro
 				lhs, rhs,
 			},
 		}, nil
-	case tokenizer.TOKEN_KIND_AND:
+	case tokenizer.TOKEN_KIND_DB_AND:
 		return Expr{
 			Kind:  EXPR_KIND_AND,
 			Token: op,
@@ -447,7 +561,7 @@ func buildBinaryOpExpr(op tokenizer.Token, lhs Expr, rhs Expr) (Expr, *nomadErro
 				lhs, rhs,
 			},
 		}, nil
-	case tokenizer.TOKEN_KIND_BAR:
+	case tokenizer.TOKEN_KIND_DB_BAR:
 		return Expr{
 			Kind:  EXPR_KIND_OR,
 			Token: op,
@@ -455,6 +569,24 @@ func buildBinaryOpExpr(op tokenizer.Token, lhs Expr, rhs Expr) (Expr, *nomadErro
 				lhs, rhs,
 			},
 		}, nil
+	case tokenizer.TOKEN_KIND_IN:
+		return Expr{
+			Kind:  EXPR_KIND_IN,
+			Token: op,
+			Children: []Expr{
+				lhs, rhs,
+			},
+		}, nil
+	}
+	kind, ok := bitwiseExprKinds[op.Kind]
+	if ok {
+		return Expr{
+			Kind:  kind,
+			Token: op,
+			Children: []Expr{
+				lhs, rhs,
+			},
+		}, nil
 	}
 	return Expr{}, nomadError.FatalParseError(fmt.Sprintf("unknown binary operator %s", op.Kind), op)
 }
@@ -496,7 +628,7 @@ func (p *Parser) parseBinaryOperatorExpr(lhs Expr, minPrecedence uint) (Expr, *n
 func (p *Parser) parseConstantExpr() (Expr, *nomadError.ParseError) {
 	t, _ := p.peek()
 	switch t.Kind {
-	case tokenizer.TOKEN_KIND_NUM_LIT, tokenizer.TOKEN_KIND_TRUE, tokenizer.TOKEN_KIND_FALSE, tokenizer.TOKEN_KIND_STRING_LIT:
+	case tokenizer.TOKEN_KIND_NUM_LIT, tokenizer.TOKEN_KIND_TRUE, tokenizer.TOKEN_KIND_FALSE, tokenizer.TOKEN_KIND_STRING_LIT, tokenizer.TOKEN_KIND_NONE:
 		p.consume()
 		return Expr{
 			Kind:  EXPR_KIND_CONSTANT,
@@ -506,6 +638,55 @@ func (p *Parser) parseConstantExpr() (Expr, *nomadError.ParseError) {
 	return Expr{}, nomadError.FatalParseError("could not parse constant", t)
 }
 
+// parseInterpolationExpr parses an interpolated string, its children being
+// the string literals and the {expression} parts, in order.
+func (p *Parser) parseInterpolationExpr() (Expr, *nomadError.ParseError) {
+	err := p.expectNF(tokenizer.TOKEN_KIND_INTERPOLATION_START, "interpolated string")
+	if err != nil {
+		return Expr{}, err
+	}
+	startToken, _ := p.peek()
+	p.consume()
+	expr := Expr{
+		Kind:     EXPR_KIND_INTERPOLATION,
+		Token:    startToken,
+		Children: []Expr{},
+	}
+	for {
+		t, ok := p.peek()
+		if !ok {
+			return Expr{}, nomadError.FatalParseError("unterminated interpolated string", startToken)
+		}
+		switch t.Kind {
+		case tokenizer.TOKEN_KIND_INTERPOLATION_END:
+			p.consume()
+			return expr, nil
+		case tokenizer.TOKEN_KIND_STRING_LIT:
+			p.consume()
+			expr.Children = append(expr.Children, Expr{
+				Kind:  EXPR_KIND_CONSTANT,
+				Token: t,
+			})
+		default:
+			err = p.expectF(tokenizer.TOKEN_KIND_LEFT_CURCLY, "opening bracket ({)")
+			if err != nil {
+				return Expr{}, err
+			}
+			p.consume()
+			part, err := p.parseExpr()
+			if err != nil {
+				return Expr{}, err.Fatal()
+			}
+			err = p.expectF(tokenizer.TOKEN_KIND_RIGHT_CURLY, "closing bracket (})")
+			if err != nil {
+				return Expr{}, err
+			}
+			p.consume()
+			expr.Children = append(expr.Children, part)
+		}
+	}
+}
+
 func (p *Parser) parseIdExpr() (Expr, *nomadError.ParseError) {
 	t, ok := p.peek()
 	if !ok {
@@ -658,6 +839,10 @@ func (p *Parser) parseBasePrimaryExpr() (Expr, *nomadError.ParseError) {
 	if err == nil {
 		return expr, err
 	}
+	expr, err = p.parseInterpolationExpr()
+	if err == nil || err.ShouldCrash() {
+		return expr, err
+	}
 	expr, err = p.parseUnaryOperatorExpr()
 	if err == nil {
 		return expr, err
@@ -666,6 +851,10 @@ func (p *Parser) parseBasePrimaryExpr() (Expr, *nomadError.ParseError) {
 	if err == nil {
 		return expr, err
 	}
+	expr, err = p.parseMapExpr()
+	if err == nil || err.ShouldCrash() {
+		return expr, err
+	}
 
 	expr, err = p.parseIdExpr()
 	if err == nil {
@@ -774,14 +963,12 @@ func (p *Parser) parseObjectExpr() (Expr, *nomadError.ParseError) {
 	if err != nil {
 		return Expr{}, err
 	}
+	t := p.parseQualifiedName()
 
-	err = p.expectNextF(tokenizer.TOKEN_KIND_LEFT_CURCLY, 1, "opening curly bracket ({)")
+	err = p.expectF(tokenizer.TOKEN_KIND_LEFT_CURCLY, "opening curly bracket ({)")
 	if err != nil {
 		return Expr{}, err
 	}
-
-	t, _ := p.peek()
-	p.consume()
 	p.consume()
 	p.cleanupNewLines()
 
@@ -892,26 +1079,115 @@ func (p *Parser) parseArrayAccess(baseExpr Expr) (Expr, *nomadError.ParseError)
 	if err != nil {
 		return baseExpr, nil
 	}
-
-	err = p.expectNextF(tokenizer.TOKEN_KIND_NUM_LIT, 1, "index")
+	begin := p.cursor
+	p.consume()
+	index, err := p.parseExpr()
+	if err != nil {
+		p.rollback(begin)
+		return p.parseTypeArgList(baseExpr)
+	}
+	err = p.expectNF(tokenizer.TOKEN_KIND_RIGHT_SQUARE_BRACKET, "closing bracket (])")
 	if err != nil {
-		err = p.expectNextF(tokenizer.TOKEN_KIND_ID, 1, "identifier")
+		p.rollback(begin)
+		return p.parseTypeArgList(baseExpr)
+	}
+	p.consume()
+
+	return p.parseArrayAccess(Expr{
+		Kind:     EXPR_KIND_ARRAY_ACCESS,
+		Children: []Expr{baseExpr, index},
+		Token:    index.Token,
+	})
+}
+
+// parseTypeArgList parses the type arguments instantiating a generic
+// function, when they cannot be parsed as an index: pair[string, [int]]
+func (p *Parser) parseTypeArgList(baseExpr Expr) (Expr, *nomadError.ParseError) {
+	begin := p.cursor
+	t, _ := p.peek()
+	p.consume()
+	expr := Expr{
+		Kind:     EXPR_KIND_TYPE_ARG_LIST,
+		Token:    t,
+		Children: []Expr{baseExpr},
+	}
+	for {
+		typeExpr, err := p.parseTypeExpr(false)
 		if err != nil {
+			p.rollback(begin)
+			return baseExpr, nil
+		}
+		expr.Children = append(expr.Children, typeExpr)
+		t, _ := p.peek()
+		if t.Kind == tokenizer.TOKEN_KIND_RIGHT_SQUARE_BRACKET {
+			p.consume()
+			return p.parseArrayAccess(expr)
+		}
+		if t.Kind != tokenizer.TOKEN_KIND_COMMA {
+			p.rollback(begin)
 			return baseExpr, nil
 		}
+		p.consume()
 	}
-	err = p.expectNextNF(tokenizer.TOKEN_KIND_RIGHT_SQUARE_BRACKET, 2, "closing bracket (])")
+}
+
+// parseMapExpr parses a map literal: {string: int}{"a": 1, "b": 2}
+func (p *Parser) parseMapExpr() (Expr, *nomadError.ParseError) {
+	mapTypeExpr, err := p.parseMapTypeExpr()
+	if err != nil {
+		return Expr{}, err
+	}
+	err = p.expectF(tokenizer.TOKEN_KIND_LEFT_CURCLY, "opening bracket ({)")
 	if err != nil {
 		return Expr{}, err
 	}
-	p.consume()
-	index, _ := p.peek()
-	p.consume()
 	p.consume()
 
-	return p.parseArrayAccess(Expr{
-		Kind:     EXPR_KIND_ARRAY_ACCESS,
-		Children: []Expr{baseExpr},
-		Token:    index,
-	})
+	entries := []Expr{}
+	for {
+		p.cleanupNewLines()
+		t, _ := p.peek()
+		if t.Kind == tokenizer.TOKEN_KIND_RIGHT_CURLY {
+			p.consume()
+			break
+		}
+		key, err := p.parseExpr()
+		if err != nil {
+			return Expr{}, err.Fatal()
+		}
+		err = p.expectF(tokenizer.TOKEN_KIND_COLON, "colon (:)")
+		if err != nil {
+			return Expr{}, err
+		}
+		colon, _ := p.peek()
+		p.consume()
+		value, err := p.parseExpr()
+		if err != nil {
+			return Expr{}, err.Fatal()
+		}
+		entries = append(entries, Expr{
+			Kind:     EXPR_KIND_MAP_ENTRY,
+			Token:    colon,
+			Children: []Expr{key, value},
+		})
+		p.cleanupNewLines()
+		t, _ = p.peek()
+		if t.Kind == tokenizer.TOKEN_KIND_COMMA {
+			p.consume()
+		} else if t.Kind != tokenizer.TOKEN_KIND_RIGHT_CURLY {
+			return Expr{}, nomadError.FatalParseError(fmt.Sprintf("expected comma or closing bracket (}), got %s", t.Kind), t)
+		}
+	}
+
+	return Expr{
+		Kind:  EXPR_KIND_MAP,
+		Token: mapTypeExpr.Token,
+		Children: []Expr{
+			mapTypeExpr,
+			{
+				Kind:     EXPR_KIND_ANONYMOUS,
+				Children: entries,
+			},
+		},
+	}, nil
 }
@@ -39,6 +39,25 @@ const (
 	EXPR_KIND_TYPE_OBJ        = "TYPE_OBJ"
 	EXPR_KIND_TYPE_OBJ_FIELD  = "TYPE_OBJ_FIELD"
 	EXPR_KIND_TYPE_FUNC       = "TYPE_FUNC"
+	EXPR_KIND_TYPE_MAP        = "TYPE_MAP"
+	EXPR_KIND_MAP             = "MAP"
+	EXPR_KIND_MAP_ENTRY       = "MAP_ENTRY"
+	EXPR_KIND_IN              = "IN"
+	EXPR_KIND_KEYS            = "KEYS"
+	EXPR_KIND_TYPE_ENUM       = "TYPE_ENUM"
+	EXPR_KIND_TYPE_ENUM_CASE  = "TYPE_ENUM_CASE"
+	EXPR_KIND_TYPE_ENUM_FIELD = "TYPE_ENUM_FIELD"
+	EXPR_KIND_TYPE_OPTIONAL   = "TYPE_OPTIONAL"
+	EXPR_KIND_SOME            = "SOME"
+	EXPR_KIND_UNWRAP          = "UNWRAP"
+	EXPR_KIND_MODULO          = "MODULO"
+	EXPR_KIND_BIT_AND         = "BIT_AND"
+	EXPR_KIND_BIT_OR          = "BIT_OR"
+	EXPR_KIND_BIT_XOR         = "BIT_XOR"
+	EXPR_KIND_BIT_NOT         = "BIT_NOT"
+	EXPR_KIND_SHIFT_LEFT      = "SHIFT_LEFT"
+	EXPR_KIND_SHIFT_RIGHT     = "SHIFT_RIGHT"
+	EXPR_KIND_INTERPOLATION   = "INTERPOLATION"
 
 	EXPR_KIND_FUNC            = "FUNC"
 	EXPR_KIND_FUNC_CALL       = "FUNC_CALL"
@@ -48,6 +67,10 @@ const (
 	EXPR_KIND_FUNC_ARG_LIST   = "FUNC_ARG_LIST"
 	EXPR_KIND_FUNC_NAMED_ARG  = "FUNC_NAMED_ARG"
 
+	EXPR_KIND_TYPE_PARAM      = "TYPE_PARAM"
+	EXPR_KIND_TYPE_PARAM_LIST = "TYPE_PARAM_LIST"
+	EXPR_KIND_TYPE_ARG_LIST   = "TYPE_ARG_LIST"
+
 	EXPR_KIND_OBJ_FIELD          = "OBJ_FIELD"
 	EXPR_KIND_OBJ                = "OBJ"
 	EXPR_KIND_OBJ_ACCESS         = "OBJ_ACCESS"
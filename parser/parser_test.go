@@ -345,3 +345,253 @@ func TestOperatorPrecedenceWithBracket(t *testing.T) {
 	assert.Equal(t, sexpr, "(- (* (+ 1 2) 3) 69)")
 
 }
+
+func TestParseMap(t *testing.T) {
+	tokens, err := tokenizer.Tokenize(`{string: [int]} m :: {string: [int]}{"a": [int]{1}, "b": [int]{}}
+m[key + "c"] :: m["a"]`)
+	assert.NoError(t, err)
+	ast, err := parser.Parse(tokens)
+	assert.NoError(t, err)
+	assert.Len(t, ast.Stmts, 2)
+
+	declaration := ast.Stmts[0]
+	typeExpr := declaration.Expr.Children[1]
+	assert.Equal(t, parser.EXPR_KIND_TYPE_MAP, typeExpr.Kind)
+	assert.Equal(t, "string", typeExpr.Children[0].Token.Content)
+	assert.Equal(t, parser.EXPR_KIND_TYPE_ARRAY, typeExpr.Children[1].Kind)
+	literal := declaration.Expr.Children[0]
+	assert.Equal(t, parser.EXPR_KIND_MAP, literal.Kind)
+	assert.Len(t, literal.Children[1].Children, 2)
+	assert.Equal(t, parser.EXPR_KIND_MAP_ENTRY, literal.Children[1].Children[0].Kind)
+
+	assignment := ast.Stmts[1]
+	assert.Equal(t, parser.STMT_KIND_ARR_ASSIGNMENT, assignment.Kind)
+	target := assignment.Expr.Children[0]
+	assert.Equal(t, parser.EXPR_KIND_ARRAY_ACCESS, target.Kind)
+	assert.Equal(t, "(+ key \"c\")", parser.ExprToSExpr(target.Children[1]))
+	assert.Equal(t, parser.EXPR_KIND_ARRAY_ACCESS, assignment.Expr.Children[1].Kind)
+}
+
+func TestParseEnumAndMatch(t *testing.T) {
+	tokens, err := tokenizer.Tokenize(`enum Shape :: {
+    Circle(float radius)
+    Rect(float width, float height), Empty
+}
+match shape {
+    Rect(w, h) { print w }
+    else {
+        print 0
+    }
+}`)
+	assert.NoError(t, err)
+	ast, err := parser.Parse(tokens)
+	assert.NoError(t, err)
+	assert.Len(t, ast.Stmts, 2)
+
+	declaration := ast.Stmts[0]
+	assert.Equal(t, parser.STMT_KIND_ENUM_DECLARATION, declaration.Kind)
+	assert.Equal(t, "Shape", declaration.Data[0].Content)
+	variants := declaration.Expr.Children
+	assert.Len(t, variants, 3)
+	assert.Equal(t, "Rect", variants[1].Token.Content)
+	assert.Len(t, variants[1].Children, 2)
+	assert.Equal(t, "height", variants[1].Children[1].Token.Content)
+	assert.Empty(t, variants[2].Children)
+
+	match := ast.Stmts[1]
+	assert.Equal(t, parser.STMT_KIND_MATCH, match.Kind)
+	assert.Equal(t, "shape", match.Expr.Token.Content)
+	assert.Len(t, match.Children, 2)
+	assert.Equal(t, parser.STMT_KIND_MATCH_ARM, match.Children[0].Kind)
+	assert.Equal(t, []string{"Rect", "w", "h"}, []string{match.Children[0].Data[0].Content, match.Children[0].Data[1].Content, match.Children[0].Data[2].Content})
+	assert.Equal(t, parser.STMT_KIND_ELSE, match.Children[1].Kind)
+	assert.Len(t, match.Children[1].Children, 1)
+}
+
+func TestParseOptional(t *testing.T) {
+	tokens, err := tokenizer.Tokenize("?[int] a :: none\nprint Some(a) && Some(unwrap a)")
+	assert.NoError(t, err)
+	ast, err := parser.Parse(tokens)
+	assert.NoError(t, err)
+	assert.Len(t, ast.Stmts, 2)
+
+	declaration := ast.Stmts[0]
+	typeExpr := declaration.Expr.Children[1]
+	assert.Equal(t, parser.EXPR_KIND_TYPE_OPTIONAL, typeExpr.Kind)
+	assert.Equal(t, parser.EXPR_KIND_TYPE_ARRAY, typeExpr.Children[0].Kind)
+	assert.Equal(t, tokenizer.TOKEN_KIND_NONE, declaration.Expr.Children[0].Token.Kind)
+
+	condition := ast.Stmts[1].Expr
+	assert.Equal(t, parser.EXPR_KIND_AND, condition.Kind)
+	assert.Equal(t, parser.EXPR_KIND_SOME, condition.Children[0].Kind)
+	assert.Equal(t, parser.EXPR_KIND_UNWRAP, condition.Children[1].Children[0].Kind)
+}
+
+func TestParseTryCatch(t *testing.T) {
+	tokens, err := tokenizer.Tokenize("try {\n  print 1\n}\ncatch e {\n  throw e\n}\ntry { print 2 } catch { print 3 }")
+	assert.NoError(t, err)
+	ast, err := parser.Parse(tokens)
+	assert.NoError(t, err)
+	assert.Len(t, ast.Stmts, 2)
+
+	try := ast.Stmts[0]
+	assert.Equal(t, parser.STMT_KIND_TRY, try.Kind)
+	assert.Len(t, try.Children, 2)
+	assert.Equal(t, parser.STMT_KIND_SCOPE, try.Children[0].Kind)
+	assert.Len(t, try.Children[0].Children, 1)
+	catch := try.Children[1]
+	assert.Equal(t, parser.STMT_KIND_CATCH, catch.Kind)
+	assert.Equal(t, "e", catch.Data[1].Content)
+	assert.Equal(t, parser.STMT_KIND_THROW, catch.Children[0].Kind)
+
+	assert.Len(t, ast.Stmts[1].Children[1].Data, 1)
+}
+
+func TestParseBreakAndContinue(t *testing.T) {
+	tokens, err := tokenizer.Tokenize("for int i :: 0; i < 3; i++ {\n  if i = 1 { continue }\n  break\n}")
+	assert.NoError(t, err)
+	ast, err := parser.Parse(tokens)
+	assert.NoError(t, err)
+
+	loop := ast.Stmts[1]
+	assert.Equal(t, parser.STMT_KIND_FOR, loop.Kind)
+	assert.Equal(t, parser.STMT_KIND_CONTINUE, loop.Children[0].Children[0].Kind)
+	assert.Equal(t, parser.STMT_KIND_BREAK, loop.Children[1].Kind)
+}
+
+func TestParseForInAndWhile(t *testing.T) {
+	tokens, err := tokenizer.Tokenize("for item in items { print item }\nfor i, item in items { print i }\nfor running { print 1 }")
+	assert.NoError(t, err)
+	ast, err := parser.Parse(tokens)
+	assert.NoError(t, err)
+	assert.Len(t, ast.Stmts, 3)
+
+	assert.Equal(t, parser.STMT_KIND_FOR_IN, ast.Stmts[0].Kind)
+	assert.Len(t, ast.Stmts[0].Data, 1)
+	assert.Equal(t, "item", ast.Stmts[0].Data[0].Content)
+	assert.Equal(t, "items", ast.Stmts[0].Expr.Token.Content)
+
+	assert.Equal(t, "item", ast.Stmts[1].Data[0].Content)
+	assert.Equal(t, "i", ast.Stmts[1].Data[1].Content)
+
+	assert.Equal(t, parser.STMT_KIND_WHILE, ast.Stmts[2].Kind)
+	assert.Equal(t, "running", ast.Stmts[2].Expr.Token.Content)
+}
+
+func TestParseOperatorPrecedence(t *testing.T) {
+	tokens, err := tokenizer.Tokenize("print a < b + c * d % e && f | g = h || ~i << 2 >= j")
+	assert.NoError(t, err)
+	ast, err := parser.Parse(tokens)
+	assert.NoError(t, err)
+
+	or := ast.Stmts[0].Expr
+	assert.Equal(t, parser.EXPR_KIND_OR, or.Kind)
+	and := or.Children[0]
+	assert.Equal(t, parser.EXPR_KIND_AND, and.Kind)
+
+	less := and.Children[0]
+	assert.Equal(t, parser.EXPR_KIND_LESS_THAN, less.Kind)
+	addition := less.Children[1]
+	assert.Equal(t, parser.EXPR_KIND_ADDITION, addition.Kind)
+	modulo := addition.Children[1]
+	assert.Equal(t, parser.EXPR_KIND_MODULO, modulo.Kind)
+	assert.Equal(t, parser.EXPR_KIND_MULTIPLICATION, modulo.Children[0].Kind)
+
+	equal := and.Children[1]
+	assert.Equal(t, parser.EXPR_KIND_EQ, equal.Kind)
+	assert.Equal(t, parser.EXPR_KIND_BIT_OR, equal.Children[0].Kind)
+
+	moreOrEqual := or.Children[1]
+	assert.Equal(t, parser.EXPR_KIND_MORE_THAN_OR_EQ, moreOrEqual.Kind)
+	shift := moreOrEqual.Children[0]
+	assert.Equal(t, parser.EXPR_KIND_SHIFT_LEFT, shift.Kind)
+	assert.Equal(t, parser.EXPR_KIND_BIT_NOT, shift.Children[0].Kind)
+}
+
+func TestParseInterpolation(t *testing.T) {
+	tokens, err := tokenizer.Tokenize(`print "port {port + 1} of {name}\{}"`)
+	assert.NoError(t, err)
+	assert.Equal(t, tokenizer.TOKEN_KIND_INTERPOLATION_START, tokens[1].Kind)
+	assert.Equal(t, tokenizer.TOKEN_KIND_INTERPOLATION_END, tokens[len(tokens)-1].Kind)
+	ast, err := parser.Parse(tokens)
+	assert.NoError(t, err)
+
+	interpolation := ast.Stmts[0].Expr
+	assert.Equal(t, parser.EXPR_KIND_INTERPOLATION, interpolation.Kind)
+	assert.Len(t, interpolation.Children, 5)
+	assert.Equal(t, `"port "`, interpolation.Children[0].Token.Content)
+	assert.Equal(t, parser.EXPR_KIND_ADDITION, interpolation.Children[1].Kind)
+	assert.Equal(t, `" of "`, interpolation.Children[2].Token.Content)
+	assert.Equal(t, parser.EXPR_KIND_ID, interpolation.Children[3].Kind)
+	assert.Equal(t, `"{}"`, interpolation.Children[4].Token.Content)
+
+	tokens, err = tokenizer.Tokenize(`print "plain \{text}"`)
+	assert.NoError(t, err)
+	assert.Equal(t, tokenizer.TOKEN_KIND_STRING_LIT, tokens[1].Kind)
+	assert.Equal(t, `"plain {text}"`, tokens[1].Content)
+
+	_, err = tokenizer.Tokenize(`print "port {port"`)
+	assert.ErrorContains(t, err, "unterminated string interpolation")
+}
+
+func TestParseGenericFunc(t *testing.T) {
+	tokens, err := tokenizer.Tokenize("auto pair :: func[K, V](K key, V value) {K: V} { return {K: V}{key: value} }\nprint pair[string, [int]](\"a\", [int]{1})\nprint max[int](1, 2)")
+	assert.NoError(t, err)
+	ast, err := parser.Parse(tokens)
+	assert.NoError(t, err)
+	assert.Len(t, ast.Stmts, 3)
+
+	funcExpr := ast.Stmts[0].Expr.Children[0]
+	assert.Equal(t, parser.EXPR_KIND_FUNC, funcExpr.Kind)
+	assert.Len(t, funcExpr.Children, 3)
+	typeParams := funcExpr.Children[2]
+	assert.Equal(t, parser.EXPR_KIND_TYPE_PARAM_LIST, typeParams.Kind)
+	assert.Equal(t, []string{"K", "V"}, []string{typeParams.Children[0].Token.Content, typeParams.Children[1].Token.Content})
+
+	call := ast.Stmts[1].Expr
+	assert.Equal(t, parser.EXPR_KIND_FUNC_CALL, call.Kind)
+	instance := call.Children[0]
+	assert.Equal(t, parser.EXPR_KIND_TYPE_ARG_LIST, instance.Kind)
+	assert.Len(t, instance.Children, 3)
+	assert.Equal(t, parser.EXPR_KIND_TYPE, instance.Children[1].Kind)
+	assert.Equal(t, parser.EXPR_KIND_TYPE_ARRAY, instance.Children[2].Kind)
+
+	// a single identifier is parsed as an index, resolved as a type by the vm
+	call = ast.Stmts[2].Expr
+	assert.Equal(t, parser.EXPR_KIND_ARRAY_ACCESS, call.Children[0].Kind)
+
+	tokens, err = tokenizer.Tokenize("auto f :: func[T, 1](T a) T { return a }")
+	assert.NoError(t, err)
+	_, err = parser.Parse(tokens)
+	assert.ErrorContains(t, err, "type parameter name")
+}
+
+func TestParseMethodDeclaration(t *testing.T) {
+	tokens, err := tokenizer.Tokenize("func (Rect r) scale(float factor :: 2.0) Rect { return r }\nfunc (Rect r) pick[T](T a) T { return a }\nrect.scale(factor: 3.0)")
+	assert.NoError(t, err)
+	ast, err := parser.Parse(tokens)
+	assert.NoError(t, err)
+	assert.Len(t, ast.Stmts, 3)
+
+	stmt := ast.Stmts[0]
+	assert.Equal(t, parser.STMT_KIND_METHOD_DECLARATION, stmt.Kind)
+	assert.Equal(t, []string{"Rect", "r", "scale"}, []string{stmt.Data[0].Content, stmt.Data[1].Content, stmt.Data[2].Content})
+	assert.Equal(t, parser.EXPR_KIND_FUNC, stmt.Expr.Kind)
+	assert.Len(t, stmt.Expr.Children[0].Children, 1)
+
+	generic := ast.Stmts[1]
+	assert.Equal(t, parser.STMT_KIND_METHOD_DECLARATION, generic.Kind)
+	assert.Len(t, generic.Expr.Children, 3)
+
+	call := ast.Stmts[2].Expr
+	assert.Equal(t, parser.EXPR_KIND_FUNC_CALL, call.Kind)
+	assert.Equal(t, parser.EXPR_KIND_OBJ_ACCESS, call.Children[0].Kind)
+	assert.Equal(t, "scale", call.Children[0].Token.Content)
+
+	// a function literal returning a named type is not a method
+	tokens, err = tokenizer.Tokenize("auto f :: func(Rect r) Rect { return r }")
+	assert.NoError(t, err)
+	ast, err = parser.Parse(tokens)
+	assert.NoError(t, err)
+	assert.Equal(t, parser.STMT_KIND_VAR_DECLARATION, ast.Stmts[0].Kind)
+}
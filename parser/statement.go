@@ -2,25 +2,39 @@ package parser
 
 import (
 	"fmt"
+	"path/filepath"
+	"strings"
 
 	nomadError "github.com/dani-gouken/nomad/errors"
 	"github.com/dani-gouken/nomad/tokenizer"
 )
 
 const (
-	STMT_KIND_IMPLICIT_RETURN   = "IMPLICIT_RETURN"
-	STMT_KIND_VAR_DECLARATION   = "VARIABLE_DECLARATION"
-	STMT_KIND_CONST_DECLARATION = "CONST_DECLARATION"
-	STMT_KIND_TYPE_DECLARATION  = "TYPE_DECLARATION"
-	STMT_KIND_IF                = "IF"
-	STMT_KIND_DEBUG_PRINT       = "DEBUG_PRINT"
-	STMT_KIND_ELSE              = "ELSE"
-	STMT_KIND_FOR               = "FOR"
-	STMT_KIND_ELIF              = "ELIF"
-	STMT_KIND_SCOPE             = "SCOPE"
-	STMT_KIND_ASSIGNMENT        = "ASSIGNMENT"
-	STMT_KIND_ARR_ASSIGNMENT    = "ARR_ASSIGNMENT"
-	STMT_KIND_RETURN            = "RETURN"
+	STMT_KIND_IMPLICIT_RETURN    = "IMPLICIT_RETURN"
+	STMT_KIND_VAR_DECLARATION    = "VARIABLE_DECLARATION"
+	STMT_KIND_CONST_DECLARATION  = "CONST_DECLARATION"
+	STMT_KIND_TYPE_DECLARATION   = "TYPE_DECLARATION"
+	STMT_KIND_IF                 = "IF"
+	STMT_KIND_DEBUG_PRINT        = "DEBUG_PRINT"
+	STMT_KIND_ELSE               = "ELSE"
+	STMT_KIND_FOR                = "FOR"
+	STMT_KIND_FOR_IN             = "FOR_IN"
+	STMT_KIND_WHILE              = "WHILE"
+	STMT_KIND_ELIF               = "ELIF"
+	STMT_KIND_SCOPE              = "SCOPE"
+	STMT_KIND_ASSIGNMENT         = "ASSIGNMENT"
+	STMT_KIND_ARR_ASSIGNMENT     = "ARR_ASSIGNMENT"
+	STMT_KIND_RETURN             = "RETURN"
+	STMT_KIND_IMPORT             = "IMPORT"
+	STMT_KIND_ENUM_DECLARATION   = "ENUM_DECLARATION"
+	STMT_KIND_METHOD_DECLARATION = "METHOD_DECLARATION"
+	STMT_KIND_MATCH              = "MATCH"
+	STMT_KIND_MATCH_ARM          = "MATCH_ARM"
+	STMT_KIND_TRY                = "TRY"
+	STMT_KIND_CATCH              = "CATCH"
+	STMT_KIND_THROW              = "THROW"
+	STMT_KIND_BREAK              = "BREAK"
+	STMT_KIND_CONTINUE           = "CONTINUE"
 )
 
 func (p *Parser) parseStmts() ([]*Stmt, *nomadError.ParseError) {
@@ -67,10 +81,19 @@ func (p *Parser) parseImplicitReturnStmt() ([]*Stmt, *nomadError.ParseError) {
 
 func (p *Parser) parseStmt() ([]*Stmt, *nomadError.ParseError) {
 	parseFuncs := []func() ([]*Stmt, *nomadError.ParseError){
+		p.parseImport,
+		p.parseIndexAssignment,
 		p.parseAssignment,
 		p.parsePrint,
 		p.parseReturn,
 		p.parseTypeDeclaration,
+		p.parseEnumDeclaration,
+		p.parseMethodDeclaration,
+		p.parseMatch,
+		p.parseTry,
+		p.parseThrow,
+		p.parseBreak,
+		p.parseContinue,
 		p.parseConstantDeclaration,
 		p.parseVariableDeclaration,
 		p.parseIfStatement,
@@ -178,11 +201,17 @@ func (p *Parser) parseForLoop() ([]*Stmt, *nomadError.ParseError) {
 		return nil, err
 	}
 	p.consume()
+	if p.isForIn() {
+		return p.parseForIn(t)
+	}
+	pos := p.cursor
 	initStmt, err := p.parseVariableDeclaration()
 	if err != nil {
+		p.rollback(pos)
 		initStmt, err = p.parseAssignment()
 		if err != nil {
-			return initStmt, nomadError.FatalParseError("for loop init expr should be an assignment or a variable declaration", t)
+			p.rollback(pos)
+			return p.parseWhileLoop(t)
 		}
 	}
 	testExpr, err := p.parseExpr()
@@ -216,6 +245,70 @@ func (p *Parser) parseForLoop() ([]*Stmt, *nomadError.ParseError) {
 		Children: append(operations, iterStmt...),
 	}), nil
 }
+
+// isForIn reports whether the loop iterates over a value: for item in items, for i, item in items
+func (p *Parser) isForIn() bool {
+	offset := 0
+	first, _ := p.peekAt(0)
+	second, _ := p.peekAt(1)
+	if first.Kind == tokenizer.TOKEN_KIND_ID && second.Kind == tokenizer.TOKEN_KIND_COMMA {
+		offset = 2
+	}
+	item, _ := p.peekAt(offset)
+	in, _ := p.peekAt(offset + 1)
+	return item.Kind == tokenizer.TOKEN_KIND_ID && in.Kind == tokenizer.TOKEN_KIND_IN
+}
+
+// parseForIn parses a loop over the items of an array, the characters of a
+// string or the keys of a map. The item comes first in the data of the
+// statement, followed by the index when it is bound, the index of a map
+// item being its key.
+func (p *Parser) parseForIn(forToken tokenizer.Token) ([]*Stmt, *nomadError.ParseError) {
+	first, _ := p.peek()
+	p.consume()
+	data := []tokenizer.Token{first}
+	next, _ := p.peek()
+	if next.Kind == tokenizer.TOKEN_KIND_COMMA {
+		p.consume()
+		item, _ := p.peek()
+		p.consume()
+		data = []tokenizer.Token{item, first}
+	}
+	p.consume()
+	iterable, err := p.parseExpr()
+	if err != nil {
+		return nil, nomadError.FatalParseError("for loop should iterate over an expression", forToken)
+	}
+	operations, err := p.parseBlock()
+	if err != nil {
+		return nil, err
+	}
+	return []*Stmt{{
+		Data:     data,
+		Expr:     iterable,
+		Kind:     STMT_KIND_FOR_IN,
+		Children: operations,
+	}}, nil
+}
+
+// parseWhileLoop parses a loop running as long as its condition holds: for cond { }
+func (p *Parser) parseWhileLoop(forToken tokenizer.Token) ([]*Stmt, *nomadError.ParseError) {
+	testExpr, err := p.parseExpr()
+	if err != nil {
+		return nil, nomadError.FatalParseError("for loop should be followed by a condition, an init statement or an iteration (item in items)", forToken)
+	}
+	operations, err := p.parseBlock()
+	if err != nil {
+		return nil, err
+	}
+	return []*Stmt{{
+		Data:     []tokenizer.Token{forToken},
+		Expr:     testExpr,
+		Kind:     STMT_KIND_WHILE,
+		Children: operations,
+	}}, nil
+}
+
 func (p *Parser) parseIfStatement() ([]*Stmt, *nomadError.ParseError) {
 	stmts, err := p.parseFlowControlStatement(tokenizer.TOKEN_KIND_IF, STMT_KIND_IF, true)
 	if err != nil {
@@ -283,6 +376,42 @@ func (p *Parser) parseAssignment() ([]*Stmt, *nomadError.ParseError) {
 	return append(stmts, &stmt), nil
 }
 
+// parseIndexAssignment parses the assignment of an array item or a map entry: a[i] :: value
+func (p *Parser) parseIndexAssignment() ([]*Stmt, *nomadError.ParseError) {
+	pos := p.cursor
+	err := p.expectNF(tokenizer.TOKEN_KIND_ID, "identifier (variable name)")
+	if err != nil {
+		return []*Stmt{}, err
+	}
+	t, _ := p.peek()
+	target, err := p.parsePrimaryExpr()
+	if err != nil || target.Kind != EXPR_KIND_ARRAY_ACCESS {
+		p.rollback(pos)
+		return []*Stmt{}, nomadError.NonFatalParseError("expected indexed expression", t)
+	}
+	err = p.expectNF(tokenizer.TOKEN_KIND_DB_COLON, "double colon (::)")
+	if err != nil {
+		p.rollback(pos)
+		return []*Stmt{}, err
+	}
+	p.consume()
+	value, err := p.parseExpr()
+	if err != nil {
+		return []*Stmt{}, err
+	}
+	stmt := Stmt{
+		Kind: STMT_KIND_ARR_ASSIGNMENT,
+		Expr: Expr{
+			Kind:     EXPR_KIND_ANONYMOUS,
+			Token:    target.Token,
+			Children: []Expr{target, value},
+		},
+	}
+	p.terminateStmt(stmt)
+
+	return []*Stmt{&stmt}, nil
+}
+
 func (p *Parser) parseVariableDeclaration() ([]*Stmt, *nomadError.ParseError) {
 	pos := p.cursor
 	t, _ := p.peek()
@@ -374,6 +503,283 @@ func (p *Parser) parseTypeDeclaration() ([]*Stmt, *nomadError.ParseError) {
 	return []*Stmt{&stmt}, nil
 }
 
+func (p *Parser) parseEnumDeclaration() ([]*Stmt, *nomadError.ParseError) {
+	err := p.expectNF(tokenizer.TOKEN_KIND_ENUM, "keyword (enum)")
+	if err != nil {
+		return []*Stmt{}, err
+	}
+	err = p.expectNextF(tokenizer.TOKEN_KIND_ID, 1, "identifier (enum name)")
+	if err != nil {
+		return []*Stmt{}, err
+	}
+	err = p.expectNextF(tokenizer.TOKEN_KIND_DB_COLON, 2, "double colon (::)")
+	if err != nil {
+		return []*Stmt{}, err
+	}
+	p.consume()
+	enumName, _ := p.peek()
+	p.consume()
+	p.consume() // consume equal sign
+
+	value, err := p.parseEnumTypeExpr()
+	if err != nil {
+		return []*Stmt{}, err
+	}
+	stmt := Stmt{
+		Data: []tokenizer.Token{enumName},
+		Kind: STMT_KIND_ENUM_DECLARATION,
+		Expr: value,
+	}
+
+	p.terminateStmt(stmt)
+
+	return []*Stmt{&stmt}, nil
+}
+
+// parseMethodDeclaration parses a method attached to an object type, the
+// receiver being bound to the object the method is called on:
+//
+//	func (Rect r) area() float { return r.width * r.height }
+func (p *Parser) parseMethodDeclaration() ([]*Stmt, *nomadError.ParseError) {
+	err := p.expectNF(tokenizer.TOKEN_KIND_FUNC, "func")
+	if err != nil {
+		return []*Stmt{}, err
+	}
+	expected := []struct {
+		kind        string
+		description string
+	}{
+		{tokenizer.TOKEN_KIND_LEFT_BRACKET, "opening bracket"},
+		{tokenizer.TOKEN_KIND_ID, "identifier (receiver type)"},
+		{tokenizer.TOKEN_KIND_ID, "identifier (receiver name)"},
+		{tokenizer.TOKEN_KIND_RIGHT_BRACKET, "closing bracket"},
+		{tokenizer.TOKEN_KIND_ID, "identifier (method name)"},
+	}
+	for i, e := range expected {
+		err = p.expectNextNF(e.kind, i+1, e.description)
+		if err != nil {
+			return []*Stmt{}, err
+		}
+	}
+	// a function literal returning a named type has the same prefix
+	next, _ := p.peekAt(len(expected) + 1)
+	if next.Kind != tokenizer.TOKEN_KIND_LEFT_BRACKET && next.Kind != tokenizer.TOKEN_KIND_LEFT_SQUARE_BRACKET {
+		return []*Stmt{}, nomadError.NewParseError("unexpected token. expected parameter list", next, false)
+	}
+	funcToken, _ := p.peek()
+	p.consume()
+	p.consume() // consume opening bracket
+	receiverType, _ := p.peek()
+	p.consume()
+	receiverName, _ := p.peek()
+	p.consume()
+	p.consume() // consume closing bracket
+	methodName, _ := p.peek()
+	p.consume()
+
+	value, err := p.parseFuncLiteral(funcToken)
+	if err != nil {
+		return []*Stmt{}, err.Fatal()
+	}
+	stmt := Stmt{
+		Data: []tokenizer.Token{receiverType, receiverName, methodName},
+		Kind: STMT_KIND_METHOD_DECLARATION,
+		Expr: value,
+	}
+
+	p.terminateStmt(stmt)
+
+	return []*Stmt{&stmt}, nil
+}
+
+// parseMatch parses a match statement. Each arm names a variant and the
+// variables its payload is bound to, a final else arm matches the other variants:
+//
+//	match shape {
+//	    Circle(radius) { ... }
+//	    else { ... }
+//	}
+func (p *Parser) parseMatch() ([]*Stmt, *nomadError.ParseError) {
+	err := p.expectNF(tokenizer.TOKEN_KIND_MATCH, "match (keyword)")
+	if err != nil {
+		return []*Stmt{}, err
+	}
+	matchToken, _ := p.peek()
+	p.consume()
+	subject, err := p.parseExpr()
+	if err != nil {
+		return []*Stmt{}, err
+	}
+	err = p.expectF(tokenizer.TOKEN_KIND_LEFT_CURCLY, "left curly ({)")
+	if err != nil {
+		return []*Stmt{}, err
+	}
+	p.consume()
+	p.cleanupNewLines()
+
+	arms := []*Stmt{}
+	for {
+		token, _ := p.peek()
+		if token.Kind == tokenizer.TOKEN_KIND_RIGHT_CURLY {
+			p.consume()
+			p.cleanupNewLines()
+			break
+		}
+		if token.Kind == tokenizer.TOKEN_KIND_ELSE {
+			p.consume()
+			block, err := p.parseArmBlock()
+			if err != nil {
+				return []*Stmt{}, err
+			}
+			arms = append(arms, &Stmt{
+				Kind:     STMT_KIND_ELSE,
+				Data:     []tokenizer.Token{token},
+				Children: block,
+			})
+			err = p.expectF(tokenizer.TOKEN_KIND_RIGHT_CURLY, "closing curly bracket (}), else should be the last arm")
+			if err != nil {
+				return []*Stmt{}, err
+			}
+			continue
+		}
+		err := p.expectF(tokenizer.TOKEN_KIND_ID, "identifier (variant name) or else")
+		if err != nil {
+			return []*Stmt{}, err
+		}
+		p.consume()
+		data := []tokenizer.Token{token}
+		next, _ := p.peek()
+		if next.Kind == tokenizer.TOKEN_KIND_LEFT_BRACKET {
+			p.consume()
+			for {
+				binding, _ := p.peek()
+				if binding.Kind == tokenizer.TOKEN_KIND_RIGHT_BRACKET {
+					p.consume()
+					break
+				}
+				err := p.expectF(tokenizer.TOKEN_KIND_ID, "identifier (variable name)")
+				if err != nil {
+					return []*Stmt{}, err
+				}
+				p.consume()
+				data = append(data, binding)
+				sep, _ := p.peek()
+				if sep.Kind == tokenizer.TOKEN_KIND_COMMA {
+					p.consume()
+				}
+			}
+		}
+		block, err := p.parseArmBlock()
+		if err != nil {
+			return []*Stmt{}, err
+		}
+		arms = append(arms, &Stmt{
+			Kind:     STMT_KIND_MATCH_ARM,
+			Data:     data,
+			Children: block,
+		})
+	}
+	return []*Stmt{{
+		Kind:     STMT_KIND_MATCH,
+		Data:     []tokenizer.Token{matchToken},
+		Expr:     subject,
+		Children: arms,
+	}}, nil
+}
+
+// parseTry parses a try block followed by its catch block, the variable
+// holding the caught error being optional: try { ... } catch e { ... }
+func (p *Parser) parseTry() ([]*Stmt, *nomadError.ParseError) {
+	err := p.expectNF(tokenizer.TOKEN_KIND_TRY, "try (keyword)")
+	if err != nil {
+		return []*Stmt{}, err
+	}
+	tryToken, _ := p.peek()
+	p.consume()
+	tryBlock, err := p.parseArmBlock()
+	if err != nil {
+		return []*Stmt{}, err
+	}
+	err = p.expectF(tokenizer.TOKEN_KIND_CATCH, "catch (keyword)")
+	if err != nil {
+		return []*Stmt{}, err
+	}
+	catchToken, _ := p.peek()
+	p.consume()
+	data := []tokenizer.Token{catchToken}
+	binding, _ := p.peek()
+	if binding.Kind == tokenizer.TOKEN_KIND_ID {
+		p.consume()
+		data = append(data, binding)
+	}
+	catchBlock, err := p.parseArmBlock()
+	if err != nil {
+		return []*Stmt{}, err
+	}
+	return []*Stmt{{
+		Kind: STMT_KIND_TRY,
+		Data: []tokenizer.Token{tryToken},
+		Children: []*Stmt{
+			{Kind: STMT_KIND_SCOPE, Data: []tokenizer.Token{tryToken}, Children: tryBlock},
+			{Kind: STMT_KIND_CATCH, Data: data, Children: catchBlock},
+		},
+	}}, nil
+}
+
+func (p *Parser) parseThrow() ([]*Stmt, *nomadError.ParseError) {
+	err := p.expectNF(tokenizer.TOKEN_KIND_THROW, "throw (keyword)")
+	if err != nil {
+		return []*Stmt{}, err
+	}
+	throwToken, _ := p.peek()
+	p.consume()
+	value, err := p.parseExpr()
+	if err != nil {
+		return []*Stmt{}, err
+	}
+	stmt := Stmt{
+		Kind: STMT_KIND_THROW,
+		Data: []tokenizer.Token{throwToken},
+		Expr: value,
+	}
+
+	p.terminateStmt(stmt)
+	return []*Stmt{&stmt}, nil
+}
+
+func (p *Parser) parseBreak() ([]*Stmt, *nomadError.ParseError) {
+	return p.parseLoopJump(tokenizer.TOKEN_KIND_BREAK, STMT_KIND_BREAK, "break (keyword)")
+}
+
+func (p *Parser) parseContinue() ([]*Stmt, *nomadError.ParseError) {
+	return p.parseLoopJump(tokenizer.TOKEN_KIND_CONTINUE, STMT_KIND_CONTINUE, "continue (keyword)")
+}
+
+// parseLoopJump parses the statements leaving the current iteration of a loop.
+func (p *Parser) parseLoopJump(tokenKind string, statementKind string, expected string) ([]*Stmt, *nomadError.ParseError) {
+	err := p.expectNF(tokenKind, expected)
+	if err != nil {
+		return []*Stmt{}, err
+	}
+	token, _ := p.peek()
+	p.consume()
+	stmt := Stmt{
+		Kind: statementKind,
+		Data: []tokenizer.Token{token},
+	}
+
+	p.terminateStmt(stmt)
+	return []*Stmt{&stmt}, nil
+}
+
+func (p *Parser) parseArmBlock() ([]*Stmt, *nomadError.ParseError) {
+	err := p.expectF(tokenizer.TOKEN_KIND_LEFT_CURCLY, "left curly ({)")
+	if err != nil {
+		return nil, err
+	}
+	return p.parseBlock()
+}
+
 func (p *Parser) parsePrint() ([]*Stmt, *nomadError.ParseError) {
 	err := p.expectNF(tokenizer.TOKEN_KIND_PRINT, "print (keyword)")
 	if err != nil {
@@ -394,6 +800,50 @@ func (p *Parser) parsePrint() ([]*Stmt, *nomadError.ParseError) {
 	return []*Stmt{&stmt}, nil
 }
 
+func (p *Parser) parseImport() ([]*Stmt, *nomadError.ParseError) {
+	err := p.expectNF(tokenizer.TOKEN_KIND_IMPORT, "import (keyword)")
+	if err != nil {
+		return []*Stmt{}, err
+	}
+	p.consume()
+	err = p.expectF(tokenizer.TOKEN_KIND_STRING_LIT, "module path (string)")
+	if err != nil {
+		return []*Stmt{}, err
+	}
+	path, _ := p.peek()
+	p.consume()
+	stmt := Stmt{
+		Kind: STMT_KIND_IMPORT,
+		Data: []tokenizer.Token{path},
+	}
+	// import "lib/util" as helpers
+	t, _ := p.peek()
+	if t.Kind == tokenizer.TOKEN_KIND_AS {
+		p.consume()
+		err = p.expectF(tokenizer.TOKEN_KIND_ID, "identifier (module alias)")
+		if err != nil {
+			return []*Stmt{}, err
+		}
+		alias, _ := p.peek()
+		p.consume()
+		stmt.Data = append(stmt.Data, alias)
+	}
+
+	p.terminateStmt(stmt)
+	return []*Stmt{&stmt}, nil
+}
+
+// ImportNamespace returns the path imported by an import statement and the
+// namespace it binds: its alias, or the base name of the path.
+func ImportNamespace(stmt *Stmt) (string, string) {
+	pathToken := stmt.Data[0]
+	path := pathToken.Content[1 : len(pathToken.Content)-1]
+	if len(stmt.Data) == 2 {
+		return path, stmt.Data[1].Content
+	}
+	return path, strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
 func (p *Parser) parseReturn() ([]*Stmt, *nomadError.ParseError) {
 	err := p.expectNF(tokenizer.TOKEN_KIND_RETURN, "return (keyword)")
 	if err != nil {
@@ -18,11 +18,24 @@ func (p *Parser) parseBasicTypeExpr(allowAuto bool) (Expr, *nomadError.ParseErro
 		})
 	}
 
-	if t.Kind == tokenizer.TOKEN_KIND_ID {
+	if t.Kind == tokenizer.TOKEN_KIND_QUESTION_MARK {
 		p.consume()
+		subtype, err := p.parseBasicTypeExpr(false)
+		if err != nil {
+			p.spit()
+			return Expr{}, err
+		}
+		return Expr{
+			Kind:     EXPR_KIND_TYPE_OPTIONAL,
+			Children: []Expr{subtype},
+			Token:    t,
+		}, nil
+	}
+
+	if t.Kind == tokenizer.TOKEN_KIND_ID {
 		return Expr{
 			Kind:  EXPR_KIND_TYPE,
-			Token: t,
+			Token: p.parseQualifiedName(),
 		}, nil
 	}
 	if t.Kind == tokenizer.TOKEN_KIND_AUTO {
@@ -41,7 +54,11 @@ func (p *Parser) parseBasicTypeExpr(allowAuto bool) (Expr, *nomadError.ParseErro
 	}
 
 	if t.Kind == tokenizer.TOKEN_KIND_LEFT_CURCLY {
-		expr, err := p.parseObjectTypeExpr()
+		expr, err := p.parseMapTypeExpr()
+		if err == nil || err.ShouldCrash() {
+			return expr, err
+		}
+		expr, err = p.parseObjectTypeExpr()
 		if err != nil {
 			return Expr{}, err
 		}
@@ -125,6 +142,123 @@ func (p *Parser) parseFuncTypeExpr() (Expr, *nomadError.ParseError) {
 	return funcExpr, nil
 }
 
+// parseMapTypeExpr parses a map type: {string: int}
+func (p *Parser) parseMapTypeExpr() (Expr, *nomadError.ParseError) {
+	pos := p.cursor
+	err := p.expectNF(tokenizer.TOKEN_KIND_LEFT_CURCLY, "opening curly bracket ({)")
+	if err != nil {
+		return Expr{}, err
+	}
+	t, _ := p.peek()
+	p.consume()
+	keyTypeExpr, err := p.parseTypeExpr(false)
+	if err != nil {
+		p.rollback(pos)
+		return Expr{}, nomadError.NonFatalParseError("could not parse map type", t)
+	}
+	err = p.expectNF(tokenizer.TOKEN_KIND_COLON, "colon (:)")
+	if err != nil {
+		p.rollback(pos)
+		return Expr{}, err
+	}
+	p.consume()
+	valueTypeExpr, err := p.parseTypeExpr(false)
+	if err != nil {
+		return Expr{}, err.Fatal()
+	}
+	err = p.expectF(tokenizer.TOKEN_KIND_RIGHT_CURLY, "closing curly bracket (})")
+	if err != nil {
+		return Expr{}, err
+	}
+	p.consume()
+	return Expr{
+		Kind:     EXPR_KIND_TYPE_MAP,
+		Children: []Expr{keyTypeExpr, valueTypeExpr},
+		Token:    t,
+	}, nil
+}
+
+// parseEnumTypeExpr parses the variants of an enum, separated by commas or new lines:
+// { Circle(float radius), Rect(float width, float height), Empty }
+func (p *Parser) parseEnumTypeExpr() (Expr, *nomadError.ParseError) {
+	err := p.expectF(tokenizer.TOKEN_KIND_LEFT_CURCLY, "opening curly bracket ({)")
+	if err != nil {
+		return Expr{}, err
+	}
+	t, _ := p.peek()
+	p.consume()
+	p.cleanupNewLines()
+
+	variants := []Expr{}
+	for {
+		token, _ := p.peek()
+		if token.Kind == tokenizer.TOKEN_KIND_RIGHT_CURLY {
+			p.consume()
+			break
+		}
+		err := p.expectF(tokenizer.TOKEN_KIND_ID, "identifier (variant name)")
+		if err != nil {
+			return Expr{}, err
+		}
+		p.consume()
+		variant := Expr{
+			Kind:  EXPR_KIND_TYPE_ENUM_CASE,
+			Token: token,
+		}
+		next, _ := p.peek()
+		if next.Kind == tokenizer.TOKEN_KIND_LEFT_BRACKET {
+			p.consume()
+			fields, err := p.parseEnumFields()
+			if err != nil {
+				return Expr{}, err
+			}
+			variant.Children = fields
+		}
+		variants = append(variants, variant)
+		sep, _ := p.peek()
+		if sep.Kind == tokenizer.TOKEN_KIND_COMMA {
+			p.consume()
+		}
+		p.cleanupNewLines()
+	}
+	return Expr{
+		Kind:     EXPR_KIND_TYPE_ENUM,
+		Children: variants,
+		Token:    t,
+	}, nil
+}
+
+func (p *Parser) parseEnumFields() ([]Expr, *nomadError.ParseError) {
+	fields := []Expr{}
+	for {
+		p.cleanupNewLines()
+		token, _ := p.peek()
+		if token.Kind == tokenizer.TOKEN_KIND_RIGHT_BRACKET {
+			p.consume()
+			return fields, nil
+		}
+		typeExpr, err := p.parseTypeExpr(false)
+		if err != nil {
+			return fields, nomadError.FatalParseError("variant fields should be declared as: type name", token)
+		}
+		err = p.expectF(tokenizer.TOKEN_KIND_ID, "identifier (field name)")
+		if err != nil {
+			return fields, err
+		}
+		name, _ := p.peek()
+		p.consume()
+		fields = append(fields, Expr{
+			Kind:     EXPR_KIND_TYPE_ENUM_FIELD,
+			Token:    name,
+			Children: []Expr{typeExpr},
+		})
+		sep, _ := p.peek()
+		if sep.Kind == tokenizer.TOKEN_KIND_COMMA {
+			p.consume()
+		}
+	}
+}
+
 func (p *Parser) parseObjectTypeExpr() (Expr, *nomadError.ParseError) {
 	err := p.expectNF(tokenizer.TOKEN_KIND_LEFT_CURCLY, "opening curly bracket ({)")
 	if err != nil {
@@ -188,3 +322,26 @@ func (p *Parser) parseTypeExprList(endTokenKind string) (Expr, *nomadError.Parse
 		}
 	}
 }
+
+// parseQualifiedName consumes an identifier, merging it with the following
+// one when they are separated by a dot (module.Type).
+func (p *Parser) parseQualifiedName() tokenizer.Token {
+	t, _ := p.peek()
+	p.consume()
+	dot, _ := p.peek()
+	name, ok := p.peekAt(1)
+	if dot.Kind != tokenizer.TOKEN_KIND_DOT || !ok || name.Kind != tokenizer.TOKEN_KIND_ID {
+		return t
+	}
+	p.consume()
+	p.consume()
+	return tokenizer.Token{
+		Kind:    tokenizer.TOKEN_KIND_ID,
+		Content: t.Content + "." + name.Content,
+		Loc: tokenizer.TokenLoc{
+			Line:  t.Loc.Line,
+			Start: t.Loc.Start,
+			End:   name.Loc.End,
+		},
+	}
+}
@@ -11,6 +11,8 @@ import (
 
 func Start() {
 	instance := vm.New()
+	instance.SetModuleLoader(interpreter.NewFileModuleLoader(instance))
+	instance.SetFsPermission(vm.FS_PERMISSION_READ_WRITE)
 	interpreter := interpreter.NewInterpreter()
 
 	for {
@@ -0,0 +1,65 @@
+package data
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RuntimeEnum is a value of an enum type: the variant it holds and its payload,
+// in the order of the variant fields.
+type RuntimeEnum struct {
+	Variant string
+	Values  []RuntimeValue
+}
+
+func NewRuntimeEnum(variant string, values []RuntimeValue) *RuntimeEnum {
+	return &RuntimeEnum{
+		Variant: variant,
+		Values:  values,
+	}
+}
+
+func (e *RuntimeEnum) String() string {
+	if len(e.Values) == 0 {
+		return e.Variant
+	}
+	values := make([]string, 0, len(e.Values))
+	for _, value := range e.Values {
+		values = append(values, fmt.Sprintf("%v", value.Value))
+	}
+	return e.Variant + "(" + strings.Join(values, ", ") + ")"
+}
+
+// Equal reports whether both values hold the same variant with equal payloads.
+func (e *RuntimeEnum) Equal(other *RuntimeEnum) bool {
+	if e.Variant != other.Variant || len(e.Values) != len(other.Values) {
+		return false
+	}
+	for i, value := range e.Values {
+		if !Equal(value, other.Values[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Equal compares two runtime values, enum values being compared by content
+// and an int being equal to the float of the same value.
+func Equal(lhs RuntimeValue, rhs RuntimeValue) bool {
+	lhsEnum, ok := lhs.Value.(*RuntimeEnum)
+	if ok {
+		rhsEnum, ok := rhs.Value.(*RuntimeEnum)
+		return ok && lhsEnum.Equal(rhsEnum)
+	}
+	if lhsInt, ok := lhs.Value.(int64); ok {
+		if rhsFloat, ok := rhs.Value.(float64); ok {
+			return float64(lhsInt) == rhsFloat
+		}
+	}
+	if lhsFloat, ok := lhs.Value.(float64); ok {
+		if rhsInt, ok := rhs.Value.(int64); ok {
+			return lhsFloat == float64(rhsInt)
+		}
+	}
+	return lhs.Value == rhs.Value
+}
@@ -16,13 +16,33 @@ type Parameter struct {
 type FuncSignature struct {
 	ReturnType types.RuntimeType
 	Parameters []Parameter
+	// TypeParams are the type variables of a generic function.
+	TypeParams []*types.TypeVar
 	names      map[string]int
 }
 
+// NativeFunc is a host function exposed to nomad scripts. Arguments are
+// received in the order of the signature parameters, defaults applied.
+type NativeFunc func(args []RuntimeValue) (RuntimeValue, error)
+
 type RuntimeFunc struct {
 	Begin     int
 	Tag       string
 	Signature FuncSignature
+	Native    NativeFunc
+	// Module is the path of the module declaring the function,
+	// empty for the entry program.
+	Module string
+	// Closure is the environment the function was defined in, its
+	// variables are resolved through it. It is owned by the vm.
+	Closure any
+	// TypeArgs are the types bound to the type parameters of an
+	// instantiated generic function, by name.
+	TypeArgs map[string]types.RuntimeType
+	// Receiver is the object a method is bound to, declared as
+	// ReceiverName when the method is called.
+	Receiver     *RuntimeValue
+	ReceiverName string
 }
 
 func (s *FuncSignature) AsType() *types.FuncType {
@@ -32,16 +52,17 @@ func (s *FuncSignature) AsType() *types.FuncType {
 		funcType.AddParam(param.RuntimeType)
 	}
 	funcType.SetRet(s.ReturnType)
+	funcType.SetTypeParams(s.TypeParams)
 	return funcType
 }
 
-func (f *RuntimeFunc) AddParam(name string, t types.RuntimeType, defaultValue RuntimeValue) error {
-	_, ok := f.Signature.names[name]
+func (s *FuncSignature) AddParam(name string, t types.RuntimeType, defaultValue RuntimeValue) error {
+	_, ok := s.names[name]
 	if ok {
 		return fmt.Errorf("cannot redeclare parameter [%s]", name)
 	}
-	f.Signature.names[name] = len(f.Signature.names)
-	f.Signature.Parameters = append(f.Signature.Parameters, Parameter{
+	s.names[name] = len(s.names)
+	s.Parameters = append(s.Parameters, Parameter{
 		HasDefault:   defaultValue != RuntimeValue{},
 		DefaultValue: defaultValue,
 		Name:         name,
@@ -50,18 +71,88 @@ func (f *RuntimeFunc) AddParam(name string, t types.RuntimeType, defaultValue Ru
 	return nil
 }
 
+func (f *RuntimeFunc) AddParam(name string, t types.RuntimeType, defaultValue RuntimeValue) error {
+	return f.Signature.AddParam(name, t, defaultValue)
+}
+
+func (f *RuntimeFunc) IsNative() bool {
+	return f.Native != nil
+}
+
+func (f *RuntimeFunc) IsGeneric() bool {
+	return len(f.Signature.TypeParams) > 0
+}
+
+// Bind returns a copy of the method f bound to receiver.
+func (f *RuntimeFunc) Bind(receiver RuntimeValue) *RuntimeFunc {
+	method := *f
+	method.Receiver = &receiver
+	return &method
+}
+
+// Instantiate returns a copy of the generic function f with its type
+// parameters replaced by the types bound to them.
+func (f *RuntimeFunc) Instantiate(bindings types.Bindings) (*RuntimeFunc, error) {
+	typeArgs := make(map[string]types.RuntimeType, len(f.TypeArgs)+len(f.Signature.TypeParams))
+	for name, t := range f.TypeArgs {
+		typeArgs[name] = t
+	}
+	for _, typeParam := range f.Signature.TypeParams {
+		t, ok := bindings[typeParam]
+		if !ok {
+			return nil, fmt.Errorf("cannot infer type parameter %s of %s", typeParam.GetName(), f.Tag)
+		}
+		typeArgs[typeParam.GetName()] = t
+	}
+	signature := NewFuncSignature(types.Substitute(f.Signature.ReturnType, bindings))
+	for i, param := range f.Signature.Parameters {
+		param.RuntimeType = types.Substitute(param.RuntimeType, bindings)
+		signature.Parameters = append(signature.Parameters, param)
+		signature.names[param.Name] = i
+	}
+	instance := *f
+	instance.Signature = signature
+	instance.TypeArgs = typeArgs
+	return &instance, nil
+}
+
+// InstantiateWith binds the type parameters of f to typeArgs, in order.
+func (f *RuntimeFunc) InstantiateWith(typeArgs []types.RuntimeType) (*RuntimeFunc, error) {
+	if len(typeArgs) != len(f.Signature.TypeParams) {
+		return nil, fmt.Errorf("%s expects %d type arguments, got %d", f.Tag, len(f.Signature.TypeParams), len(typeArgs))
+	}
+	bindings := types.Bindings{}
+	for i, typeParam := range f.Signature.TypeParams {
+		bindings[typeParam] = typeArgs[i]
+	}
+	return f.Instantiate(bindings)
+}
+
 func (f *RuntimeFunc) SetRet(t types.RuntimeType) {
 	f.Signature.ReturnType = t
 }
 
+func NewFuncSignature(returnType types.RuntimeType) FuncSignature {
+	return FuncSignature{
+		ReturnType: returnType,
+		Parameters: []Parameter{},
+		names:      make(map[string]int),
+	}
+}
+
 func NewRuntimeFunc(t *types.Registrar, beginPtr int) *RuntimeFunc {
 	return &RuntimeFunc{
-		Begin: beginPtr,
-		Tag:   "closure",
-		Signature: FuncSignature{
-			ReturnType: t.GetOrPanic("void"),
-			Parameters: []Parameter{},
-			names:      make(map[string]int),
-		},
+		Begin:     beginPtr,
+		Tag:       "closure",
+		Signature: NewFuncSignature(t.GetOrPanic(types.VOID_TYPE)),
+	}
+}
+
+func NewNativeFunc(tag string, signature FuncSignature, fn NativeFunc) *RuntimeFunc {
+	return &RuntimeFunc{
+		Begin:     -1,
+		Tag:       tag,
+		Signature: signature,
+		Native:    fn,
 	}
 }
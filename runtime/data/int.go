@@ -73,6 +73,9 @@ func DivInt(a *RuntimeValue, b *RuntimeValue) (*RuntimeValue, error) {
 	}
 	aInt := a.Value.(int64)
 	bInt := b.Value.(int64)
+	if bInt == 0 {
+		return nil, fmt.Errorf("integer division by zero")
+	}
 
 	return &RuntimeValue{
 		RuntimeType: a.RuntimeType,
@@ -81,6 +84,63 @@ func DivInt(a *RuntimeValue, b *RuntimeValue) (*RuntimeValue, error) {
 
 }
 
+func ModInt(a *RuntimeValue, b *RuntimeValue) (*RuntimeValue, error) {
+	err := types.ExpectedIntType(a.RuntimeType)
+	if err != nil {
+		return nil, err
+	}
+	err = types.ExpectedIntType(b.RuntimeType)
+	if err != nil {
+		return nil, err
+	}
+	aInt := a.Value.(int64)
+	bInt := b.Value.(int64)
+	if bInt == 0 {
+		return nil, fmt.Errorf("integer division by zero")
+	}
+
+	return &RuntimeValue{
+		RuntimeType: a.RuntimeType,
+		Value:       aInt % bInt,
+	}, nil
+}
+
+// BitwiseInt applies an operator working on the bits of both integers: & | ^ << >>
+func BitwiseInt(symbol string, a *RuntimeValue, b *RuntimeValue) (*RuntimeValue, error) {
+	err := types.ExpectedIntType(a.RuntimeType)
+	if err != nil {
+		return nil, err
+	}
+	err = types.ExpectedIntType(b.RuntimeType)
+	if err != nil {
+		return nil, err
+	}
+	aInt := a.Value.(int64)
+	bInt := b.Value.(int64)
+	if (symbol == "<<" || symbol == ">>") && bInt < 0 {
+		return nil, fmt.Errorf("negative shift count %d", bInt)
+	}
+	var result int64
+	switch symbol {
+	case "&":
+		result = aInt & bInt
+	case "|":
+		result = aInt | bInt
+	case "^":
+		result = aInt ^ bInt
+	case "<<":
+		result = aInt << bInt
+	case ">>":
+		result = aInt >> bInt
+	default:
+		return nil, fmt.Errorf("unsupported operand %s for type %s", symbol, a.RuntimeType.GetName())
+	}
+	return &RuntimeValue{
+		RuntimeType: a.RuntimeType,
+		Value:       result,
+	}, nil
+}
+
 func CmpInt(a *RuntimeValue, b *RuntimeValue) (*RuntimeValue, error) {
 	err := types.ExpectedIntType(a.RuntimeType)
 	if err != nil {
@@ -117,6 +177,10 @@ func ApplyBinaryOpToInt(symbol string, lhs *RuntimeValue, rhs *RuntimeValue) (*R
 		return MultInt(lhs, rhs)
 	case "/":
 		return DivInt(lhs, rhs)
+	case "%":
+		return ModInt(lhs, rhs)
+	case "&", "|", "^", "<<", ">>":
+		return BitwiseInt(symbol, lhs, rhs)
 	case "<->":
 		return CmpInt(lhs, rhs)
 	default:
@@ -0,0 +1,76 @@
+package data
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type mapEntry struct {
+	key   RuntimeValue
+	value RuntimeValue
+}
+
+// RuntimeMap is shared by reference, like objects.
+type RuntimeMap struct {
+	entries map[interface{}]mapEntry
+}
+
+func NewRuntimeMap() *RuntimeMap {
+	return &RuntimeMap{
+		entries: make(map[interface{}]mapEntry),
+	}
+}
+
+func (m *RuntimeMap) Get(key RuntimeValue) (RuntimeValue, bool) {
+	entry, ok := m.entries[key.Value]
+	return entry.value, ok
+}
+
+func (m *RuntimeMap) Set(key RuntimeValue, value RuntimeValue) {
+	m.entries[key.Value] = mapEntry{key: key, value: value}
+}
+
+func (m *RuntimeMap) Has(key RuntimeValue) bool {
+	_, ok := m.entries[key.Value]
+	return ok
+}
+
+func (m *RuntimeMap) Len() int {
+	return len(m.entries)
+}
+
+// Keys returns the keys of the map in ascending order.
+func (m *RuntimeMap) Keys() []RuntimeValue {
+	keys := make([]RuntimeValue, 0, len(m.entries))
+	for _, entry := range m.entries {
+		keys = append(keys, entry.key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return lessKey(keys[i].Value, keys[j].Value)
+	})
+	return keys
+}
+
+func (m *RuntimeMap) String() string {
+	entries := []string{}
+	for _, key := range m.Keys() {
+		value, _ := m.Get(key)
+		entries = append(entries, fmt.Sprintf("%v: %v", key.Value, value.Value))
+	}
+	return "{" + strings.Join(entries, ", ") + "}"
+}
+
+func lessKey(a interface{}, b interface{}) bool {
+	switch a := a.(type) {
+	case int64:
+		return a < b.(int64)
+	case float64:
+		return a < b.(float64)
+	case string:
+		return a < b.(string)
+	case bool:
+		return !a && b.(bool)
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package data
+
+import (
+	"github.com/dani-gouken/nomad/runtime/types"
+)
+
+// Number returns a number with its concrete type, values held by a
+// variable of type num being typed after their content.
+func Number(value RuntimeValue) RuntimeValue {
+	if value.RuntimeType == nil {
+		return value
+	}
+	scalarType, err := types.ToScalarType(value.RuntimeType)
+	if err != nil || !scalarType.IsNum() {
+		return value
+	}
+	switch v := value.Value.(type) {
+	case int64:
+		return RuntimeValue{RuntimeType: types.MakeIntType(), Value: v}
+	case float64:
+		return RuntimeValue{RuntimeType: types.MakeFloatType(), Value: v}
+	}
+	return value
+}
+
+// Promote converts an int to a float when the target type, or the subtype
+// of an optional target, is float. Other values are returned unchanged.
+func Promote(value RuntimeValue, target types.RuntimeType) RuntimeValue {
+	if target == nil || value.RuntimeType == nil {
+		return value
+	}
+	optionalType, err := types.ToOptionalType(target)
+	if err == nil {
+		target = optionalType.GetSubtype()
+	}
+	if types.ExpectedFloatType(target) != nil {
+		return value
+	}
+	number := Number(value)
+	if types.ExpectedIntType(number.RuntimeType) != nil {
+		return value
+	}
+	return RuntimeValue{
+		RuntimeType: target,
+		Value:       float64(number.Value.(int64)),
+	}
+}
+
+// promoteOperands gives both operands of a binary operator the same numeric
+// type, the int operand being promoted when the other one is a float.
+func promoteOperands(t types.Registrar, lhs *RuntimeValue, rhs *RuntimeValue) (*RuntimeValue, *RuntimeValue) {
+	lhsNumber, rhsNumber := Number(*lhs), Number(*rhs)
+	if !types.IsNumericType(lhsNumber.RuntimeType) || !types.IsNumericType(rhsNumber.RuntimeType) {
+		return lhs, rhs
+	}
+	floatType := t.GetOrPanic(types.FLOAT_TYPE)
+	if types.ExpectedFloatType(lhsNumber.RuntimeType) == nil {
+		rhsNumber = Promote(rhsNumber, floatType)
+	}
+	if types.ExpectedFloatType(rhsNumber.RuntimeType) == nil {
+		lhsNumber = Promote(lhsNumber, floatType)
+	}
+	return &lhsNumber, &rhsNumber
+}
@@ -2,6 +2,8 @@ package data
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/dani-gouken/nomad/runtime/types"
 )
@@ -33,3 +35,41 @@ func ApplyBinaryOpToString(symbol string, lhs *RuntimeValue, rhs *RuntimeValue)
 		return nil, fmt.Errorf("unsupported operand %s for type %s", symbol, lhs.RuntimeType.GetName())
 	}
 }
+
+// ToString converts a value to its string representation, as used by
+// interpolated strings. Nested strings are not quoted.
+func ToString(value RuntimeValue) string {
+	switch v := value.Value.(type) {
+	case nil:
+		return "none"
+	case string:
+		return v
+	case RuntimeArray:
+		items := make([]string, 0, len(v.Values))
+		for _, item := range v.Values {
+			items = append(items, ToString(item))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case *RuntimeMap:
+		entries := []string{}
+		for _, key := range v.Keys() {
+			item, _ := v.Get(key)
+			entries = append(entries, ToString(key)+": "+ToString(item))
+		}
+		return "{" + strings.Join(entries, ", ") + "}"
+	case *RuntimeObject:
+		names := make([]string, 0, len(v.fields))
+		for name := range v.fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fields := make([]string, 0, len(names))
+		for _, name := range names {
+			fields = append(fields, name+": "+ToString(*v.fields[name]))
+		}
+		return value.RuntimeType.GetName() + "{" + strings.Join(fields, ", ") + "}"
+	case *RuntimeFunc:
+		return value.RuntimeType.GetName()
+	}
+	return fmt.Sprintf("%v", value.Value)
+}
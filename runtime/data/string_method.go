@@ -0,0 +1,191 @@
+package data
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dani-gouken/nomad/runtime/types"
+)
+
+// MAX_REPEAT_LENGTH is the length in bytes of the longest string repeat
+// can build, larger strings would exhaust the memory of the host.
+const MAX_REPEAT_LENGTH = 1 << 28
+
+type stringMethod struct {
+	params    []Parameter
+	ret       types.RuntimeType
+	fn        func(s string, args []RuntimeValue) (interface{}, error)
+	signature FuncSignature
+}
+
+// StringMethods holds the methods of the string scalar of a registrar.
+type StringMethods map[string]stringMethod
+
+// NewStringMethods builds the methods of the string scalar of t.
+func NewStringMethods(t types.Registrar) StringMethods {
+	methods := stringMethods(t)
+	for name, method := range methods {
+		method.signature = NewFuncSignature(method.ret)
+		for _, p := range method.params {
+			method.signature.AddParam(p.Name, p.RuntimeType, RuntimeValue{})
+		}
+		methods[name] = method
+	}
+	return methods
+}
+
+// stringMethods lists the methods of the string scalar. Positions are
+// counted in characters (runes), not in bytes.
+func stringMethods(t types.Registrar) StringMethods {
+	stringType := t.GetOrPanic(types.STRING_TYPE)
+	intType := t.GetOrPanic(types.INT_TYPE)
+	boolType := t.GetOrPanic(types.BOOL_TYPE)
+	arrayType := types.NewArrayType(stringType)
+	param := func(name string, t types.RuntimeType) Parameter {
+		return Parameter{Name: name, RuntimeType: t}
+	}
+	return StringMethods{
+		"split": {
+			params: []Parameter{param("separator", stringType)},
+			ret:    arrayType,
+			fn: func(s string, args []RuntimeValue) (interface{}, error) {
+				parts := strings.Split(s, args[0].Value.(string))
+				values := make([]RuntimeValue, 0, len(parts))
+				for _, part := range parts {
+					values = append(values, RuntimeValue{RuntimeType: stringType, Value: part})
+				}
+				return RuntimeArray{Values: values}, nil
+			},
+		},
+		"join": {
+			params: []Parameter{param("parts", arrayType)},
+			ret:    stringType,
+			fn: func(s string, args []RuntimeValue) (interface{}, error) {
+				values := args[0].Value.(RuntimeArray).Values
+				parts := make([]string, 0, len(values))
+				for _, value := range values {
+					parts = append(parts, value.Value.(string))
+				}
+				return strings.Join(parts, s), nil
+			},
+		},
+		"trim": {
+			ret: stringType,
+			fn: func(s string, args []RuntimeValue) (interface{}, error) {
+				return strings.TrimSpace(s), nil
+			},
+		},
+		"trim_start": {
+			ret: stringType,
+			fn: func(s string, args []RuntimeValue) (interface{}, error) {
+				return strings.TrimLeftFunc(s, unicode.IsSpace), nil
+			},
+		},
+		"trim_end": {
+			ret: stringType,
+			fn: func(s string, args []RuntimeValue) (interface{}, error) {
+				return strings.TrimRightFunc(s, unicode.IsSpace), nil
+			},
+		},
+		"contains": {
+			params: []Parameter{param("value", stringType)},
+			ret:    boolType,
+			fn: func(s string, args []RuntimeValue) (interface{}, error) {
+				return strings.Contains(s, args[0].Value.(string)), nil
+			},
+		},
+		"starts_with": {
+			params: []Parameter{param("prefix", stringType)},
+			ret:    boolType,
+			fn: func(s string, args []RuntimeValue) (interface{}, error) {
+				return strings.HasPrefix(s, args[0].Value.(string)), nil
+			},
+		},
+		"ends_with": {
+			params: []Parameter{param("suffix", stringType)},
+			ret:    boolType,
+			fn: func(s string, args []RuntimeValue) (interface{}, error) {
+				return strings.HasSuffix(s, args[0].Value.(string)), nil
+			},
+		},
+		"replace": {
+			params: []Parameter{param("old", stringType), param("new", stringType)},
+			ret:    stringType,
+			fn: func(s string, args []RuntimeValue) (interface{}, error) {
+				return strings.ReplaceAll(s, args[0].Value.(string), args[1].Value.(string)), nil
+			},
+		},
+		"index_of": {
+			params: []Parameter{param("value", stringType)},
+			ret:    intType,
+			fn: func(s string, args []RuntimeValue) (interface{}, error) {
+				i := strings.Index(s, args[0].Value.(string))
+				if i < 0 {
+					return int64(-1), nil
+				}
+				return int64(utf8.RuneCountInString(s[:i])), nil
+			},
+		},
+		"upper": {
+			ret: stringType,
+			fn: func(s string, args []RuntimeValue) (interface{}, error) {
+				return strings.ToUpper(s), nil
+			},
+		},
+		"lower": {
+			ret: stringType,
+			fn: func(s string, args []RuntimeValue) (interface{}, error) {
+				return strings.ToLower(s), nil
+			},
+		},
+		"repeat": {
+			params: []Parameter{param("count", intType)},
+			ret:    stringType,
+			fn: func(s string, args []RuntimeValue) (interface{}, error) {
+				count := args[0].Value.(int64)
+				if count < 0 {
+					return nil, fmt.Errorf("negative repeat count %d", count)
+				}
+				if len(s) > 0 && count > MAX_REPEAT_LENGTH/int64(len(s)) {
+					return nil, fmt.Errorf("repeat count %d exceeds the maximum string length of %d bytes", count, MAX_REPEAT_LENGTH)
+				}
+				return strings.Repeat(s, int(count)), nil
+			},
+		},
+		"substring": {
+			params: []Parameter{param("start", intType), param("end", intType)},
+			ret:    stringType,
+			fn: func(s string, args []RuntimeValue) (interface{}, error) {
+				return Substring(s, args[0].Value.(int64), args[1].Value.(int64))
+			},
+		},
+	}
+}
+
+// Substring returns the characters of s from start included to end excluded.
+func Substring(s string, start int64, end int64) (string, error) {
+	runes := []rune(s)
+	if start < 0 || end > int64(len(runes)) || start > end {
+		return "", fmt.Errorf("invalid range [%d:%d] for string of length %d", start, end, len(runes))
+	}
+	return string(runes[start:end]), nil
+}
+
+// Bind returns the method name of the string receiver, as a native
+// function bound to it.
+func (m StringMethods) Bind(receiver RuntimeValue, name string) (*RuntimeFunc, error) {
+	method, ok := m[name]
+	if !ok {
+		return nil, fmt.Errorf("type string has no method [%s]", name)
+	}
+	s, _ := receiver.Value.(string)
+	return NewNativeFunc("string."+name, method.signature, func(args []RuntimeValue) (RuntimeValue, error) {
+		value, err := method.fn(s, args)
+		if err != nil {
+			return RuntimeValue{}, err
+		}
+		return RuntimeValue{RuntimeType: method.ret, Value: value}, nil
+	}), nil
+}
@@ -36,7 +36,43 @@ func (o *RuntimeObject) SetField(name string, value RuntimeValue) error {
 	return nil
 }
 
+// Copy returns a deep copy of value, so the arrays, maps and objects it
+// holds are not shared with value.
+func Copy(value RuntimeValue) RuntimeValue {
+	switch v := value.Value.(type) {
+	case RuntimeArray:
+		value.Value = RuntimeArray{Values: copyValues(v.Values)}
+	case *RuntimeMap:
+		m := NewRuntimeMap()
+		for key, entry := range v.entries {
+			m.entries[key] = mapEntry{key: entry.key, value: Copy(entry.value)}
+		}
+		value.Value = m
+	case *RuntimeObject:
+		object := NewRuntimeObject()
+		for name, field := range v.fields {
+			object.SetField(name, Copy(*field))
+		}
+		value.Value = object
+	case *RuntimeEnum:
+		value.Value = NewRuntimeEnum(v.Variant, copyValues(v.Values))
+	}
+	return value
+}
+
+func copyValues(values []RuntimeValue) []RuntimeValue {
+	if values == nil {
+		return nil
+	}
+	copies := make([]RuntimeValue, len(values))
+	for i, value := range values {
+		copies[i] = Copy(value)
+	}
+	return copies
+}
+
 func ApplyBinaryOp(t types.Registrar, symbol string, lhs *RuntimeValue, rhs *RuntimeValue) (*RuntimeValue, error) {
+	lhs, rhs = promoteOperands(t, lhs, rhs)
 	err := lhs.RuntimeType.Match(rhs.RuntimeType)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,108 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+)
+
+type EnumField struct {
+	Name string
+	Type RuntimeType
+}
+
+// EnumVariant is one of the cases of an enum, with the types of its payload.
+type EnumVariant struct {
+	Name   string
+	Fields []EnumField
+}
+
+type EnumType struct {
+	name      string
+	anonymous bool
+	variants  []*EnumVariant
+}
+
+var enumId int = 0
+
+func (e *EnumType) GetName() string {
+	return e.name
+}
+
+func (e *EnumType) SetName(name string) {
+	e.anonymous = false
+	e.name = name
+}
+
+func (e *EnumType) IsAnonymous() bool {
+	return e.anonymous
+}
+
+func (e *EnumType) GetVariants() []*EnumVariant {
+	return e.variants
+}
+
+func (e *EnumType) GetVariant(name string) (*EnumVariant, error) {
+	for _, variant := range e.variants {
+		if variant.Name == name {
+			return variant, nil
+		}
+	}
+	return nil, fmt.Errorf("enum %s has no variant [%s]", e.name, name)
+}
+
+func (e *EnumType) AddVariant(name string) error {
+	_, err := e.GetVariant(name)
+	if err == nil {
+		return fmt.Errorf("cannot redeclare variant %s", name)
+	}
+	e.variants = append(e.variants, &EnumVariant{Name: name})
+	return nil
+}
+
+// AddField adds a field to the payload of the last declared variant.
+func (e *EnumType) AddField(name string, fieldType RuntimeType) error {
+	if len(e.variants) == 0 {
+		return fmt.Errorf("cannot declare field %s outside of a variant", name)
+	}
+	variant := e.variants[len(e.variants)-1]
+	for _, field := range variant.Fields {
+		if field.Name == name {
+			return fmt.Errorf("cannot redeclare field %s of variant %s", name, variant.Name)
+		}
+	}
+	variant.Fields = append(variant.Fields, EnumField{Name: name, Type: fieldType})
+	return nil
+}
+
+func (e *EnumType) Match(t2 RuntimeType) error {
+	t2Enum, err := ToEnumType(t2)
+	if err != nil {
+		return fmt.Errorf("expected type %s, got %s", e.GetName(), t2.GetName())
+	}
+	if t2Enum.name != e.name {
+		return fmt.Errorf("expected type %s, got %s", e.GetName(), t2.GetName())
+	}
+	return nil
+}
+
+func NewEnumType() *EnumType {
+	id := enumId
+	enumId++
+	return &EnumType{
+		name:      "AnonymousEnum" + strconv.Itoa(id),
+		anonymous: true,
+	}
+}
+
+func IsEnumType(t RuntimeType) bool {
+	_, err := ToEnumType(t)
+	return err == nil
+}
+
+func ToEnumType(t RuntimeType) (*EnumType, error) {
+	tEnum, ok := t.(*EnumType)
+	if !ok {
+		return nil, fmt.Errorf("enum type expected")
+	}
+	return tEnum, nil
+}
@@ -0,0 +1,12 @@
+package types
+
+const ERROR_TYPE = "Error"
+
+// MakeErrorType returns the type of the errors thrown by scripts and of the
+// runtime errors caught by them.
+func MakeErrorType(defaultMessage interface{}) *ObjectType {
+	t := NewObjectType()
+	t.AddField("message", MakeStringType(), defaultMessage)
+	t.SetName(ERROR_TYPE)
+	return t
+}
@@ -7,12 +7,23 @@ import (
 type FuncType struct {
 	anonymous  bool
 	generic    bool
+	typeParams []*TypeVar
 	returnType RuntimeType
 	parameters []RuntimeType
 }
 
 func (f *FuncType) GetName() string {
 	name := "func"
+	if f.generic {
+		name = name + "["
+		for i, typeParam := range f.typeParams {
+			if i > 0 {
+				name = name + ","
+			}
+			name = name + typeParam.GetName()
+		}
+		name = name + "]"
+	}
 	if len(f.parameters) > 0 {
 		name = name + "("
 	}
@@ -44,11 +55,59 @@ func (o *FuncType) IsGeneric() bool {
 	return o.generic
 }
 
+func (f *FuncType) GetTypeParams() []*TypeVar {
+	return f.typeParams
+}
+
+// SetTypeParams makes the function generic over the given type variables.
+func (f *FuncType) SetTypeParams(typeParams []*TypeVar) {
+	f.typeParams = typeParams
+	f.generic = len(typeParams) > 0
+}
+
+// HasTypeVar reports whether the parameters or the return type of the
+// function refer to a type variable.
+func (f *FuncType) HasTypeVar() bool {
+	for _, param := range f.parameters {
+		if HasTypeVar(param) {
+			return true
+		}
+	}
+	return f.returnType != nil && HasTypeVar(f.returnType)
+}
+
+// Match accepts a generic function when it can be instantiated to t, its
+// type variables being bound by unification.
 func (t *FuncType) Match(t2 RuntimeType) error {
 	t2Func, err := ToFuncType(t2)
 	if err != nil {
 		return err
 	}
+	if t2Func.generic {
+		bindings := Bindings{}
+		if t.generic {
+			if len(t.typeParams) != len(t2Func.typeParams) {
+				return fmt.Errorf("expected type %s, got %s", t.GetName(), t2.GetName())
+			}
+			for i, typeParam := range t2Func.typeParams {
+				bindings[typeParam] = t.typeParams[i]
+			}
+		}
+		for i := range t2Func.parameters {
+			if i < len(t.parameters) {
+				Unify(t2Func.parameters[i], t.parameters[i], bindings)
+			}
+		}
+		if t.returnType != nil && t2Func.returnType != nil {
+			Unify(t2Func.returnType, t.returnType, bindings)
+		}
+		t2Func = Substitute(t2Func, bindings).(*FuncType)
+		if t2Func.generic && !t.generic {
+			return fmt.Errorf("expected type %s, got %s", t.GetName(), t2.GetName())
+		}
+	} else if t.generic {
+		return fmt.Errorf("expected type %s, got %s", t.GetName(), t2.GetName())
+	}
 	err = t.returnType.Match(t2Func.returnType)
 	if err != nil {
 		return fmt.Errorf("return type mismatch, %s", err.Error())
@@ -65,6 +124,15 @@ func (t *FuncType) Match(t2 RuntimeType) error {
 	return nil
 }
 
+func (f *FuncType) GetParams() []RuntimeType {
+	return f.parameters
+}
+
+// GetRet returns the return type of the function, nil when unspecified.
+func (f *FuncType) GetRet() RuntimeType {
+	return f.returnType
+}
+
 func (f *FuncType) AddParam(t RuntimeType) {
 	f.parameters = append(f.parameters, t)
 }
@@ -76,7 +144,6 @@ func (f *FuncType) SetRet(t RuntimeType) {
 func NewFuncType() *FuncType {
 	return &FuncType{
 		anonymous:  true,
-		generic:    true,
 		parameters: []RuntimeType{},
 	}
 }
@@ -0,0 +1,162 @@
+package types
+
+import (
+	"fmt"
+)
+
+// TypeVar is a type parameter of a generic function, like T in
+// func[T](T a, T b) T. It is replaced by a concrete type when the
+// function is instantiated.
+type TypeVar struct {
+	name string
+}
+
+func (t *TypeVar) GetName() string {
+	return t.name
+}
+
+// Match only accepts the type variable itself, the other types being
+// bound to it through Unify.
+func (t *TypeVar) Match(t2 RuntimeType) error {
+	if t != t2 {
+		return fmt.Errorf("expected type %s, got %s", t.GetName(), t2.GetName())
+	}
+	return nil
+}
+
+func NewTypeVar(name string) *TypeVar {
+	return &TypeVar{name: name}
+}
+
+func IsTypeVar(t RuntimeType) bool {
+	_, ok := t.(*TypeVar)
+	return ok
+}
+
+// Bindings maps the type variables of a generic function to concrete types.
+type Bindings map[*TypeVar]RuntimeType
+
+// Unify binds the type variables of pattern so it matches actual. An int
+// and a float bound to the same variable make it a float, the int being
+// promoted like in arithmetic.
+func Unify(pattern RuntimeType, actual RuntimeType, bindings Bindings) error {
+	mismatch := fmt.Errorf("expected type %s, got %s", Substitute(pattern, bindings).GetName(), actual.GetName())
+	switch pattern := pattern.(type) {
+	case *TypeVar:
+		bound, ok := bindings[pattern]
+		if !ok {
+			bindings[pattern] = actual
+			return nil
+		}
+		if ExpectedIntType(bound) == nil && ExpectedFloatType(actual) == nil {
+			bindings[pattern] = actual
+			return nil
+		}
+		if ExpectedFloatType(bound) == nil && ExpectedIntType(actual) == nil {
+			return nil
+		}
+		if bound.Match(actual) != nil {
+			return mismatch
+		}
+		return nil
+	case *ArrayType:
+		actualArray, err := ToArrayType(actual)
+		if err != nil {
+			return mismatch
+		}
+		return Unify(pattern.GetSubtype(), actualArray.GetSubtype(), bindings)
+	case *MapType:
+		actualMap, err := ToMapType(actual)
+		if err != nil {
+			return mismatch
+		}
+		err = Unify(pattern.GetKeyType(), actualMap.GetKeyType(), bindings)
+		if err != nil {
+			return err
+		}
+		return Unify(pattern.GetValueType(), actualMap.GetValueType(), bindings)
+	case *OptionalType:
+		if IsNoneType(actual) {
+			return nil
+		}
+		actualOptional, err := ToOptionalType(actual)
+		if err == nil {
+			actual = actualOptional.GetSubtype()
+		}
+		return Unify(pattern.GetSubtype(), actual, bindings)
+	case *FuncType:
+		if !pattern.HasTypeVar() {
+			return pattern.Match(actual)
+		}
+		actualFunc, err := ToFuncType(actual)
+		if err != nil || len(actualFunc.parameters) != len(pattern.parameters) {
+			return mismatch
+		}
+		for i := range pattern.parameters {
+			err := Unify(pattern.parameters[i], actualFunc.parameters[i], bindings)
+			if err != nil {
+				return err
+			}
+		}
+		return Unify(pattern.returnType, actualFunc.returnType, bindings)
+	}
+	return pattern.Match(actual)
+}
+
+// Substitute replaces the bound type variables of t.
+func Substitute(t RuntimeType, bindings Bindings) RuntimeType {
+	switch t := t.(type) {
+	case *TypeVar:
+		bound, ok := bindings[t]
+		if !ok {
+			return t
+		}
+		return bound
+	case *ArrayType:
+		return NewArrayType(Substitute(t.GetSubtype(), bindings))
+	case *MapType:
+		return &MapType{
+			key:   Substitute(t.GetKeyType(), bindings),
+			value: Substitute(t.GetValueType(), bindings),
+		}
+	case *OptionalType:
+		return &OptionalType{subtype: Substitute(t.GetSubtype(), bindings)}
+	case *FuncType:
+		if !t.HasTypeVar() {
+			return t
+		}
+		funcType := NewFuncType()
+		for _, param := range t.parameters {
+			funcType.AddParam(Substitute(param, bindings))
+		}
+		typeParams := []*TypeVar{}
+		for _, typeParam := range t.typeParams {
+			if _, ok := bindings[typeParam]; !ok {
+				typeParams = append(typeParams, typeParam)
+			}
+		}
+		funcType.SetTypeParams(typeParams)
+		if t.returnType != nil {
+			funcType.SetRet(Substitute(t.returnType, bindings))
+		}
+		return funcType
+	}
+	return t
+}
+
+// HasTypeVar reports whether t refers to a type variable.
+func HasTypeVar(t RuntimeType) bool {
+	switch t := t.(type) {
+	case *TypeVar:
+		return true
+	case *ArrayType:
+		return HasTypeVar(t.GetSubtype())
+	case *MapType:
+		return HasTypeVar(t.GetKeyType()) || HasTypeVar(t.GetValueType())
+	case *OptionalType:
+		return HasTypeVar(t.GetSubtype())
+	case *FuncType:
+		return t.HasTypeVar()
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package types
+
+import "fmt"
+
+type MapType struct {
+	key   RuntimeType
+	value RuntimeType
+}
+
+func (t *MapType) GetName() string {
+	return fmt.Sprintf("{%s: %s}", t.key.GetName(), t.value.GetName())
+}
+
+func (t *MapType) GetKeyType() RuntimeType {
+	return t.key
+}
+
+func (t *MapType) GetValueType() RuntimeType {
+	return t.value
+}
+
+func (t *MapType) Match(t2 RuntimeType) error {
+	t2Map, err := ToMapType(t2)
+	if err != nil {
+		return err
+	}
+	if t.key.Match(t2Map.key) != nil || t.value.Match(t2Map.value) != nil {
+		return fmt.Errorf("expected type %s, got %s", t.GetName(), t2.GetName())
+	}
+	return nil
+}
+
+func NewMapType(key RuntimeType, value RuntimeType) (*MapType, error) {
+	// a type variable key is checked once the function using it is instantiated
+	if !IsHashable(key) && !IsTypeVar(key) {
+		return nil, fmt.Errorf("invalid map key type %s, only int, float, string and bool can be used as keys", key.GetName())
+	}
+	return &MapType{
+		key:   key,
+		value: value,
+	}, nil
+}
+
+// IsHashable reports whether values of type t can be used as map keys.
+func IsHashable(t RuntimeType) bool {
+	scalar, err := ToScalarType(t)
+	if err != nil {
+		return false
+	}
+	return scalar.IsInt() || scalar.IsFloat() || scalar.IsString() || scalar.IsBoolean()
+}
+
+func IsMapType(t RuntimeType) bool {
+	_, err := ToMapType(t)
+	return err == nil
+}
+
+func ToMapType(t RuntimeType) (*MapType, error) {
+	tMap, ok := t.(*MapType)
+	if !ok {
+		return nil, fmt.Errorf("map type expected")
+	}
+	return tMap, nil
+}
@@ -10,6 +10,9 @@ type ObjectType struct {
 	anonymous bool
 	fields    map[string]RuntimeType
 	defaults  map[string]interface{}
+	// methods are attached by the vm, the checker keeping the signatures
+	// of the methods declared by the program being checked.
+	methods map[string]interface{}
 }
 
 var objId int = 0
@@ -68,6 +71,24 @@ func (t *ObjectType) AddField(name string, fieldType RuntimeType, defaultValue i
 	return nil
 }
 
+// AddMethod attaches a method to the type, a method cannot share its name
+// with a field.
+func (t *ObjectType) AddMethod(name string, method interface{}) error {
+	if _, ok := t.fields[name]; ok {
+		return fmt.Errorf("cannot declare method %s, type %s has a field named %s", name, t.GetName(), name)
+	}
+	if _, ok := t.methods[name]; ok {
+		return fmt.Errorf("cannot redeclare method %s of type %s", name, t.GetName())
+	}
+	t.methods[name] = method
+	return nil
+}
+
+func (t *ObjectType) GetMethod(name string) (interface{}, bool) {
+	method, ok := t.methods[name]
+	return method, ok
+}
+
 func NewObjectType() *ObjectType {
 	id := objId
 	objId++
@@ -76,6 +97,7 @@ func NewObjectType() *ObjectType {
 		anonymous: true,
 		fields:    make(map[string]RuntimeType),
 		defaults:  make(map[string]interface{}),
+		methods:   make(map[string]interface{}),
 	}
 }
 
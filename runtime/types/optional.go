@@ -0,0 +1,80 @@
+package types
+
+import (
+	"fmt"
+)
+
+// OptionalType (?T) holds either a value of its subtype or none.
+type OptionalType struct {
+	subtype RuntimeType
+}
+
+func (t *OptionalType) GetName() string {
+	return "?" + t.subtype.GetName()
+}
+
+func (t *OptionalType) GetSubtype() RuntimeType {
+	return t.subtype
+}
+
+// Match accepts none, optionals of a matching subtype and plain values of the subtype.
+func (t *OptionalType) Match(t2 RuntimeType) error {
+	if IsNoneType(t2) {
+		return nil
+	}
+	t2Optional, err := ToOptionalType(t2)
+	if err == nil {
+		t2 = t2Optional.GetSubtype()
+	}
+	err = t.subtype.Match(t2)
+	if err != nil {
+		return fmt.Errorf("expected type %s, got %s", t.GetName(), t2.GetName())
+	}
+	return nil
+}
+
+func NewOptionalType(subtype RuntimeType) (*OptionalType, error) {
+	if IsOptionalType(subtype) || IsNoneType(subtype) || IsVoidType(subtype) {
+		return nil, fmt.Errorf("invalid optional type ?%s", subtype.GetName())
+	}
+	return &OptionalType{
+		subtype: subtype,
+	}, nil
+}
+
+func IsOptionalType(t RuntimeType) bool {
+	_, err := ToOptionalType(t)
+	return err == nil
+}
+
+func ToOptionalType(t RuntimeType) (*OptionalType, error) {
+	tOptional, ok := t.(*OptionalType)
+	if !ok {
+		return nil, fmt.Errorf("optional type expected")
+	}
+	return tOptional, nil
+}
+
+// NoneType is the type of the none literal, which can only be assigned to optionals.
+type NoneType struct {
+}
+
+func (t *NoneType) GetName() string {
+	return NONE_TYPE
+}
+
+func (t *NoneType) Match(t2 RuntimeType) error {
+	if !IsNoneType(t2) {
+		return fmt.Errorf("expected type %s, got %s", NONE_TYPE, t2.GetName())
+	}
+	return nil
+}
+
+func MakeNoneType() *NoneType {
+	return &NoneType{}
+}
+
+func IsNoneType(t RuntimeType) bool {
+	_, ok := t.(*NoneType)
+	return ok
+}
@@ -47,9 +47,11 @@ func NewRegistrar() Registrar {
 	r.Add(MakeVoidType(), tokenizer.Token{})
 	r.Add(MakeIntType(), tokenizer.Token{})
 	r.Add(MakeFloatType(), tokenizer.Token{})
+	r.Add(MakeNumType(), tokenizer.Token{})
 	r.Add(MakeBoolType(), tokenizer.Token{})
 	r.Add(MakeTypeType(), tokenizer.Token{})
 	r.Add(MakeStringType(), tokenizer.Token{})
+	r.Add(MakeNoneType(), tokenizer.Token{})
 
 	return r
 }
@@ -13,7 +13,12 @@ func (t *ScalarType) GetName() string {
 }
 
 func (t *ScalarType) Match(t2 RuntimeType) error {
-	_, ok := t2.(*ScalarType)
+	t2Scalar, ok := t2.(*ScalarType)
+
+	// num is the supertype of every number
+	if ok && t.IsNum() && t2Scalar.IsNumeric() {
+		return nil
+	}
 
 	if !ok || (t.GetName() != t2.GetName()) {
 		return fmt.Errorf("expected type %s, got %s", t.GetName(), t2.GetName())
@@ -30,6 +35,15 @@ func (t *ScalarType) IsFloat() bool {
 	return t.GetName() == "float"
 }
 
+func (t *ScalarType) IsNum() bool {
+	return t.GetName() == NUM_TYPE
+}
+
+// IsNumeric reports whether the type is int, float or their supertype num.
+func (t *ScalarType) IsNumeric() bool {
+	return t.IsInt() || t.IsFloat() || t.IsNum()
+}
+
 func (t *ScalarType) IsType() bool {
 	return t.GetName() == "type"
 }
@@ -75,6 +89,16 @@ func MakeFloatType() *ScalarType {
 	return New(FLOAT_TYPE)
 }
 
+func MakeNumType() *ScalarType {
+	return New(NUM_TYPE)
+}
+
+// IsNumericType reports whether t is int, float or num.
+func IsNumericType(t RuntimeType) bool {
+	tScalar, err := ToScalarType(t)
+	return err == nil && tScalar.IsNumeric()
+}
+
 func MakeStringType() *ScalarType {
 	return New(STRING_TYPE)
 }
@@ -8,6 +8,8 @@ const (
 	NUM_TYPE    = "num"
 	STRING_TYPE = "string"
 	ARRAY_TYPE  = "array"
+	VOID_TYPE   = "void"
+	NONE_TYPE   = "none"
 )
 
 type RuntimeTypeType = int
@@ -16,3 +18,15 @@ type RuntimeType interface {
 	GetName() string
 	Match(t2 RuntimeType) error
 }
+
+// DynamicType is implemented by the types whose values are only known at
+// runtime, like the values decoded from json.
+type DynamicType interface {
+	RuntimeType
+	IsDynamic() bool
+}
+
+func IsDynamicType(t RuntimeType) bool {
+	dynamicType, ok := t.(DynamicType)
+	return ok && dynamicType.IsDynamic()
+}
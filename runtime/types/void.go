@@ -8,7 +8,7 @@ type VoidType struct {
 }
 
 func (f *VoidType) GetName() string {
-	return "void"
+	return VOID_TYPE
 }
 
 func (t *VoidType) Match(t2 RuntimeType) error {
@@ -59,6 +59,29 @@ const (
 	TOKEN_KIND_LEN                  = "TOKEN_KIND_LEN"
 	TOKEN_KIND_NEW                  = "TOKEN_KIND_NEW"
 	TOKEN_KIND_DOT                  = "TOKEN_KIND_DOT"
+	TOKEN_KIND_IMPORT               = "TOKEN_KIND_IMPORT"
+	TOKEN_KIND_AS                   = "TOKEN_KIND_AS"
+	TOKEN_KIND_IN                   = "TOKEN_KIND_IN"
+	TOKEN_KIND_KEYS                 = "TOKEN_KIND_KEYS"
+	TOKEN_KIND_ENUM                 = "TOKEN_KIND_ENUM"
+	TOKEN_KIND_MATCH                = "TOKEN_KIND_MATCH"
+	TOKEN_KIND_QUESTION_MARK        = "TOKEN_KIND_QUESTION_MARK"
+	TOKEN_KIND_SOME                 = "TOKEN_KIND_SOME"
+	TOKEN_KIND_NONE                 = "TOKEN_KIND_NONE"
+	TOKEN_KIND_UNWRAP               = "TOKEN_KIND_UNWRAP"
+	TOKEN_KIND_TRY                  = "TOKEN_KIND_TRY"
+	TOKEN_KIND_CATCH                = "TOKEN_KIND_CATCH"
+	TOKEN_KIND_THROW                = "TOKEN_KIND_THROW"
+	TOKEN_KIND_BREAK                = "TOKEN_KIND_BREAK"
+	TOKEN_KIND_CONTINUE             = "TOKEN_KIND_CONTINUE"
+	TOKEN_KIND_DB_AND               = "TOKEN_KIND_DB_AND"
+	TOKEN_KIND_DB_BAR               = "TOKEN_KIND_DB_BAR"
+	TOKEN_KIND_CARET                = "TOKEN_KIND_CARET"
+	TOKEN_KIND_TILDE                = "TOKEN_KIND_TILDE"
+	TOKEN_KIND_SHIFT_LEFT           = "TOKEN_KIND_SHIFT_LEFT"
+	TOKEN_KIND_SHIFT_RIGHT          = "TOKEN_KIND_SHIFT_RIGHT"
+	TOKEN_KIND_INTERPOLATION_START  = "TOKEN_KIND_INTERPOLATION_START"
+	TOKEN_KIND_INTERPOLATION_END    = "TOKEN_KIND_INTERPOLATION_END"
 )
 
 type TokenLoc struct {
@@ -67,6 +90,16 @@ type TokenLoc struct {
 	End   int
 }
 
+// SyntaxError reports a character sequence that does not form a token.
+type SyntaxError struct {
+	Message string
+	Loc     TokenLoc
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s at position %d:%d", e.Message, e.Loc.Line, e.Loc.Start)
+}
+
 type Token struct {
 	Kind    TOKEN_KIND
 	Loc     TokenLoc
@@ -202,6 +235,12 @@ func (t *Tokenizer) Tokenize() ([]Token, error) {
 				c += next
 				kind = TOKEN_KIND_INFERIOR_OR_EQ_SIGN
 			}
+			if ok && next == "<" {
+				t.consume()
+				end = t.col
+				c += next
+				kind = TOKEN_KIND_SHIFT_LEFT
+			}
 			tokens = append(tokens, Token{
 				Kind: kind,
 				Loc: TokenLoc{
@@ -223,6 +262,12 @@ func (t *Tokenizer) Tokenize() ([]Token, error) {
 				c += next
 				kind = TOKEN_KIND_SUPERIOR_OR_EQ_SIGN
 			}
+			if ok && next == ">" {
+				t.consume()
+				end = t.col
+				c += next
+				kind = TOKEN_KIND_SHIFT_RIGHT
+			}
 			tokens = append(tokens, Token{
 				Kind: kind,
 				Loc: TokenLoc{
@@ -280,6 +325,17 @@ func (t *Tokenizer) Tokenize() ([]Token, error) {
 				},
 				Content: c,
 			})
+		case r == '?':
+			t.consume()
+			tokens = append(tokens, Token{
+				Kind: TOKEN_KIND_QUESTION_MARK,
+				Loc: TokenLoc{
+					Start: t.col,
+					End:   t.col,
+					Line:  t.line,
+				},
+				Content: c,
+			})
 		case r == '(':
 			t.consume()
 			tokens = append(tokens, Token{
@@ -293,10 +349,18 @@ func (t *Tokenizer) Tokenize() ([]Token, error) {
 			})
 		case r == '|':
 			t.consume()
+			start := t.col
+			kind := TOKEN_KIND_BAR
+			next, ok := t.peek()
+			if ok && next == "|" {
+				t.consume()
+				c += next
+				kind = TOKEN_KIND_DB_BAR
+			}
 			tokens = append(tokens, Token{
-				Kind: TOKEN_KIND_BAR,
+				Kind: kind,
 				Loc: TokenLoc{
-					Start: t.col,
+					Start: start,
 					End:   t.col,
 					Line:  t.line,
 				},
@@ -304,10 +368,18 @@ func (t *Tokenizer) Tokenize() ([]Token, error) {
 			})
 		case r == '&':
 			t.consume()
+			start := t.col
+			kind := TOKEN_KIND_AND
+			next, ok := t.peek()
+			if ok && next == "&" {
+				t.consume()
+				c += next
+				kind = TOKEN_KIND_DB_AND
+			}
 			tokens = append(tokens, Token{
-				Kind: TOKEN_KIND_AND,
+				Kind: kind,
 				Loc: TokenLoc{
-					Start: t.col,
+					Start: start,
 					End:   t.col,
 					Line:  t.line,
 				},
@@ -357,6 +429,28 @@ func (t *Tokenizer) Tokenize() ([]Token, error) {
 				},
 				Content: c,
 			})
+		case r == '^':
+			t.consume()
+			tokens = append(tokens, Token{
+				Kind: TOKEN_KIND_CARET,
+				Loc: TokenLoc{
+					Start: t.col,
+					End:   t.col,
+					Line:  t.line,
+				},
+				Content: c,
+			})
+		case r == '~':
+			t.consume()
+			tokens = append(tokens, Token{
+				Kind: TOKEN_KIND_TILDE,
+				Loc: TokenLoc{
+					Start: t.col,
+					End:   t.col,
+					Line:  t.line,
+				},
+				Content: c,
+			})
 		case r == '%':
 			t.consume()
 			tokens = append(tokens, Token{
@@ -440,41 +534,11 @@ func (t *Tokenizer) Tokenize() ([]Token, error) {
 				Content: c,
 			})
 		case isQuote(r):
-			string := c
-			t.consume()
-			tokStart := t.col
-			opener := r
-			for {
-				stop := false
-				c, ok = t.peek()
-				if !ok {
-					break
-				}
-				r, _ = utf8.DecodeRuneInString(c)
-				if r == opener {
-					previous, _ := t.peekAt(-1)
-					if previous != "\\" {
-						stop = true
-					}
-				}
-
-				string += c
-				t.consume()
-				if stop {
-					break
-				}
-
+			stringTokens, err := t.tokenizeString()
+			if err != nil {
+				return tokens, err
 			}
-			tokEnd := t.col
-			tokens = append(tokens, Token{
-				Kind: TOKEN_KIND_STRING_LIT,
-				Loc: TokenLoc{
-					Start: tokStart,
-					End:   tokEnd,
-					Line:  t.line,
-				},
-				Content: string,
-			})
+			tokens = append(tokens, stringTokens...)
 		case unicode.IsNumber(r):
 			number := c
 			t.consume()
@@ -570,6 +634,62 @@ func (t *Tokenizer) Tokenize() ([]Token, error) {
 				kind = TOKEN_KIND_CONST
 			}
 
+			if strings.ToLower(id) == "import" {
+				kind = TOKEN_KIND_IMPORT
+			}
+
+			if strings.ToLower(id) == "as" {
+				kind = TOKEN_KIND_AS
+			}
+
+			if strings.ToLower(id) == "in" {
+				kind = TOKEN_KIND_IN
+			}
+
+			if strings.ToLower(id) == "keys" {
+				kind = TOKEN_KIND_KEYS
+			}
+
+			if strings.ToLower(id) == "enum" {
+				kind = TOKEN_KIND_ENUM
+			}
+
+			if strings.ToLower(id) == "match" {
+				kind = TOKEN_KIND_MATCH
+			}
+
+			if strings.ToLower(id) == "some" {
+				kind = TOKEN_KIND_SOME
+			}
+
+			if strings.ToLower(id) == "none" {
+				kind = TOKEN_KIND_NONE
+			}
+
+			if strings.ToLower(id) == "unwrap" {
+				kind = TOKEN_KIND_UNWRAP
+			}
+
+			if strings.ToLower(id) == "try" {
+				kind = TOKEN_KIND_TRY
+			}
+
+			if strings.ToLower(id) == "catch" {
+				kind = TOKEN_KIND_CATCH
+			}
+
+			if strings.ToLower(id) == "throw" {
+				kind = TOKEN_KIND_THROW
+			}
+
+			if strings.ToLower(id) == "break" {
+				kind = TOKEN_KIND_BREAK
+			}
+
+			if strings.ToLower(id) == "continue" {
+				kind = TOKEN_KIND_CONTINUE
+			}
+
 			tokens = append(tokens, Token{
 				Kind: kind,
 				Loc: TokenLoc{
@@ -580,13 +700,149 @@ func (t *Tokenizer) Tokenize() ([]Token, error) {
 				Content: id,
 			})
 		default:
-			return tokens, fmt.Errorf("unexpected token %s at position %d:%d", c, t.line, t.cursor)
+			return tokens, &SyntaxError{Message: fmt.Sprintf("unexpected token %s", c), Loc: TokenLoc{Line: t.line, Start: t.cursor, End: t.cursor}}
 		}
 	}
 
 	return tokens, nil
 }
 
+// tokenizeString reads a string literal. A literal holding {expression}
+// parts is split between INTERPOLATION_START and INTERPOLATION_END into
+// string literals and the tokens of each expression, surrounded by curly
+// braces. \{ stands for a literal curly brace.
+func (t *Tokenizer) tokenizeString() ([]Token, error) {
+	opener, _ := t.peek()
+	t.consume()
+	tokStart := t.col
+	text := opener
+	parts := []Token{}
+	interpolated := false
+	for {
+		c, ok := t.peek()
+		if !ok {
+			break
+		}
+		if c == opener {
+			previous, _ := t.peekAt(-1)
+			if previous != "\\" {
+				text += c
+				t.consume()
+				break
+			}
+		}
+		next, _ := t.peekAt(1)
+		if c == "\\" && next == "{" {
+			t.consume()
+			text += next
+			t.consume()
+			continue
+		}
+		if c != "{" {
+			text += c
+			t.consume()
+			continue
+		}
+		interpolated = true
+		if len(text) > len(opener) {
+			parts = append(parts, t.stringToken(text+opener, tokStart))
+		}
+		exprTokens, err := t.tokenizeInterpolatedExpr()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, exprTokens...)
+		text = opener
+		tokStart = t.col + 1
+	}
+	if !interpolated {
+		return []Token{t.stringToken(text, tokStart)}, nil
+	}
+	if len(text) > 2*len(opener) {
+		parts = append(parts, t.stringToken(text, tokStart))
+	}
+	loc := TokenLoc{Start: t.col, End: t.col, Line: t.line}
+	return append(append([]Token{{
+		Kind:    TOKEN_KIND_INTERPOLATION_START,
+		Loc:     TokenLoc{Start: tokStart, End: tokStart, Line: t.line},
+		Content: opener,
+	}}, parts...), Token{
+		Kind:    TOKEN_KIND_INTERPOLATION_END,
+		Loc:     loc,
+		Content: opener,
+	}), nil
+}
+
+// tokenizeInterpolatedExpr reads the {expression} part of a string,
+// strings nested in the expression being skipped while looking for the
+// closing curly brace.
+func (t *Tokenizer) tokenizeInterpolatedExpr() ([]Token, error) {
+	t.consume()
+	open := Token{
+		Kind:    TOKEN_KIND_LEFT_CURCLY,
+		Loc:     TokenLoc{Start: t.col, End: t.col, Line: t.line},
+		Content: "{",
+	}
+	offset := t.col
+	code := ""
+	depth := 0
+	quote := ""
+	for {
+		c, ok := t.peek()
+		if !ok || c == "\n" {
+			return nil, &SyntaxError{Message: "unterminated string interpolation", Loc: open.Loc}
+		}
+		r, _ := utf8.DecodeRuneInString(c)
+		previous, _ := t.peekAt(-1)
+		if quote != "" {
+			if c == quote && previous != "\\" {
+				quote = ""
+			}
+		} else if isQuote(r) {
+			quote = c
+		} else if c == "{" {
+			depth++
+		} else if c == "}" {
+			if depth == 0 {
+				break
+			}
+			depth--
+		}
+		code += c
+		t.consume()
+	}
+	t.consume()
+	tokens, err := Tokenize(code)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, &SyntaxError{Message: "empty string interpolation", Loc: open.Loc}
+	}
+	for i := range tokens {
+		tokens[i].Loc.Line = t.line
+		tokens[i].Loc.Start += offset
+		tokens[i].Loc.End += offset
+	}
+	return append(append([]Token{open}, tokens...), Token{
+		Kind:    TOKEN_KIND_RIGHT_CURLY,
+		Loc:     TokenLoc{Start: t.col, End: t.col, Line: t.line},
+		Content: "}",
+	}), nil
+}
+
+func (t *Tokenizer) stringToken(content string, start int) Token {
+	return Token{
+		Kind: TOKEN_KIND_STRING_LIT,
+		Loc: TokenLoc{
+			Start: start,
+			End:   t.col,
+			Line:  t.line,
+		},
+		Content: content,
+	}
+}
+
 func isQuote(str rune) bool {
 	return str == '\'' || str == '"'
 }
@@ -0,0 +1,359 @@
+package vm
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/dani-gouken/nomad/runtime/data"
+	"github.com/dani-gouken/nomad/runtime/types"
+	"github.com/dani-gouken/nomad/tokenizer"
+)
+
+const BIND_FIELD_TAG = "nomad"
+
+var errorInterface = reflect.TypeOf((*error)(nil)).Elem()
+
+// Bind exposes a Go value to the scripts using reflection.
+// Structs are registered as object types, functions are registered as
+// native functions and any other value is declared as a global variable.
+func (vm *Vm) Bind(name string, goValue interface{}) error {
+	value := reflect.ValueOf(goValue)
+	if !value.IsValid() {
+		return fmt.Errorf("cannot bind nil value to [%s]", name)
+	}
+	switch value.Kind() {
+	case reflect.Struct:
+		_, err := vm.bindStruct(name, value)
+		return err
+	case reflect.Func:
+		return vm.bindFunc(name, value)
+	default:
+		runtimeValue, err := vm.ToRuntimeValue(goValue)
+		if err != nil {
+			return fmt.Errorf("cannot bind [%s]: %s", name, err.Error())
+		}
+		return vm.callStack.Get(0).Env().DeclareVariable(name, &runtimeValue, runtimeValue.RuntimeType)
+	}
+}
+
+// ToRuntimeValue converts a Go value into its nomad counterpart.
+func (vm *Vm) ToRuntimeValue(goValue interface{}) (data.RuntimeValue, error) {
+	return vm.toRuntimeValue(reflect.ValueOf(goValue))
+}
+
+// FromRuntimeValue converts a nomad value into target, which must be a pointer.
+func (vm *Vm) FromRuntimeValue(value data.RuntimeValue, target interface{}) error {
+	ptr := reflect.ValueOf(target)
+	if ptr.Kind() != reflect.Pointer || ptr.IsNil() {
+		return fmt.Errorf("non-nil pointer expected, got %T", target)
+	}
+	converted, err := vm.fromRuntimeValue(value, ptr.Elem().Type())
+	if err != nil {
+		return err
+	}
+	ptr.Elem().Set(converted)
+	return nil
+}
+
+func (vm *Vm) bindStruct(name string, value reflect.Value) (*types.ObjectType, error) {
+	goType := value.Type()
+	objectType, ok := vm.boundTypes[goType]
+	if ok {
+		return objectType, nil
+	}
+	if vm.types.Has(name) {
+		return nil, fmt.Errorf("cannot redeclare type %s", name)
+	}
+	objectType = types.NewObjectType()
+	objectType.SetName(name)
+	// registered before the fields so that recursive types resolve
+	vm.boundTypes[goType] = objectType
+	for i := 0; i < goType.NumField(); i++ {
+		field := goType.Field(i)
+		fieldName, ok := boundFieldName(field)
+		if !ok {
+			continue
+		}
+		fieldType, err := vm.typeOf(field.Type)
+		if err != nil {
+			delete(vm.boundTypes, goType)
+			return nil, fmt.Errorf("cannot bind field %s.%s: %s", name, field.Name, err.Error())
+		}
+		defaultValue, err := vm.toRuntimeValue(value.Field(i))
+		if err != nil {
+			delete(vm.boundTypes, goType)
+			return nil, fmt.Errorf("cannot bind field %s.%s: %s", name, field.Name, err.Error())
+		}
+		err = objectType.AddField(fieldName, fieldType, defaultValue)
+		if err != nil {
+			delete(vm.boundTypes, goType)
+			return nil, err
+		}
+	}
+	err := vm.types.Add(objectType, tokenizer.Token{})
+	if err != nil {
+		delete(vm.boundTypes, goType)
+		return nil, err
+	}
+	return objectType, nil
+}
+
+func (vm *Vm) bindFunc(name string, value reflect.Value) error {
+	goType := value.Type()
+	if goType.IsVariadic() {
+		return fmt.Errorf("cannot bind variadic function [%s]", name)
+	}
+	returnType, returnsError, err := vm.funcReturnType(goType)
+	if err != nil {
+		return fmt.Errorf("cannot bind function [%s]: %s", name, err.Error())
+	}
+	signature := data.NewFuncSignature(returnType)
+	for i := 0; i < goType.NumIn(); i++ {
+		paramType, err := vm.typeOf(goType.In(i))
+		if err != nil {
+			return fmt.Errorf("cannot bind parameter %d of function [%s]: %s", i, name, err.Error())
+		}
+		err = signature.AddParam(fmt.Sprintf("arg%d", i), paramType, data.RuntimeValue{})
+		if err != nil {
+			return err
+		}
+	}
+	return vm.RegisterNative(name, signature, func(args []data.RuntimeValue) (data.RuntimeValue, error) {
+		in := make([]reflect.Value, len(args))
+		for i, arg := range args {
+			goArg, err := vm.fromRuntimeValue(arg, goType.In(i))
+			if err != nil {
+				return data.RuntimeValue{}, fmt.Errorf("argument %d: %s", i, err.Error())
+			}
+			in[i] = goArg
+		}
+		out := value.Call(in)
+		if returnsError {
+			errValue := out[len(out)-1]
+			if !errValue.IsNil() {
+				return data.RuntimeValue{}, errValue.Interface().(error)
+			}
+			out = out[:len(out)-1]
+		}
+		if len(out) == 0 {
+			return data.RuntimeValue{}, nil
+		}
+		return vm.toRuntimeValue(out[0])
+	})
+}
+
+func (vm *Vm) funcReturnType(goType reflect.Type) (types.RuntimeType, bool, error) {
+	void := vm.types.GetOrPanic(types.VOID_TYPE)
+	switch goType.NumOut() {
+	case 0:
+		return void, false, nil
+	case 1:
+		if goType.Out(0) == errorInterface {
+			return void, true, nil
+		}
+		t, err := vm.typeOf(goType.Out(0))
+		return t, false, err
+	case 2:
+		if goType.Out(1) != errorInterface {
+			return nil, false, fmt.Errorf("second return value should be an error")
+		}
+		t, err := vm.typeOf(goType.Out(0))
+		return t, true, err
+	}
+	return nil, false, fmt.Errorf("too many return values")
+}
+
+func (vm *Vm) typeOf(goType reflect.Type) (types.RuntimeType, error) {
+	switch goType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return vm.types.GetOrPanic(types.INT_TYPE), nil
+	case reflect.Float32, reflect.Float64:
+		return vm.types.GetOrPanic(types.FLOAT_TYPE), nil
+	case reflect.String:
+		return vm.types.GetOrPanic(types.STRING_TYPE), nil
+	case reflect.Bool:
+		return vm.types.GetOrPanic(types.BOOL_TYPE), nil
+	case reflect.Slice, reflect.Array:
+		subtype, err := vm.typeOf(goType.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return types.NewArrayType(subtype), nil
+	case reflect.Map:
+		keyType, err := vm.typeOf(goType.Key())
+		if err != nil {
+			return nil, err
+		}
+		valueType, err := vm.typeOf(goType.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return types.NewMapType(keyType, valueType)
+	case reflect.Struct:
+		objectType, ok := vm.boundTypes[goType]
+		if ok {
+			return objectType, nil
+		}
+		if goType.Name() == "" {
+			return nil, fmt.Errorf("cannot bind anonymous struct %s, use a named type", goType.String())
+		}
+		return vm.bindStruct(goType.Name(), reflect.New(goType).Elem())
+	}
+	return nil, fmt.Errorf("unsupported go type %s", goType.String())
+}
+
+func (vm *Vm) toRuntimeValue(value reflect.Value) (data.RuntimeValue, error) {
+	if !value.IsValid() {
+		return data.RuntimeValue{}, fmt.Errorf("cannot convert nil value")
+	}
+	runtimeType, err := vm.typeOf(value.Type())
+	if err != nil {
+		return data.RuntimeValue{}, err
+	}
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return data.RuntimeValue{RuntimeType: runtimeType, Value: value.Int()}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return data.RuntimeValue{RuntimeType: runtimeType, Value: int64(value.Uint())}, nil
+	case reflect.Float32, reflect.Float64:
+		return data.RuntimeValue{RuntimeType: runtimeType, Value: value.Float()}, nil
+	case reflect.String:
+		return data.RuntimeValue{RuntimeType: runtimeType, Value: value.String()}, nil
+	case reflect.Bool:
+		return data.RuntimeValue{RuntimeType: runtimeType, Value: value.Bool()}, nil
+	case reflect.Slice, reflect.Array:
+		array := data.RuntimeArray{Values: make([]data.RuntimeValue, 0, value.Len())}
+		for i := 0; i < value.Len(); i++ {
+			item, err := vm.toRuntimeValue(value.Index(i))
+			if err != nil {
+				return data.RuntimeValue{}, fmt.Errorf("[%d]: %s", i, err.Error())
+			}
+			array.Values = append(array.Values, item)
+		}
+		return data.RuntimeValue{RuntimeType: runtimeType, Value: array}, nil
+	case reflect.Map:
+		runtimeMap := data.NewRuntimeMap()
+		iter := value.MapRange()
+		for iter.Next() {
+			key, err := vm.toRuntimeValue(iter.Key())
+			if err != nil {
+				return data.RuntimeValue{}, err
+			}
+			item, err := vm.toRuntimeValue(iter.Value())
+			if err != nil {
+				return data.RuntimeValue{}, fmt.Errorf("[%v]: %s", key.Value, err.Error())
+			}
+			runtimeMap.Set(key, item)
+		}
+		return data.RuntimeValue{RuntimeType: runtimeType, Value: runtimeMap}, nil
+	case reflect.Struct:
+		object := data.NewRuntimeObject()
+		for i := 0; i < value.NumField(); i++ {
+			fieldName, ok := boundFieldName(value.Type().Field(i))
+			if !ok {
+				continue
+			}
+			field, err := vm.toRuntimeValue(value.Field(i))
+			if err != nil {
+				return data.RuntimeValue{}, fmt.Errorf("%s: %s", fieldName, err.Error())
+			}
+			object.SetField(fieldName, field)
+		}
+		return data.RuntimeValue{RuntimeType: runtimeType, Value: object}, nil
+	}
+	return data.RuntimeValue{}, fmt.Errorf("unsupported go type %s", value.Type().String())
+}
+
+func (vm *Vm) fromRuntimeValue(value data.RuntimeValue, goType reflect.Type) (reflect.Value, error) {
+	expectedType, err := vm.typeOf(goType)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	err = expectedType.Match(value.RuntimeType)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	result := reflect.New(goType).Elem()
+	switch goType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i := value.Value.(int64)
+		if result.OverflowInt(i) {
+			return reflect.Value{}, fmt.Errorf("%d overflows %s", i, goType.String())
+		}
+		result.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		i := value.Value.(int64)
+		if i < 0 || result.OverflowUint(uint64(i)) {
+			return reflect.Value{}, fmt.Errorf("%d overflows %s", i, goType.String())
+		}
+		result.SetUint(uint64(i))
+	case reflect.Float32, reflect.Float64:
+		result.SetFloat(value.Value.(float64))
+	case reflect.String:
+		result.SetString(value.Value.(string))
+	case reflect.Bool:
+		result.SetBool(value.Value.(bool))
+	case reflect.Slice, reflect.Array:
+		array := value.Value.(data.RuntimeArray)
+		if goType.Kind() == reflect.Slice {
+			result = reflect.MakeSlice(goType, len(array.Values), len(array.Values))
+		} else if len(array.Values) > goType.Len() {
+			return reflect.Value{}, fmt.Errorf("array of length %d does not fit in %s", len(array.Values), goType.String())
+		}
+		for i, item := range array.Values {
+			goItem, err := vm.fromRuntimeValue(item, goType.Elem())
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("[%d]: %s", i, err.Error())
+			}
+			result.Index(i).Set(goItem)
+		}
+	case reflect.Map:
+		runtimeMap := value.Value.(*data.RuntimeMap)
+		result = reflect.MakeMapWithSize(goType, runtimeMap.Len())
+		for _, key := range runtimeMap.Keys() {
+			goKey, err := vm.fromRuntimeValue(key, goType.Key())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			item, _ := runtimeMap.Get(key)
+			goItem, err := vm.fromRuntimeValue(item, goType.Elem())
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("[%v]: %s", key.Value, err.Error())
+			}
+			result.SetMapIndex(goKey, goItem)
+		}
+	case reflect.Struct:
+		object := value.Value.(*data.RuntimeObject)
+		for i := 0; i < goType.NumField(); i++ {
+			fieldName, ok := boundFieldName(goType.Field(i))
+			if !ok {
+				continue
+			}
+			field, err := object.GetField(fieldName)
+			if err != nil {
+				continue
+			}
+			goField, err := vm.fromRuntimeValue(*field, goType.Field(i).Type)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("%s: %s", fieldName, err.Error())
+			}
+			result.Field(i).Set(goField)
+		}
+	}
+	return result, nil
+}
+
+func boundFieldName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+	tag := field.Tag.Get(BIND_FIELD_TAG)
+	if tag == "-" {
+		return "", false
+	}
+	if tag != "" {
+		return tag, true
+	}
+	return field.Name, true
+}
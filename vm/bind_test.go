@@ -0,0 +1,96 @@
+package vm_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/dani-gouken/nomad/interpreter"
+	"github.com/dani-gouken/nomad/runtime/types"
+	"github.com/dani-gouken/nomad/vm"
+	"github.com/stretchr/testify/assert"
+)
+
+type point struct {
+	X     float64 `nomad:"x"`
+	Y     float64 `nomad:"y"`
+	Label string  `nomad:"label"`
+	Tags  []string
+	id    int
+}
+
+func TestBindStructAndFunc(t *testing.T) {
+	instance := vm.New()
+	assert.NoError(t, instance.Bind("Point", point{Label: "origin"}))
+
+	var received []point
+	assert.NoError(t, instance.Bind("norm", func(p point) float64 {
+		received = append(received, p)
+		return math.Sqrt(p.X*p.X + p.Y*p.Y)
+	}))
+	var norms []float64
+	assert.NoError(t, instance.Bind("keep", func(f float64) {
+		norms = append(norms, f)
+	}))
+	assert.NoError(t, instance.Bind("scale", func(p point, factor int) point {
+		p.X *= float64(factor)
+		p.Y *= float64(factor)
+		return p
+	}))
+
+	pointType, err := instance.Types().Get("Point")
+	assert.NoError(t, err)
+	objectType, err := types.ToObjectType(pointType)
+	assert.NoError(t, err)
+	_, err = objectType.GetFieldType("id")
+	assert.Error(t, err)
+
+	i := interpreter.NewInterpreter()
+	err = i.Interpret(`
+auto p :: new Point{ x :: 3.0, y :: 4.0, Tags :: [string]{"a", "b"} }
+keep(norm(p))
+keep(norm(scale(p, 2)))
+keep(scale(p, 3).y)
+`, instance)
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{5, 10, 12}, norms)
+	assert.Equal(t, point{X: 3, Y: 4, Label: "origin", Tags: []string{"a", "b"}}, received[0])
+}
+
+func TestBindErrors(t *testing.T) {
+	instance := vm.New()
+	assert.NoError(t, instance.Bind("fail", func(n int8) (int, error) {
+		if n < 0 {
+			return 0, errors.New("negative")
+		}
+		return int(n), nil
+	}))
+	assert.NoError(t, instance.Bind("limit", 200))
+	assert.Error(t, instance.Bind("channel", make(chan int)))
+	assert.Error(t, instance.Bind("variadic", func(a ...int) {}))
+	assert.EqualError(
+		t,
+		instance.Bind("anonymous", struct{ Inner struct{ X int } }{}),
+		"cannot bind field anonymous.Inner: cannot bind anonymous struct struct { X int }, use a named type",
+	)
+
+	i := interpreter.NewInterpreter()
+	assert.NoError(t, i.Interpret("fail(1)", instance))
+	assert.ErrorContains(t, i.Interpret("fail(-1)", instance), "negative")
+	assert.ErrorContains(t, i.Interpret("fail(limit)", instance), "200 overflows int8")
+	assert.ErrorContains(t, i.Interpret(`fail("a")`, instance), "type mismatch")
+}
+
+func TestRuntimeValueRoundTrip(t *testing.T) {
+	instance := vm.New()
+	value, err := instance.ToRuntimeValue([]point{{X: 1, Tags: []string{}}})
+	assert.NoError(t, err)
+	assert.Equal(t, "[point]", value.RuntimeType.GetName())
+
+	var back []point
+	assert.NoError(t, instance.FromRuntimeValue(value, &back))
+	assert.Equal(t, []point{{X: 1, Tags: []string{}}}, back)
+
+	var wrong []string
+	assert.Error(t, instance.FromRuntimeValue(value, &wrong))
+}
@@ -0,0 +1,26 @@
+package vm
+
+import (
+	"github.com/dani-gouken/nomad/runtime/data"
+	"github.com/dani-gouken/nomad/runtime/types"
+)
+
+// bitwise applies & or | on integers, or on booleans as logical operators
+// evaluating both operands.
+func bitwise(registrar types.Registrar, code string, lhs *data.RuntimeValue, rhs *data.RuntimeValue) (*data.RuntimeValue, error) {
+	symbol, err := OpToSymbol(code)
+	if err != nil {
+		return nil, err
+	}
+	if types.ExpectedBoolType(lhs.RuntimeType) != nil || types.ExpectedBoolType(rhs.RuntimeType) != nil {
+		return data.ApplyBinaryOp(registrar, symbol, lhs, rhs)
+	}
+	result := lhs.Value.(bool) && rhs.Value.(bool)
+	if code == OP_BIT_OR {
+		result = lhs.Value.(bool) || rhs.Value.(bool)
+	}
+	return &data.RuntimeValue{
+		RuntimeType: lhs.RuntimeType,
+		Value:       result,
+	}, nil
+}
@@ -0,0 +1,61 @@
+package vm
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/dani-gouken/nomad/runtime/data"
+	"github.com/dani-gouken/nomad/runtime/types"
+)
+
+// registerBuiltins declares the functions available to every script and
+// the modules implemented in go.
+func (vm *Vm) registerBuiltins() {
+	numType := vm.types.GetOrPanic(types.NUM_TYPE)
+	intType := vm.types.GetOrPanic(types.INT_TYPE)
+	floatType := vm.types.GetOrPanic(types.FLOAT_TYPE)
+
+	toInt := data.NewFuncSignature(intType)
+	toInt.AddParam("value", numType, data.RuntimeValue{})
+	err := vm.RegisterNative("int", toInt, func(args []data.RuntimeValue) (data.RuntimeValue, error) {
+		switch v := args[0].Value.(type) {
+		case int64:
+			return data.RuntimeValue{RuntimeType: intType, Value: v}, nil
+		case float64:
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return data.RuntimeValue{}, fmt.Errorf("cannot convert %v to int", v)
+			}
+			return data.RuntimeValue{RuntimeType: intType, Value: int64(v)}, nil
+		}
+		return data.RuntimeValue{}, fmt.Errorf("cannot convert %v to int", args[0].Value)
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	toFloat := data.NewFuncSignature(floatType)
+	toFloat.AddParam("value", numType, data.RuntimeValue{})
+	err = vm.RegisterNative("float", toFloat, func(args []data.RuntimeValue) (data.RuntimeValue, error) {
+		return data.Promote(data.Number(args[0]), floatType), nil
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	err = vm.registerMathModule()
+	if err != nil {
+		panic(err)
+	}
+	err = vm.registerHttpModule()
+	if err != nil {
+		panic(err)
+	}
+	err = vm.registerFsModule()
+	if err != nil {
+		panic(err)
+	}
+	err = vm.registerJsonModule()
+	if err != nil {
+		panic(err)
+	}
+}
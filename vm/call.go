@@ -0,0 +1,98 @@
+package vm
+
+import (
+	"fmt"
+
+	"github.com/dani-gouken/nomad/runtime/data"
+	"github.com/dani-gouken/nomad/runtime/types"
+	"github.com/dani-gouken/nomad/tokenizer"
+)
+
+// GetGlobal returns the value of a variable declared at the top level of the scripts.
+func (vm *Vm) GetGlobal(name string) (data.RuntimeValue, error) {
+	value, err := vm.callStack.Get(0).Env().GetVariable(name)
+	if err != nil {
+		return data.RuntimeValue{}, err
+	}
+	return *value, nil
+}
+
+// Globals returns the variables of the root scope: the builtins, the values
+// registered by the host and the globals declared by the programs run so far.
+func (vm *Vm) Globals() map[string]data.RuntimeValue {
+	globals := map[string]data.RuntimeValue{}
+	for name, value := range vm.callStack.Get(0).Env().Variables() {
+		globals[name] = *value
+	}
+	return globals
+}
+
+// Call invokes a nomad function with positional arguments and returns its result.
+func (vm *Vm) Call(fn data.RuntimeValue, args ...data.RuntimeValue) (data.RuntimeValue, error) {
+	return vm.CallNamed(fn, args, nil)
+}
+
+// CallNamed invokes a nomad function with positional and named arguments,
+// runs it until it returns and gives back the returned value.
+func (vm *Vm) CallNamed(fn data.RuntimeValue, args []data.RuntimeValue, namedArgs map[string]data.RuntimeValue) (data.RuntimeValue, error) {
+	if fn.RuntimeType == nil || !types.IsFuncType(fn.RuntimeType) {
+		return data.RuntimeValue{}, fmt.Errorf("cannot call non-function value")
+	}
+	f := fn.Value.(*data.RuntimeFunc)
+
+	vm.ClearArguments()
+	for _, arg := range args {
+		vm.PushPositionalArgument(arg)
+	}
+	for name, arg := range namedArgs {
+		err := vm.PushNamedArgument(name, arg)
+		if err != nil {
+			vm.ClearArguments()
+			return data.RuntimeValue{}, err
+		}
+	}
+	debugToken := tokenizer.Token{Content: f.Tag}
+	f, boundArgs, err := vm.bindArguments(f, debugToken)
+	if err != nil {
+		return data.RuntimeValue{}, err
+	}
+	if f.IsNative() {
+		return vm.callNative(f, boundArgs, debugToken)
+	}
+
+	returnDepth := vm.callStack.pointer
+	frame := vm.newCallFrame(-1, f, debugToken)
+	for index, pData := range f.Signature.Parameters {
+		frame.Env().DeclareVariable(pData.Name, &boundArgs[index], pData.RuntimeType)
+	}
+	err = vm.callStack.Push(frame)
+	if err != nil {
+		return data.RuntimeValue{}, err
+	}
+	err = vm.run(f.Begin, returnDepth)
+	if err != nil {
+		vm.callStack.SetPointer(returnDepth)
+		return data.RuntimeValue{}, err
+	}
+	if vm.callStack.pointer != returnDepth {
+		vm.callStack.SetPointer(returnDepth)
+		return data.RuntimeValue{}, fmt.Errorf("function %s did not return", f.Tag)
+	}
+	result, err := vm.stack().Pop()
+	if err != nil {
+		return data.RuntimeValue{}, err
+	}
+	return *result, nil
+}
+
+// CallMethod invokes the method name of an object with positional arguments.
+func (vm *Vm) CallMethod(object data.RuntimeValue, name string, args ...data.RuntimeValue) (data.RuntimeValue, error) {
+	if object.RuntimeType == nil || !types.IsObjectType(object.RuntimeType) {
+		return data.RuntimeValue{}, fmt.Errorf("cannot call method [%s] of non-object value", name)
+	}
+	method, ok := lookupMethod(object, name)
+	if !ok {
+		return data.RuntimeValue{}, fmt.Errorf("type %s has no method [%s]", object.RuntimeType.GetName(), name)
+	}
+	return vm.Call(method, args...)
+}
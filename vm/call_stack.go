@@ -17,6 +17,7 @@ type Frame struct {
 	Parent      *Frame
 	env         Environment
 	returnAddr  int
+	module      string
 }
 
 type CallStack struct {
@@ -82,19 +83,25 @@ func (s *CallStack) SetPointer(pointer int) {
 }
 
 func (s *CallStack) GetVariable(name string) (*data.RuntimeValue, error) {
+	variable, _, err := s.FindVariable(name)
+	return variable, err
+}
+
+// FindVariable returns the variable name along with the environment
+// declaring it.
+func (s *CallStack) FindVariable(name string) (*data.RuntimeValue, *Environment, error) {
 	frame, err := s.Current()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	var globalErr error
 	for frame != nil {
 		variable, err := frame.Env().GetVariable(name)
 		if err == nil {
-			return variable, nil
+			return variable, frame.Env(), nil
 		}
 		globalErr = err
 		frame = frame.Parent
 	}
-	return nil, globalErr
-
+	return nil, nil, globalErr
 }
@@ -0,0 +1,123 @@
+package vm_test
+
+import (
+	"testing"
+
+	"github.com/dani-gouken/nomad/interpreter"
+	"github.com/dani-gouken/nomad/runtime/data"
+	"github.com/dani-gouken/nomad/vm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCall(t *testing.T) {
+	instance := vm.New()
+	i := interpreter.NewInterpreter()
+	err := i.Interpret(`
+auto greet :: func(string name, string greeting :: "hello") string {
+    return greeting + " " + name
+}
+auto fib :: func(int n) int {
+    if n < 2 {
+        return n
+    }
+    return fib(n - 1) + fib(n - 2)
+}
+`, instance)
+	assert.NoError(t, err)
+
+	fib, err := instance.GetGlobal("fib")
+	assert.NoError(t, err)
+	n, err := instance.ToRuntimeValue(10)
+	assert.NoError(t, err)
+	result, err := instance.Call(fib, n)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(55), result.Value)
+
+	greet, err := instance.GetGlobal("greet")
+	assert.NoError(t, err)
+	name, _ := instance.ToRuntimeValue("nomad")
+	greeting, _ := instance.ToRuntimeValue("bye")
+	result, err = instance.Call(greet, name)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello nomad", result.Value)
+
+	result, err = instance.CallNamed(greet, nil, map[string]data.RuntimeValue{
+		"name":     name,
+		"greeting": greeting,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "bye nomad", result.Value)
+
+	_, err = instance.Call(greet, n)
+	assert.ErrorContains(t, err, "type mismatch for parameter \"name\"")
+
+	// functions declared by a previous interpretation remain callable
+	err = i.Interpret("auto fib12 :: fib(12)", instance)
+	assert.NoError(t, err)
+	fib12, err := instance.GetGlobal("fib12")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(144), fib12.Value)
+}
+
+func TestCallCallback(t *testing.T) {
+	instance := vm.New()
+	var seen []int64
+	err := instance.Bind("log", func(n int) {
+		seen = append(seen, int64(n))
+	})
+	assert.NoError(t, err)
+
+	i := interpreter.NewInterpreter()
+	err = i.Interpret(`
+auto handler :: func(int n) void {
+    log(n * 2)
+}
+auto broken :: func(int n) int {
+    log(n)
+}
+`, instance)
+	assert.NoError(t, err)
+
+	handler, err := instance.GetGlobal("handler")
+	assert.NoError(t, err)
+	for n := 1; n <= 3; n++ {
+		arg, _ := instance.ToRuntimeValue(n)
+		result, err := instance.Call(handler, arg)
+		assert.NoError(t, err)
+		assert.Nil(t, result.Value)
+	}
+	assert.Equal(t, []int64{2, 4, 6}, seen)
+
+	broken, err := instance.GetGlobal("broken")
+	assert.NoError(t, err)
+	arg, _ := instance.ToRuntimeValue(1)
+	_, err = instance.Call(broken, arg)
+	assert.ErrorContains(t, err, "without returning a value of type int")
+
+	_, err = instance.Call(arg)
+	assert.Error(t, err)
+
+	// the vm is still usable after a failed call
+	result, err := instance.Call(handler, arg)
+	assert.NoError(t, err)
+	assert.Nil(t, result.Value)
+}
+
+func TestNestedCallArguments(t *testing.T) {
+	instance := vm.New()
+	i := interpreter.NewInterpreter()
+	err := i.Interpret(`
+auto sub :: func(int a, int b) int { return a - b }
+auto twice :: func(int n) int { return n * 2 }
+auto positional :: sub(10, twice(3))
+auto nested :: sub(twice(sub(5, 1)), sub(b: 1, a: twice(2)))
+`, instance)
+	assert.NoError(t, err)
+
+	positional, err := instance.GetGlobal("positional")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), positional.Value)
+	nested, err := instance.GetGlobal("nested")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), nested.Value)
+}
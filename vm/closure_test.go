@@ -0,0 +1,76 @@
+package vm_test
+
+import (
+	"testing"
+
+	"github.com/dani-gouken/nomad/interpreter"
+	"github.com/dani-gouken/nomad/vm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClosureKeepsCapturedVariables(t *testing.T) {
+	instance := vm.New()
+	i := interpreter.NewInterpreter()
+	err := i.Interpret(`
+auto counter :: func(int start) func() -> (int) {
+    int count :: start
+    return func() int {
+        count++
+        return count
+    }
+}
+auto a :: counter(0)
+auto b :: counter(10)
+a()
+auto first :: a()
+auto second :: b()
+`, instance)
+	assert.NoError(t, err)
+
+	first, err := instance.GetGlobal("first")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), first.Value)
+	second, err := instance.GetGlobal("second")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(11), second.Value)
+
+	a, err := instance.GetGlobal("a")
+	assert.NoError(t, err)
+	result, err := instance.Call(a)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), result.Value)
+}
+
+func TestFunctionDoesNotSeeCallerVariables(t *testing.T) {
+	i := interpreter.NewInterpreter()
+	err := i.Interpret(`
+auto read :: func() int {
+    return secret
+}
+auto caller :: func() int {
+    int secret :: 3
+    return read()
+}
+caller()
+`, vm.New())
+	assert.ErrorContains(t, err, "undeclared variable [secret]")
+}
+
+func TestFunctionAssignsItsDefiningFrameVariables(t *testing.T) {
+	instance := vm.New()
+	i := interpreter.NewInterpreter()
+	err := i.Interpret(`
+int total :: 0
+auto add :: func(int n) int {
+    total :: total + n
+    return total
+}
+add(2)
+add(3)
+`, instance)
+	assert.NoError(t, err)
+
+	total, err := instance.GetGlobal("total")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), total.Value)
+}
@@ -0,0 +1,56 @@
+package vm_test
+
+import (
+	"testing"
+
+	"github.com/dani-gouken/nomad/interpreter"
+	"github.com/dani-gouken/nomad/vm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConstRead(t *testing.T) {
+	i := interpreter.NewInterpreter()
+	instance := vm.New()
+	err := i.Interpret(`
+const int answer :: 42
+auto double :: answer * 2
+`, instance)
+	assert.NoError(t, err)
+
+	double, err := instance.GetGlobal("double")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(84), double.Value)
+}
+
+func TestConstAssignmentIsRejectedAtCompileTime(t *testing.T) {
+	i := interpreter.NewInterpreter()
+	for code, message := range map[string]string{
+		"const int answer :: 42\nanswer :: 1":                "/2:1:6: compilation error. cannot assign to constant answer declared at 1:8",
+		"const int answer :: 42\nanswer++":                   "/2:1:6: compilation error. cannot assign to constant answer declared at 1:8",
+		"const int answer :: 42\nif true {\n    answer--\n}": "/3:1:6: compilation error. cannot assign to constant answer declared at 1:8",
+	} {
+		err := i.Interpret(code, vm.New())
+		assert.EqualError(t, err, message, code)
+	}
+}
+
+func TestConstRedeclarationIsRejectedAtRuntime(t *testing.T) {
+	i := interpreter.NewInterpreter()
+	err := i.Interpret(`
+const int answer :: 42
+int answer :: 1
+`, vm.New())
+	assert.EqualError(t, err, "/3:1:3: runtime error. cannot redeclare constant answer declared at 2:9")
+}
+
+func TestConstAssignmentIsRejectedAtRuntime(t *testing.T) {
+	i := interpreter.NewInterpreter()
+	err := i.Interpret(`
+const int answer :: 42
+auto change :: func() void {
+    answer :: 1
+}
+change()
+`, vm.New())
+	assert.EqualError(t, err, "/4:1:6: runtime error. cannot assign to constant answer declared at 2:9")
+}
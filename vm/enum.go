@@ -0,0 +1,100 @@
+package vm
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/dani-gouken/nomad/runtime/data"
+	"github.com/dani-gouken/nomad/runtime/types"
+)
+
+// declaredEnumType returns the enum type being declared, on top of the stack.
+func (vm *Vm) declaredEnumType() (*types.EnumType, error) {
+	value, err := vm.stack().Current()
+	if err != nil {
+		return nil, err
+	}
+	err = types.ExpectedTypeType(value.RuntimeType)
+	if err != nil {
+		return nil, err
+	}
+	return types.ToEnumType(value.Value.(types.RuntimeType))
+}
+
+// loadVariant returns the value of a variant without payload, or the
+// function building the variant from its payload.
+func (vm *Vm) loadVariant(enumType *types.EnumType, name string) (data.RuntimeValue, error) {
+	variant, err := enumType.GetVariant(name)
+	if err != nil {
+		return data.RuntimeValue{}, err
+	}
+	if len(variant.Fields) == 0 {
+		return data.RuntimeValue{
+			RuntimeType: enumType,
+			Value:       data.NewRuntimeEnum(name, nil),
+		}, nil
+	}
+	signature := data.NewFuncSignature(enumType)
+	for _, field := range variant.Fields {
+		err := signature.AddParam(field.Name, field.Type, data.RuntimeValue{})
+		if err != nil {
+			return data.RuntimeValue{}, err
+		}
+	}
+	constructor := data.NewNativeFunc(enumType.GetName()+"#"+name, signature, func(args []data.RuntimeValue) (data.RuntimeValue, error) {
+		return data.RuntimeValue{
+			RuntimeType: enumType,
+			Value:       data.NewRuntimeEnum(name, args),
+		}, nil
+	})
+	return data.RuntimeValue{
+		RuntimeType: constructor.Signature.AsType(),
+		Value:       constructor,
+	}, nil
+}
+
+// matchSubject returns the value being matched, which stays on the stack
+// until an arm is selected.
+func (vm *Vm) matchSubject() (*types.EnumType, *data.RuntimeEnum, error) {
+	subject, err := vm.stack().Current()
+	if err != nil {
+		return nil, nil, err
+	}
+	enumType, err := types.ToEnumType(subject.RuntimeType)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot match value of type %s, enum expected", subject.RuntimeType.GetName())
+	}
+	return enumType, subject.Value.(*data.RuntimeEnum), nil
+}
+
+func (vm *Vm) isVariant(name string) (bool, error) {
+	enumType, value, err := vm.matchSubject()
+	if err != nil {
+		return false, err
+	}
+	_, err = enumType.GetVariant(name)
+	if err != nil {
+		return false, err
+	}
+	return value.Variant == name, nil
+}
+
+// bindVariantField declares a variable holding a field of the payload of the matched variant.
+func (vm *Vm) bindVariantField(name string, index string) error {
+	enumType, value, err := vm.matchSubject()
+	if err != nil {
+		return err
+	}
+	i, err := strconv.Atoi(index)
+	if err != nil {
+		return err
+	}
+	variant, err := enumType.GetVariant(value.Variant)
+	if err != nil {
+		return err
+	}
+	if i >= len(variant.Fields) {
+		return fmt.Errorf("too many bindings, variant %s has %d fields", variant.Name, len(variant.Fields))
+	}
+	return vm.Env().DeclareVariable(name, &value.Values[i], variant.Fields[i].Type)
+}
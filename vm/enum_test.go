@@ -0,0 +1,66 @@
+package vm_test
+
+import (
+	"testing"
+
+	"github.com/dani-gouken/nomad/compiler"
+	"github.com/dani-gouken/nomad/parser"
+	"github.com/dani-gouken/nomad/tokenizer"
+	"github.com/dani-gouken/nomad/vm"
+	"github.com/stretchr/testify/assert"
+)
+
+const shapes = `
+enum Shape :: {
+    Circle(float radius)
+    Rect(float width, float height)
+    Empty
+}
+`
+
+func TestEnumMatch(t *testing.T) {
+	instance := interpret(t, vm.New(), shapes+`
+auto area :: func(Shape shape) float {
+    match shape {
+        Circle(r) { return 3.0 * r * r }
+        Rect(w, h) { return w * h }
+        Empty { return 0.0 }
+    }
+}
+auto circle :: area(Shape#Circle(2.0))
+auto rect :: area(Shape#Rect(height: 3.0, width: 2.0))
+auto empty :: area(Shape#Empty)
+auto same :: Shape#Rect(1.0, 2.0) = Shape#Rect(1.0, 2.0)
+auto different :: Shape#Rect(1.0, 2.0) = Shape#Circle(1.0)
+string name :: ""
+match Shape#Empty {
+    Circle { name :: "circle" }
+    else { name :: "other" }
+}
+`)
+
+	assertGlobals(t, instance, map[string]interface{}{
+		"circle":    12.0,
+		"rect":      6.0,
+		"empty":     0.0,
+		"same":      true,
+		"different": false,
+		"name":      "other",
+	})
+}
+
+func TestMatchWithoutArmIsARuntimeError(t *testing.T) {
+	// the checker rejects this program, the vm should still fail cleanly
+	tokens, err := tokenizer.Tokenize(shapes + `
+match Shape#Empty {
+    Circle(r) { print r }
+}
+`)
+	assert.NoError(t, err)
+	program, err := parser.Parse(tokens)
+	assert.NoError(t, err)
+	instructions, err := compiler.Compile(program.Stmts)
+	assert.NoError(t, err)
+	err = vm.New().Interpret(instructions)
+	assert.ErrorContains(t, err, "no arm matches variant Empty of Shape")
+}
@@ -5,12 +5,15 @@ import (
 
 	"github.com/dani-gouken/nomad/runtime/data"
 	"github.com/dani-gouken/nomad/runtime/types"
+	"github.com/dani-gouken/nomad/tokenizer"
 )
 
 const ROOT_SCOPE = 0
 
 type Scope struct {
 	variables map[string]*data.RuntimeValue
+	// constants maps the name of the constants to their declaration
+	constants map[string]tokenizer.Token
 	parent    int
 	id        int
 }
@@ -24,8 +27,10 @@ type Environment struct {
 func (e *Environment) PushScope() Scope {
 	nextScopeId := e.scopeCounter + 1
 	scope := Scope{
-		parent: e.currentScope,
-		id:     nextScopeId,
+		variables: map[string]*data.RuntimeValue{},
+		constants: map[string]tokenizer.Token{},
+		parent:    e.currentScope,
+		id:        nextScopeId,
 	}
 	e.scopes[nextScopeId] = scope
 	return scope
@@ -58,18 +63,46 @@ func (e *Environment) PopScope() error {
 	return nil
 }
 
+// popScopesTo pops the scopes pushed after the scope with the given id.
+func (e *Environment) popScopesTo(id int) {
+	for e.currentScope != id {
+		if e.PopScope() != nil {
+			return
+		}
+	}
+}
+
 func (s *Scope) DeclareVariable(name string, runtimeValue *data.RuntimeValue, declaredType types.RuntimeType) error {
-	err := declaredType.Match(runtimeValue.RuntimeType)
+	declaration, ok := s.constants[name]
+	if ok {
+		return fmt.Errorf("cannot redeclare constant %s declared at %d:%d", name, declaration.Loc.Line, declaration.Loc.Start)
+	}
+	value := data.Promote(*runtimeValue, declaredType)
+	err := declaredType.Match(value.RuntimeType)
 	if err != nil {
 		return fmt.Errorf("type mismatch, could not assign value of type %s to the variable %s declared as %s", runtimeValue.RuntimeType.GetName(), name, declaredType.GetName())
 	}
 	s.variables[name] = &data.RuntimeValue{
 		RuntimeType: declaredType,
-		Value:       runtimeValue.Value,
+		Value:       value.Value,
 	}
 	return nil
 }
 
+func (s *Scope) DeclareConstant(name string, runtimeValue *data.RuntimeValue, declaredType types.RuntimeType, declaration tokenizer.Token) error {
+	err := s.DeclareVariable(name, runtimeValue, declaredType)
+	if err != nil {
+		return err
+	}
+	s.constants[name] = declaration
+	return nil
+}
+
+func (s *Scope) GetConstant(name string) (tokenizer.Token, bool) {
+	declaration, ok := s.constants[name]
+	return declaration, ok
+}
+
 func (s *Scope) UnsetVariable(name string) {
 	delete(s.variables, name)
 }
@@ -94,6 +127,28 @@ func (e *Environment) DeclareVariable(
 	return scope.DeclareVariable(name, runtimeValue, declaredType)
 }
 
+func (e *Environment) DeclareConstant(
+	name string,
+	runtimeValue *data.RuntimeValue,
+	declaredType types.RuntimeType,
+	declaration tokenizer.Token,
+) error {
+	scope, err := e.GetCurrentScope()
+	if err != nil {
+		return err
+	}
+	return scope.DeclareConstant(name, runtimeValue, declaredType, declaration)
+}
+
+// GetConstant returns the declaration of the constant name, if name is one.
+func (e *Environment) GetConstant(name string) (tokenizer.Token, bool) {
+	scope, err := e.GetCurrentScope()
+	if err != nil {
+		return tokenizer.Token{}, false
+	}
+	return scope.GetConstant(name)
+}
+
 func (e *Environment) UnsetVariable(name string) error {
 	scope, err := e.GetCurrentScope()
 	if err != nil {
@@ -111,10 +166,20 @@ func (e *Environment) GetVariable(name string) (*data.RuntimeValue, error) {
 	return scope.GetVariable(name)
 }
 
+// Variables returns the variables declared in the current scope.
+func (e *Environment) Variables() map[string]*data.RuntimeValue {
+	scope, err := e.GetCurrentScope()
+	if err != nil {
+		return map[string]*data.RuntimeValue{}
+	}
+	return scope.variables
+}
+
 func NewEnvironment() Environment {
 	scopes := make(map[int]Scope)
 	scopes[ROOT_SCOPE] = Scope{
 		variables: map[string]*data.RuntimeValue{},
+		constants: map[string]tokenizer.Token{},
 	}
 	return Environment{
 		currentScope: ROOT_SCOPE,
@@ -0,0 +1,181 @@
+package vm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/dani-gouken/nomad/runtime/data"
+	"github.com/dani-gouken/nomad/runtime/types"
+	"github.com/dani-gouken/nomad/tokenizer"
+)
+
+// FsPermission is the access to the file system granted to the scripts
+// through the fs module.
+type FsPermission int
+
+const (
+	FS_PERMISSION_NONE FsPermission = iota
+	FS_PERMISSION_READ
+	FS_PERMISSION_READ_WRITE
+)
+
+const (
+	FS_READ_RESULT_TYPE = "fs.ReadResult"
+	FS_LIST_RESULT_TYPE = "fs.ListResult"
+)
+
+// SetFsPermission grants the scripts access to the file system through
+// the fs module, they have no access by default.
+func (vm *Vm) SetFsPermission(permission FsPermission) {
+	vm.fsPermission = permission
+}
+
+func (vm *Vm) checkFsPermission(required FsPermission, path string) error {
+	if vm.fsPermission >= required {
+		return nil
+	}
+	access := "read"
+	if required == FS_PERMISSION_READ_WRITE {
+		access = "write"
+	}
+	return fmt.Errorf("%s access to %s denied", access, path)
+}
+
+// registerFsModule declares the fs module. Failures are returned to the
+// scripts as Error values instead of stopping them, except for exists which
+// raises the permission error.
+func (vm *Vm) registerFsModule() error {
+	stringType := vm.types.GetOrPanic(types.STRING_TYPE)
+	boolType := vm.types.GetOrPanic(types.BOOL_TYPE)
+	stringsType := types.NewArrayType(stringType)
+	optionalErrorType, err := types.NewOptionalType(vm.types.GetOrPanic(types.ERROR_TYPE))
+	if err != nil {
+		return err
+	}
+	noError := data.RuntimeValue{RuntimeType: optionalErrorType}
+	errorResult := func(err error) data.RuntimeValue {
+		if err == nil {
+			return noError
+		}
+		return data.RuntimeValue{RuntimeType: optionalErrorType, Value: vm.errorValue(err).Value}
+	}
+	str := func(value string) data.RuntimeValue {
+		return data.RuntimeValue{RuntimeType: stringType, Value: value}
+	}
+
+	readResultType := types.NewObjectType()
+	readResultType.AddField("content", stringType, str(""))
+	readResultType.AddField("error", optionalErrorType, noError)
+	readResultType.SetName(FS_READ_RESULT_TYPE)
+
+	listResultType := types.NewObjectType()
+	listResultType.AddField("entries", stringsType, data.RuntimeValue{RuntimeType: stringsType, Value: data.RuntimeArray{}})
+	listResultType.AddField("error", optionalErrorType, noError)
+	listResultType.SetName(FS_LIST_RESULT_TYPE)
+
+	for _, t := range []types.RuntimeType{readResultType, listResultType} {
+		err := vm.types.Add(t, tokenizer.Token{})
+		if err != nil {
+			return err
+		}
+	}
+
+	signature := func(ret types.RuntimeType, params ...string) data.FuncSignature {
+		s := data.NewFuncSignature(ret)
+		for _, param := range params {
+			s.AddParam(param, stringType, data.RuntimeValue{})
+		}
+		return s
+	}
+	members := map[string]data.RuntimeValue{}
+
+	members["read"] = vm.nativeFunc("fs.read", signature(readResultType, "path"), func(args []data.RuntimeValue) (data.RuntimeValue, error) {
+		path := args[0].Value.(string)
+		result := data.NewRuntimeObject()
+		content, err := []byte{}, vm.checkFsPermission(FS_PERMISSION_READ, path)
+		if err == nil {
+			content, err = os.ReadFile(path)
+		}
+		result.SetField("content", str(string(content)))
+		result.SetField("error", errorResult(err))
+		return data.RuntimeValue{RuntimeType: readResultType, Value: result}, nil
+	})
+
+	members["list"] = vm.nativeFunc("fs.list", signature(listResultType, "path"), func(args []data.RuntimeValue) (data.RuntimeValue, error) {
+		path := args[0].Value.(string)
+		result := data.NewRuntimeObject()
+		names := []data.RuntimeValue{}
+		err := vm.checkFsPermission(FS_PERMISSION_READ, path)
+		if err == nil {
+			var entries []os.DirEntry
+			entries, err = os.ReadDir(path)
+			for _, entry := range entries {
+				names = append(names, str(entry.Name()))
+			}
+		}
+		sort.Slice(names, func(i, j int) bool {
+			return names[i].Value.(string) < names[j].Value.(string)
+		})
+		result.SetField("entries", data.RuntimeValue{RuntimeType: stringsType, Value: data.RuntimeArray{Values: names}})
+		result.SetField("error", errorResult(err))
+		return data.RuntimeValue{RuntimeType: listResultType, Value: result}, nil
+	})
+
+	members["exists"] = vm.nativeFunc("fs.exists", signature(boolType, "path"), func(args []data.RuntimeValue) (data.RuntimeValue, error) {
+		path := args[0].Value.(string)
+		err := vm.checkFsPermission(FS_PERMISSION_READ, path)
+		if err != nil {
+			return data.RuntimeValue{}, err
+		}
+		_, err = os.Stat(path)
+		return data.RuntimeValue{RuntimeType: boolType, Value: err == nil}, nil
+	})
+
+	write := func(name string, params []string, fn func(args []string) error) {
+		members[name] = vm.nativeFunc("fs."+name, signature(optionalErrorType, params...), func(args []data.RuntimeValue) (data.RuntimeValue, error) {
+			values := make([]string, 0, len(args))
+			for _, arg := range args {
+				values = append(values, arg.Value.(string))
+			}
+			err := vm.checkFsPermission(FS_PERMISSION_READ_WRITE, values[0])
+			if err == nil {
+				err = fn(values)
+			}
+			return errorResult(err), nil
+		})
+	}
+	write("write", []string{"path", "content"}, func(args []string) error {
+		return os.WriteFile(args[0], []byte(args[1]), 0644)
+	})
+	write("append", []string{"path", "content"}, func(args []string) error {
+		file, err := os.OpenFile(args[0], os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		_, err = file.WriteString(args[1])
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		return err
+	})
+	write("mkdir", []string{"path"}, func(args []string) error {
+		return os.MkdirAll(args[0], 0755)
+	})
+	write("remove", []string{"path"}, func(args []string) error {
+		return os.Remove(args[0])
+	})
+
+	join := data.NewFuncSignature(stringType)
+	join.AddParam("parts", stringsType, data.RuntimeValue{})
+	members["join"] = vm.nativeFunc("fs.join", join, func(args []data.RuntimeValue) (data.RuntimeValue, error) {
+		parts := []string{}
+		for _, part := range args[0].Value.(data.RuntimeArray).Values {
+			parts = append(parts, part.Value.(string))
+		}
+		return str(filepath.Join(parts...)), nil
+	})
+
+	return vm.RegisterModule("fs", members)
+}
@@ -0,0 +1,94 @@
+package vm_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dani-gouken/nomad/runtime/data"
+	"github.com/dani-gouken/nomad/vm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFsModule(t *testing.T) {
+	dir := t.TempDir()
+	instance := vm.New()
+	instance.SetFsPermission(vm.FS_PERMISSION_READ_WRITE)
+	interpret(t, instance, fmt.Sprintf(`
+import "fs"
+auto dir :: fs.join([string]{%q, "data"})
+auto path :: fs.join([string]{dir, "notes.txt"})
+auto mkdir :: Some(fs.mkdir(dir))
+auto write :: Some(fs.write(path, "hello"))
+auto append :: Some(fs.append(path, " world"))
+auto exists :: fs.exists(path)
+auto file :: fs.read(path)
+auto content :: file.content
+auto read_failed :: Some(file.error)
+auto entries :: fs.list(dir).entries
+auto missing :: fs.read(fs.join([string]{dir, "missing.txt"}))
+auto missing_failed :: Some(missing.error)
+auto remove_dir :: Some(fs.remove(dir))
+auto remove :: Some(fs.remove(path))
+auto removed :: fs.exists(path)
+`, dir))
+
+	assertGlobals(t, instance, map[string]interface{}{
+		"path":           filepath.Join(dir, "data", "notes.txt"),
+		"mkdir":          false,
+		"write":          false,
+		"append":         false,
+		"exists":         true,
+		"content":        "hello world",
+		"read_failed":    false,
+		"missing_failed": true,
+		"remove_dir":     true,
+		"remove":         false,
+		"removed":        false,
+	})
+	entries, err := instance.GetGlobal("entries")
+	assert.NoError(t, err)
+	assert.Equal(t, "[notes.txt]", data.ToString(entries))
+}
+
+func TestFsPermission(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notes.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("hello"), 0644))
+
+	instance := vm.New()
+	instance.SetFsPermission(vm.FS_PERMISSION_READ)
+	interpret(t, instance, fmt.Sprintf(`
+import "fs"
+auto content :: fs.read(%q).content
+?Error err :: fs.write(%q, "overwritten")
+auto message :: (unwrap err).message
+`, path, path))
+	content, _ := instance.GetGlobal("content")
+	assert.Equal(t, "hello", content.Value)
+	message, _ := instance.GetGlobal("message")
+	assert.Equal(t, fmt.Sprintf("write access to %s denied", path), message.Value)
+
+	instance = interpret(t, vm.New(), fmt.Sprintf(`
+import "fs"
+auto exists :: func(string path) string {
+    try {
+        fs.exists(path)
+    } catch e {
+        return e.message
+    }
+    return "allowed"
+}
+auto denied :: exists(%q)
+auto failed :: Some(fs.read(%q).error)
+`, path, path))
+	assertGlobals(t, instance, map[string]interface{}{
+		"denied": fmt.Sprintf("fs.exists: read access to %s denied", path),
+		"failed": true,
+	})
+
+	content2, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(content2))
+}
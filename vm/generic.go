@@ -0,0 +1,96 @@
+package vm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dani-gouken/nomad/runtime/data"
+	"github.com/dani-gouken/nomad/runtime/types"
+)
+
+// declareTypeParams makes f generic. Its type parameters stay in scope
+// until endTypeParams is called, once its signature is built.
+func (vm *Vm) declareTypeParams(fn *data.RuntimeValue, names string) (data.RuntimeValue, error) {
+	f, ok := fn.Value.(*data.RuntimeFunc)
+	if !ok {
+		return data.RuntimeValue{}, fmt.Errorf("function expected, got %s", fn.RuntimeType.GetName())
+	}
+	typeParams := []*types.TypeVar{}
+	for _, name := range strings.Split(names, ",") {
+		typeParams = append(typeParams, types.NewTypeVar(name))
+	}
+	f.Signature.TypeParams = typeParams
+	vm.typeParams = append(vm.typeParams, typeParams)
+	return data.RuntimeValue{
+		RuntimeType: f.Signature.AsType(),
+		Value:       f,
+	}, nil
+}
+
+func (vm *Vm) endTypeParams() {
+	vm.typeParams = vm.typeParams[:len(vm.typeParams)-1]
+}
+
+// lookupTypeParam resolves the type parameters of the generic function
+// being declared, then the types bound to the functions being run.
+func (vm *Vm) lookupTypeParam(name string) (types.RuntimeType, bool) {
+	for i := len(vm.typeParams) - 1; i >= 0; i-- {
+		for _, typeParam := range vm.typeParams[i] {
+			if typeParam.GetName() == name {
+				return typeParam, true
+			}
+		}
+	}
+	frame, err := vm.callStack.Current()
+	if err != nil {
+		return nil, false
+	}
+	for ; frame != nil; frame = frame.Parent {
+		if frame.CurrentFunc == nil {
+			continue
+		}
+		t, ok := frame.CurrentFunc.TypeArgs[name]
+		if ok {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// instantiate binds the type parameters of a generic function, like in max[int].
+func (vm *Vm) instantiate(fn data.RuntimeValue, typeArgs []data.RuntimeValue) (data.RuntimeValue, error) {
+	f, ok := fn.Value.(*data.RuntimeFunc)
+	if !ok || !f.IsGeneric() {
+		return data.RuntimeValue{}, fmt.Errorf("cannot instantiate value of type %s, generic function expected", fn.RuntimeType.GetName())
+	}
+	argTypes := []types.RuntimeType{}
+	for _, typeArg := range typeArgs {
+		err := types.ExpectedTypeType(typeArg.RuntimeType)
+		if err != nil {
+			return data.RuntimeValue{}, fmt.Errorf("type argument expected, got %s", typeArg.RuntimeType.GetName())
+		}
+		argTypes = append(argTypes, typeArg.Value.(types.RuntimeType))
+	}
+	instance, err := f.InstantiateWith(argTypes)
+	if err != nil {
+		return data.RuntimeValue{}, err
+	}
+	return data.RuntimeValue{
+		RuntimeType: instance.Signature.AsType(),
+		Value:       instance,
+	}, nil
+}
+
+// inferTypeArgs instantiates a generic function from the types of the
+// arguments bound to its parameters.
+func inferTypeArgs(f *data.RuntimeFunc, args []data.RuntimeValue) (*data.RuntimeFunc, error) {
+	bindings := types.Bindings{}
+	for i, param := range f.Signature.Parameters {
+		if args[i].RuntimeType == nil {
+			continue
+		}
+		// mismatches are reported once the parameters are instantiated
+		types.Unify(param.RuntimeType, args[i].RuntimeType, bindings)
+	}
+	return f.Instantiate(bindings)
+}
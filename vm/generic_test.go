@@ -0,0 +1,114 @@
+package vm_test
+
+import (
+	"testing"
+
+	"github.com/dani-gouken/nomad/interpreter"
+	"github.com/dani-gouken/nomad/runtime/data"
+	"github.com/dani-gouken/nomad/vm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenericFunctions(t *testing.T) {
+	instance := interpret(t, vm.New(), `
+auto max :: func[T](T a, T b) T {
+    if a > b {
+        return a
+    }
+    return b
+}
+auto first :: func[T]([T] items, T fallback) T {
+    for item in items {
+        return item
+    }
+    return fallback
+}
+auto pair :: func[K, V](K key, V value) {K: V} {
+    return {K: V}{key: value}
+}
+auto wrap :: func[T](T value) [T] {
+    auto items :: [T]{value}
+    return items
+}
+auto apply :: func(func(int) -> int f, int x) int {
+    return f(x)
+}
+auto identity :: func[T](T x) T {
+    return x
+}
+auto counter :: func[T](T start) func() -> T {
+    return func() T {
+        T value :: start
+        return value
+    }
+}
+
+auto max_int :: max(1, 2)
+auto max_mixed :: max(3, 2.5)
+auto max_explicit :: max[float](1, 2)
+auto first_string :: first([string]{}, "none")
+auto pairs :: pair[string, [int]]("a", [int]{1})
+auto wrapped :: wrap("x")
+auto applied :: apply(identity, 3)
+auto next :: counter("start")
+auto closure :: next()
+`)
+
+	assertGlobals(t, instance, map[string]interface{}{
+		"max_int":      int64(2),
+		"max_mixed":    3.0,
+		"max_explicit": 2.0,
+		"first_string": "none",
+		"applied":      int64(3),
+		"closure":      "start",
+	})
+	pairs, _ := instance.GetGlobal("pairs")
+	assert.Equal(t, "{string: [int]}", pairs.RuntimeType.GetName())
+	wrapped, _ := instance.GetGlobal("wrapped")
+	assert.Equal(t, "[string]", wrapped.RuntimeType.GetName())
+	assert.Equal(t, "[x]", data.ToString(wrapped))
+	identity, _ := instance.GetGlobal("identity")
+	assert.Equal(t, "func[T](T) -> (T)", identity.RuntimeType.GetName())
+}
+
+func TestGenericFunctionErrors(t *testing.T) {
+	code := `
+auto max :: func[T](T a, T b) T {
+    if a > b {
+        return a
+    }
+    return b
+}
+auto empty :: func[T]() [T] {
+    return [T]{}
+}
+`
+	cases := map[string]string{
+		`auto a :: max[int, int](1, 2)`: "expected 1 type arguments, got 2",
+		`auto a :: empty()`:             "cannot infer type parameter T",
+		`auto a :: max(1, "a")`:         "type mismatch for parameter b, expected int, got string",
+	}
+	i := interpreter.NewInterpreter()
+	for source, message := range cases {
+		err := i.Interpret(code+source, vm.New())
+		assert.ErrorContains(t, err, message, source)
+	}
+
+	instance := interpret(t, vm.New(), code+`auto a :: empty[int]()`)
+	a, _ := instance.GetGlobal("a")
+	assert.Equal(t, "[int]", a.RuntimeType.GetName())
+}
+
+func TestCallGenericFromGo(t *testing.T) {
+	instance := interpret(t, vm.New(), `
+auto identity :: func[T](T x) T {
+    return x
+}
+`)
+	identity, err := instance.GetGlobal("identity")
+	assert.NoError(t, err)
+	result, err := instance.Call(identity, data.RuntimeValue{RuntimeType: instance.Types().GetOrPanic("string"), Value: "go"})
+	assert.NoError(t, err)
+	assert.Equal(t, "go", result.Value)
+	assert.Equal(t, "string", result.RuntimeType.GetName())
+}
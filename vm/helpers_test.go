@@ -0,0 +1,29 @@
+package vm_test
+
+import (
+	"testing"
+
+	"github.com/dani-gouken/nomad/interpreter"
+	"github.com/dani-gouken/nomad/vm"
+	"github.com/stretchr/testify/assert"
+)
+
+// interpret runs code in instance and returns it.
+func interpret(t *testing.T, instance *vm.Vm, code string) *vm.Vm {
+	t.Helper()
+	i := interpreter.NewInterpreter()
+	err := i.Interpret(code, instance)
+	assert.NoError(t, err)
+	return instance
+}
+
+// assertGlobals asserts the values of the globals of instance, by name.
+func assertGlobals(t *testing.T, instance *vm.Vm, expected map[string]interface{}) {
+	t.Helper()
+	for name, value := range expected {
+		actual, err := instance.GetGlobal(name)
+		if assert.NoError(t, err, name) {
+			assert.Equal(t, value, actual.Value, name)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dani-gouken/nomad/runtime/data"
+	"github.com/dani-gouken/nomad/runtime/types"
+	"github.com/dani-gouken/nomad/tokenizer"
+)
+
+const (
+	HTTP_REQUEST_TYPE  = "http.Request"
+	HTTP_RESPONSE_TYPE = "http.Response"
+	HTTP_SERVER_TYPE   = "http.Server"
+)
+
+// HTTP_MAX_BODY_SIZE is the size in bytes of the largest request body the
+// servers accept, larger requests get a 413 response.
+const HTTP_MAX_BODY_SIZE = 1 << 20
+
+// httpModule holds the servers created by the scripts. Requests are served
+// concurrently by net/http, the lock makes them run one at a time in the vm.
+type httpModule struct {
+	vm      *Vm
+	lock    sync.Mutex
+	servers map[*data.RuntimeObject]*httpServer
+}
+
+type httpRoute struct {
+	method   string
+	segments []string
+	handler  data.RuntimeValue
+}
+
+// httpServer dispatches the requests it receives to the nomad handlers
+// registered on its routes.
+type httpServer struct {
+	module *httpModule
+	routes []httpRoute
+}
+
+// HTTPHandler returns the go handler of a server created with http.server(),
+// to serve it without calling listen.
+func (vm *Vm) HTTPHandler(server data.RuntimeValue) (http.Handler, error) {
+	object, ok := server.Value.(*data.RuntimeObject)
+	if !ok || vm.http == nil {
+		return nil, fmt.Errorf("%s expected", HTTP_SERVER_TYPE)
+	}
+	s, ok := vm.http.servers[object]
+	if !ok {
+		return nil, fmt.Errorf("%s expected", HTTP_SERVER_TYPE)
+	}
+	return s, nil
+}
+
+// registerHttpModule declares the http module, backed by the go net/http package.
+func (vm *Vm) registerHttpModule() error {
+	stringType := vm.types.GetOrPanic(types.STRING_TYPE)
+	intType := vm.types.GetOrPanic(types.INT_TYPE)
+	headersType, err := types.NewMapType(stringType, stringType)
+	if err != nil {
+		return err
+	}
+	emptyHeaders := data.RuntimeValue{RuntimeType: headersType, Value: data.NewRuntimeMap()}
+
+	requestType := types.NewObjectType()
+	requestType.AddField("method", stringType, data.RuntimeValue{RuntimeType: stringType, Value: ""})
+	requestType.AddField("path", stringType, data.RuntimeValue{RuntimeType: stringType, Value: ""})
+	requestType.AddField("params", headersType, emptyHeaders)
+	requestType.AddField("query", headersType, emptyHeaders)
+	requestType.AddField("headers", headersType, emptyHeaders)
+	requestType.AddField("body", stringType, data.RuntimeValue{RuntimeType: stringType, Value: ""})
+	requestType.SetName(HTTP_REQUEST_TYPE)
+
+	responseType := types.NewObjectType()
+	responseType.AddField("status", intType, data.RuntimeValue{RuntimeType: intType, Value: int64(http.StatusOK)})
+	responseType.AddField("body", stringType, data.RuntimeValue{RuntimeType: stringType, Value: ""})
+	responseType.AddField("headers", headersType, emptyHeaders)
+	responseType.SetName(HTTP_RESPONSE_TYPE)
+
+	handlerType := types.NewFuncType()
+	handlerType.AddParam(requestType)
+	handlerType.SetRet(responseType)
+
+	module := &httpModule{
+		vm:      vm,
+		servers: make(map[*data.RuntimeObject]*httpServer),
+	}
+	methodRoute := module.routeSignature(handlerType, false)
+	route := module.routeSignature(handlerType, true)
+	listen := module.listenSignature()
+	serverType := types.NewObjectType()
+	for _, method := range []string{"get", "post", "put", "delete"} {
+		serverType.AddField(method, methodRoute.AsType(), data.RuntimeValue{})
+	}
+	serverType.AddField("route", route.AsType(), data.RuntimeValue{})
+	serverType.AddField("listen", listen.AsType(), data.RuntimeValue{})
+	serverType.SetName(HTTP_SERVER_TYPE)
+
+	for _, t := range []types.RuntimeType{requestType, responseType, serverType} {
+		err := vm.types.Add(t, tokenizer.Token{})
+		if err != nil {
+			return err
+		}
+	}
+	vm.http = module
+
+	return vm.RegisterModule("http", map[string]data.RuntimeValue{
+		"server": vm.nativeFunc("http.server", data.NewFuncSignature(serverType), func(args []data.RuntimeValue) (data.RuntimeValue, error) {
+			return module.newServer(serverType, handlerType), nil
+		}),
+	})
+}
+
+func (m *httpModule) routeSignature(handlerType types.RuntimeType, withMethod bool) data.FuncSignature {
+	stringType := m.vm.types.GetOrPanic(types.STRING_TYPE)
+	signature := data.NewFuncSignature(m.vm.types.GetOrPanic(types.VOID_TYPE))
+	if withMethod {
+		signature.AddParam("method", stringType, data.RuntimeValue{})
+	}
+	signature.AddParam("path", stringType, data.RuntimeValue{})
+	signature.AddParam("handler", handlerType, data.RuntimeValue{})
+	return signature
+}
+
+func (m *httpModule) listenSignature() data.FuncSignature {
+	stringType := m.vm.types.GetOrPanic(types.STRING_TYPE)
+	signature := data.NewFuncSignature(m.vm.types.GetOrPanic(types.VOID_TYPE))
+	signature.AddParam("port", m.vm.types.GetOrPanic(types.INT_TYPE), data.RuntimeValue{})
+	signature.AddParam("host", stringType, data.RuntimeValue{RuntimeType: stringType, Value: ""})
+	return signature
+}
+
+// newServer creates a server object, its fields being functions bound to it.
+func (m *httpModule) newServer(serverType *types.ObjectType, handlerType types.RuntimeType) data.RuntimeValue {
+	server := &httpServer{module: m}
+	object := data.NewRuntimeObject()
+	void := data.RuntimeValue{RuntimeType: m.vm.types.GetOrPanic(types.VOID_TYPE)}
+	for _, name := range []string{"get", "post", "put", "delete"} {
+		method := strings.ToUpper(name)
+		object.SetField(name, m.vm.nativeFunc("http.Server."+name, m.routeSignature(handlerType, false), func(args []data.RuntimeValue) (data.RuntimeValue, error) {
+			server.addRoute(method, args[0].Value.(string), args[1])
+			return void, nil
+		}))
+	}
+	object.SetField("route", m.vm.nativeFunc("http.Server.route", m.routeSignature(handlerType, true), func(args []data.RuntimeValue) (data.RuntimeValue, error) {
+		server.addRoute(strings.ToUpper(args[0].Value.(string)), args[1].Value.(string), args[2])
+		return void, nil
+	}))
+	object.SetField("listen", m.vm.nativeFunc("http.Server.listen", m.listenSignature(), func(args []data.RuntimeValue) (data.RuntimeValue, error) {
+		address := net.JoinHostPort(args[1].Value.(string), strconv.FormatInt(args[0].Value.(int64), 10))
+		return void, http.ListenAndServe(address, server)
+	}))
+	m.servers[object] = server
+	return data.RuntimeValue{RuntimeType: serverType, Value: object}
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+func (s *httpServer) addRoute(method string, path string, handler data.RuntimeValue) {
+	s.routes = append(s.routes, httpRoute{
+		method:   method,
+		segments: splitPath(path),
+		handler:  handler,
+	})
+}
+
+// match returns the path params of the route when the path matches it,
+// :name segments matching any non empty segment.
+func (r httpRoute) match(segments []string) (map[string]string, bool) {
+	if len(segments) != len(r.segments) {
+		return nil, false
+	}
+	params := map[string]string{}
+	for i, segment := range r.segments {
+		if strings.HasPrefix(segment, ":") && segments[i] != "" {
+			params[segment[1:]] = segments[i]
+			continue
+		}
+		if segment != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+func (s *httpServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	segments := splitPath(r.URL.Path)
+	allowed := []string{}
+	for _, route := range s.routes {
+		params, ok := route.match(segments)
+		if !ok {
+			continue
+		}
+		if route.method != r.Method {
+			allowed = append(allowed, route.method)
+			continue
+		}
+		s.dispatch(w, r, route, params)
+		return
+	}
+	if len(allowed) > 0 {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	http.NotFound(w, r)
+}
+
+func (s *httpServer) dispatch(w http.ResponseWriter, r *http.Request, route httpRoute, params map[string]string) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, HTTP_MAX_BODY_SIZE))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	vm := s.module.vm
+	s.module.lock.Lock()
+	defer s.module.lock.Unlock()
+
+	request, err := vm.newRequest(r, params, string(body))
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+	result, err := vm.Call(route.handler, request)
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+	response, ok := result.Value.(*data.RuntimeObject)
+	if !ok {
+		internalError(w, r, fmt.Errorf("%s expected, got %s", HTTP_RESPONSE_TYPE, result.RuntimeType.GetName()))
+		return
+	}
+	status, _ := response.GetField("status")
+	code := status.Value.(int64)
+	if code < 100 || code > 999 {
+		internalError(w, r, fmt.Errorf("invalid response status %d", code))
+		return
+	}
+	headers, _ := response.GetField("headers")
+	if headersMap, ok := headers.Value.(*data.RuntimeMap); ok {
+		for _, key := range headersMap.Keys() {
+			value, _ := headersMap.Get(key)
+			w.Header().Set(key.Value.(string), value.Value.(string))
+		}
+	}
+	w.WriteHeader(int(code))
+	content, _ := response.GetField("body")
+	io.WriteString(w, content.Value.(string))
+}
+
+// internalError logs err on the server, the client only gets a generic
+// message.
+func internalError(w http.ResponseWriter, r *http.Request, err error) {
+	log.Printf("http: %s %s: %s", r.Method, r.URL.Path, err)
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
+// newRequest converts a go request to a nomad http.Request.
+func (vm *Vm) newRequest(r *http.Request, params map[string]string, body string) (data.RuntimeValue, error) {
+	requestType, err := vm.types.Get(HTTP_REQUEST_TYPE)
+	if err != nil {
+		return data.RuntimeValue{}, err
+	}
+	stringType := vm.types.GetOrPanic(types.STRING_TYPE)
+	headersType, _ := requestType.(*types.ObjectType).GetFieldType("headers")
+	str := func(value string) data.RuntimeValue {
+		return data.RuntimeValue{RuntimeType: stringType, Value: value}
+	}
+	stringMap := func(values map[string]string) data.RuntimeValue {
+		m := data.NewRuntimeMap()
+		for key, value := range values {
+			m.Set(str(key), str(value))
+		}
+		return data.RuntimeValue{RuntimeType: headersType, Value: m}
+	}
+	query := map[string]string{}
+	for key, values := range r.URL.Query() {
+		query[key] = values[0]
+	}
+	headers := map[string]string{}
+	for key, values := range r.Header {
+		headers[key] = strings.Join(values, ", ")
+	}
+
+	request := data.NewRuntimeObject()
+	request.SetField("method", str(r.Method))
+	request.SetField("path", str(r.URL.Path))
+	request.SetField("params", stringMap(params))
+	request.SetField("query", stringMap(query))
+	request.SetField("headers", stringMap(headers))
+	request.SetField("body", str(body))
+	return data.RuntimeValue{RuntimeType: requestType, Value: request}, nil
+}
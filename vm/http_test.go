@@ -0,0 +1,129 @@
+package vm_test
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/dani-gouken/nomad/interpreter"
+	"github.com/dani-gouken/nomad/runtime/data"
+	"github.com/dani-gouken/nomad/vm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHttpServer(t *testing.T) {
+	i := interpreter.NewInterpreter()
+	instance := vm.New()
+	err := i.Interpret(`
+import "http"
+auto app :: http.server()
+app.get("/ping", func(http.Request req) http.Response {
+    return new http.Response{ body :: "pong" }
+})
+app.get("/users/:id", func(http.Request req) http.Response {
+    return new http.Response{
+        status :: 201,
+        body :: "user {req.params["id"]}",
+        headers :: {string: string}{"X-User": req.params["id"]},
+    }
+})
+app.route("post", "/echo", func(http.Request req) http.Response {
+    return new http.Response{ body :: "{req.method} {req.path} {req.query["q"]} {req.headers["X-Test"]} {req.body}" }
+})
+app.delete("/fail", func(http.Request req) http.Response {
+    throw new Error{ message :: "handler failed" }
+})
+app.get("/status/:code", func(http.Request req) http.Response {
+    auto codes :: {string: int}{"0": 0, "42": 42, "1000": 1000}
+    return new http.Response{ status :: codes[req.params["code"]] }
+})
+`, instance)
+	assert.NoError(t, err)
+
+	app, err := instance.GetGlobal("app")
+	assert.NoError(t, err)
+	handler, err := instance.HTTPHandler(app)
+	assert.NoError(t, err)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	send := func(method string, path string, body string) (*http.Response, string) {
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		assert.NoError(t, err)
+		req.Header.Set("X-Test", "header")
+		res, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer res.Body.Close()
+		content, err := io.ReadAll(res.Body)
+		assert.NoError(t, err)
+		return res, string(content)
+	}
+
+	res, body := send("GET", "/ping", "")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "pong", body)
+
+	res, body = send("GET", "/users/42", "")
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.Equal(t, "user 42", body)
+	assert.Equal(t, "42", res.Header.Get("X-User"))
+
+	res, body = send("POST", "/echo?q=search", "payload")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "POST /echo search header payload", body)
+
+	// the body is checked on the handler, the client could not send it all
+	for size, status := range map[int]int{
+		vm.HTTP_MAX_BODY_SIZE:     http.StatusOK,
+		vm.HTTP_MAX_BODY_SIZE + 1: http.StatusRequestEntityTooLarge,
+	} {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("POST", "/echo?q=large", strings.NewReader(strings.Repeat("a", size)))
+		request.Header.Set("X-Test", "header")
+		handler.ServeHTTP(recorder, request)
+		assert.Equal(t, status, recorder.Code, size)
+	}
+
+	res, _ = send("GET", "/missing", "")
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	res, _ = send("POST", "/ping", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, res.StatusCode)
+	assert.Equal(t, "GET", res.Header.Get("Allow"))
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	res, body = send("DELETE", "/fail", "")
+	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	assert.Equal(t, "Internal Server Error\n", body)
+	assert.Contains(t, logs.String(), "DELETE /fail")
+	assert.Contains(t, logs.String(), "handler failed")
+
+	for _, code := range []string{"0", "42", "1000"} {
+		res, body = send("GET", "/status/"+code, "")
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode, code)
+		assert.Equal(t, "Internal Server Error\n", body, code)
+		assert.Contains(t, logs.String(), "invalid response status "+code)
+	}
+}
+
+func TestHttpServerErrors(t *testing.T) {
+	i := interpreter.NewInterpreter()
+	err := i.Interpret(`
+import "http"
+auto app :: http.server()
+app.get("/", func(string path) string { return path })
+`, vm.New())
+	assert.ErrorContains(t, err, "type mismatch for parameter 1, expected func(http.Request) -> (http.Response), got func(string) -> (string)")
+
+	instance := vm.New()
+	_, err = instance.HTTPHandler(data.RuntimeValue{})
+	assert.ErrorContains(t, err, "http.Server expected")
+}
@@ -0,0 +1,107 @@
+package vm
+
+import (
+	"fmt"
+
+	"github.com/dani-gouken/nomad/runtime/data"
+	"github.com/dani-gouken/nomad/runtime/types"
+)
+
+func toMap(value *data.RuntimeValue) (*data.RuntimeMap, *types.MapType, error) {
+	mapType, err := types.ToMapType(value.RuntimeType)
+	if err != nil {
+		return nil, nil, err
+	}
+	return value.Value.(*data.RuntimeMap), mapType, nil
+}
+
+func arrayIndex(array data.RuntimeArray, index *data.RuntimeValue) (int, error) {
+	err := types.ExpectedIntType(index.RuntimeType)
+	if err != nil {
+		return 0, fmt.Errorf("index should be an integer")
+	}
+	i := index.Value.(int64)
+	if i < 0 || i >= int64(len(array.Values)) {
+		return 0, fmt.Errorf("index %d out of range, array length is %d", i, len(array.Values))
+	}
+	return int(i), nil
+}
+
+// loadIndex returns the item of an array or the entry of a map.
+func loadIndex(container *data.RuntimeValue, index *data.RuntimeValue) (data.RuntimeValue, error) {
+	runtimeMap, mapType, err := toMap(container)
+	if err == nil {
+		err = mapType.GetKeyType().Match(index.RuntimeType)
+		if err != nil {
+			return data.RuntimeValue{}, fmt.Errorf("invalid key. %s", err.Error())
+		}
+		value, ok := runtimeMap.Get(*index)
+		if !ok {
+			return data.RuntimeValue{}, fmt.Errorf("key %v not found", index.Value)
+		}
+		return value, nil
+	}
+	if types.ExpectedStringType(container.RuntimeType) == nil {
+		return stringIndex(container, index)
+	}
+	if !types.IsArrayType(container.RuntimeType) {
+		return data.RuntimeValue{}, fmt.Errorf("cannot index value of type %s", container.RuntimeType.GetName())
+	}
+	array := container.Value.(data.RuntimeArray)
+	i, err := arrayIndex(array, index)
+	if err != nil {
+		return data.RuntimeValue{}, err
+	}
+	return array.Values[i], nil
+}
+
+// stringIndex returns the character of a string at index, as a string.
+func stringIndex(container *data.RuntimeValue, index *data.RuntimeValue) (data.RuntimeValue, error) {
+	err := types.ExpectedIntType(index.RuntimeType)
+	if err != nil {
+		return data.RuntimeValue{}, fmt.Errorf("index should be an integer")
+	}
+	runes := []rune(container.Value.(string))
+	i := index.Value.(int64)
+	if i < 0 || i >= int64(len(runes)) {
+		return data.RuntimeValue{}, fmt.Errorf("index %d out of range, string length is %d", i, len(runes))
+	}
+	return data.RuntimeValue{
+		RuntimeType: container.RuntimeType,
+		Value:       string(runes[i]),
+	}, nil
+}
+
+// storeIndex sets the item of an array or the entry of a map.
+func storeIndex(container *data.RuntimeValue, index *data.RuntimeValue, value *data.RuntimeValue) error {
+	runtimeMap, mapType, err := toMap(container)
+	if err == nil {
+		err = mapType.GetKeyType().Match(index.RuntimeType)
+		if err != nil {
+			return fmt.Errorf("invalid key. %s", err.Error())
+		}
+		*value = data.Promote(*value, mapType.GetValueType())
+		err = mapType.GetValueType().Match(value.RuntimeType)
+		if err != nil {
+			return fmt.Errorf("invalid value. %s", err.Error())
+		}
+		runtimeMap.Set(*index, *value)
+		return nil
+	}
+	arrayType, err := types.ToArrayType(container.RuntimeType)
+	if err != nil {
+		return fmt.Errorf("cannot index value of type %s", container.RuntimeType.GetName())
+	}
+	array := container.Value.(data.RuntimeArray)
+	i, err := arrayIndex(array, index)
+	if err != nil {
+		return err
+	}
+	*value = data.Promote(*value, arrayType.GetSubtype())
+	err = arrayType.MatchSubtype(value.RuntimeType)
+	if err != nil {
+		return fmt.Errorf("type mismatch, %s expected, %s given", arrayType.GetSubtype().GetName(), value.RuntimeType.GetName())
+	}
+	array.Values[i] = *value
+	return nil
+}
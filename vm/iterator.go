@@ -0,0 +1,104 @@
+package vm
+
+import (
+	"fmt"
+
+	"github.com/dani-gouken/nomad/runtime/data"
+	"github.com/dani-gouken/nomad/runtime/types"
+)
+
+// iterator walks the items of an array, the characters of a string or the
+// keys of a map, as they were when the loop started. A loop binding two
+// names over a map walks its values, the keys being bound as the index.
+type iterator struct {
+	items    []data.RuntimeValue
+	itemType types.RuntimeType
+	// keys holds the keys of the map walked by the iterator, the index of
+	// the other items being their position.
+	keys     []data.RuntimeValue
+	keyType  types.RuntimeType
+	position int
+}
+
+func (vm *Vm) newIterator(value data.RuntimeValue, indexed bool) (*iterator, error) {
+	runtimeMap, mapType, err := toMap(&value)
+	if err == nil && !indexed {
+		return &iterator{items: runtimeMap.Keys(), itemType: mapType.GetKeyType(), position: -1}, nil
+	}
+	if err == nil {
+		keys := runtimeMap.Keys()
+		values := make([]data.RuntimeValue, len(keys))
+		for i, key := range keys {
+			values[i], _ = runtimeMap.Get(key)
+		}
+		return &iterator{
+			items:    values,
+			itemType: mapType.GetValueType(),
+			keys:     keys,
+			keyType:  mapType.GetKeyType(),
+			position: -1,
+		}, nil
+	}
+	arrayType, err := types.ToArrayType(value.RuntimeType)
+	if err == nil {
+		return &iterator{items: value.Value.(data.RuntimeArray).Values, itemType: arrayType.GetSubtype(), position: -1}, nil
+	}
+	scalarType, err := types.ToScalarType(value.RuntimeType)
+	if err == nil && scalarType.IsString() {
+		items := []data.RuntimeValue{}
+		for _, char := range value.Value.(string) {
+			items = append(items, data.RuntimeValue{RuntimeType: value.RuntimeType, Value: string(char)})
+		}
+		return &iterator{items: items, itemType: value.RuntimeType, position: -1}, nil
+	}
+	return nil, fmt.Errorf("cannot iterate over value of type %s", value.RuntimeType.GetName())
+}
+
+// initIterator declares the variable holding the iterator over the value on
+// top of the stack, indexed being true when the loop binds an index.
+func (vm *Vm) initIterator(name string, indexed bool) error {
+	value, err := vm.stack().Pop()
+	if err != nil {
+		return err
+	}
+	it, err := vm.newIterator(*value, indexed)
+	if err != nil {
+		return err
+	}
+	return vm.Env().DeclareVariable(name, &data.RuntimeValue{
+		RuntimeType: value.RuntimeType,
+		Value:       it,
+	}, value.RuntimeType)
+}
+
+func (vm *Vm) getIterator(name string) (*iterator, error) {
+	variable, err := vm.Env().GetVariable(name)
+	if err != nil {
+		return nil, err
+	}
+	it, ok := variable.Value.(*iterator)
+	if !ok {
+		return nil, fmt.Errorf("variable %s is not an iterator", name)
+	}
+	return it, nil
+}
+
+// next moves to the following item, reporting whether there is one.
+func (it *iterator) next() bool {
+	it.position++
+	return it.position < len(it.items)
+}
+
+func (it *iterator) current() *data.RuntimeValue {
+	return &it.items[it.position]
+}
+
+// index returns the index of the current item: its key when walking a map,
+// its position otherwise.
+func (it *iterator) index(reg types.Registrar) (*data.RuntimeValue, types.RuntimeType) {
+	if it.keys != nil {
+		return &it.keys[it.position], it.keyType
+	}
+	intType := reg.GetOrPanic(types.INT_TYPE)
+	return &data.RuntimeValue{RuntimeType: intType, Value: int64(it.position)}, intType
+}
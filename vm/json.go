@@ -0,0 +1,297 @@
+package vm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/dani-gouken/nomad/runtime/data"
+	"github.com/dani-gouken/nomad/runtime/types"
+)
+
+// jsonValueType accepts the values of any type, json.encode and json.decode
+// checking them while walking them.
+type jsonValueType struct{}
+
+func (t *jsonValueType) GetName() string {
+	return "json.Value"
+}
+
+func (t *jsonValueType) Match(t2 types.RuntimeType) error {
+	return nil
+}
+
+func (t *jsonValueType) IsDynamic() bool {
+	return true
+}
+
+// registerJsonModule declares the json module, converting values from and to
+// json text.
+func (vm *Vm) registerJsonModule() error {
+	stringType := vm.types.GetOrPanic(types.STRING_TYPE)
+	valueType := &jsonValueType{}
+
+	encode := data.NewFuncSignature(stringType)
+	encode.AddParam("value", valueType, data.RuntimeValue{})
+	encode.AddParam("indent", stringType, data.RuntimeValue{RuntimeType: stringType, Value: ""})
+
+	decode := data.NewFuncSignature(valueType)
+	decode.AddParam("text", stringType, data.RuntimeValue{})
+	decode.AddParam("type", vm.types.GetOrPanic(types.TYPE_TYPE), data.RuntimeValue{})
+
+	return vm.RegisterModule("json", map[string]data.RuntimeValue{
+		"encode": vm.nativeFunc("json.encode", encode, func(args []data.RuntimeValue) (data.RuntimeValue, error) {
+			text, err := EncodeJson(args[0], args[1].Value.(string))
+			if err != nil {
+				return data.RuntimeValue{}, err
+			}
+			return data.RuntimeValue{RuntimeType: stringType, Value: text}, nil
+		}),
+		"decode": vm.nativeFunc("json.decode", decode, func(args []data.RuntimeValue) (data.RuntimeValue, error) {
+			return vm.DecodeJson(args[0].Value.(string), args[1].Value.(types.RuntimeType))
+		}),
+	})
+}
+
+// EncodeJson converts a value to json text. Object fields and map keys are
+// sorted, none is encoded as null.
+func EncodeJson(value data.RuntimeValue, indent string) (string, error) {
+	tree, err := jsonTree(value, "")
+	if err != nil {
+		return "", err
+	}
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", indent)
+	err = encoder.Encode(tree)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buffer.String(), "\n"), nil
+}
+
+func jsonPathError(path string, format string, args ...interface{}) error {
+	message := fmt.Sprintf(format, args...)
+	if path == "" {
+		return fmt.Errorf("%s", message)
+	}
+	return fmt.Errorf("%s: %s", path, message)
+}
+
+func jsonFieldPath(path string, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+func jsonTree(value data.RuntimeValue, path string) (interface{}, error) {
+	switch v := value.Value.(type) {
+	case nil:
+		return nil, nil
+	case bool, int64, string:
+		return v, nil
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, jsonPathError(path, "cannot encode %v", v)
+		}
+		return v, nil
+	case data.RuntimeArray:
+		items := make([]interface{}, 0, len(v.Values))
+		for i, item := range v.Values {
+			encoded, err := jsonTree(item, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, encoded)
+		}
+		return items, nil
+	case *data.RuntimeMap:
+		entries := make(map[string]interface{}, v.Len())
+		for _, key := range v.Keys() {
+			item, _ := v.Get(key)
+			encoded, err := jsonTree(item, fmt.Sprintf("%s[%s]", path, strconv.Quote(data.ToString(key))))
+			if err != nil {
+				return nil, err
+			}
+			entries[data.ToString(key)] = encoded
+		}
+		return entries, nil
+	case *data.RuntimeObject:
+		fields := make(map[string]interface{}, len(v.GetFields()))
+		for name, field := range v.GetFields() {
+			encoded, err := jsonTree(*field, jsonFieldPath(path, name))
+			if err != nil {
+				return nil, err
+			}
+			fields[name] = encoded
+		}
+		return fields, nil
+	}
+	return nil, jsonPathError(path, "cannot encode value of type %s", value.RuntimeType.GetName())
+}
+
+// DecodeJson parses json text into a value of type t. The missing fields of
+// objects take their default value.
+func (vm *Vm) DecodeJson(text string, t types.RuntimeType) (data.RuntimeValue, error) {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	var tree interface{}
+	err := decoder.Decode(&tree)
+	if err != nil {
+		return data.RuntimeValue{}, fmt.Errorf("invalid json: %s", err.Error())
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return data.RuntimeValue{}, fmt.Errorf("invalid json: unexpected data after the value")
+	}
+	return vm.jsonValue(tree, t, "")
+}
+
+func jsonKind(tree interface{}) string {
+	switch tree.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	}
+	return "object"
+}
+
+func (vm *Vm) jsonValue(tree interface{}, t types.RuntimeType, path string) (data.RuntimeValue, error) {
+	mismatch := func() (data.RuntimeValue, error) {
+		return data.RuntimeValue{}, jsonPathError(path, "expected %s, got %s", t.GetName(), jsonKind(tree))
+	}
+	switch t := t.(type) {
+	case *types.OptionalType:
+		if tree == nil {
+			return data.RuntimeValue{RuntimeType: t}, nil
+		}
+		value, err := vm.jsonValue(tree, t.GetSubtype(), path)
+		if err != nil {
+			return data.RuntimeValue{}, err
+		}
+		return data.RuntimeValue{RuntimeType: t, Value: value.Value}, nil
+	case *types.ArrayType:
+		items, ok := tree.([]interface{})
+		if !ok {
+			return mismatch()
+		}
+		values := make([]data.RuntimeValue, 0, len(items))
+		for i, item := range items {
+			value, err := vm.jsonValue(item, t.GetSubtype(), fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return data.RuntimeValue{}, err
+			}
+			values = append(values, value)
+		}
+		return data.RuntimeValue{RuntimeType: t, Value: data.RuntimeArray{Values: values}}, nil
+	case *types.MapType:
+		entries, ok := tree.(map[string]interface{})
+		if !ok {
+			return mismatch()
+		}
+		m := data.NewRuntimeMap()
+		for key, item := range entries {
+			itemPath := fmt.Sprintf("%s[%s]", path, strconv.Quote(key))
+			keyValue, err := vm.jsonKey(key, t.GetKeyType(), itemPath)
+			if err != nil {
+				return data.RuntimeValue{}, err
+			}
+			value, err := vm.jsonValue(item, t.GetValueType(), itemPath)
+			if err != nil {
+				return data.RuntimeValue{}, err
+			}
+			m.Set(keyValue, value)
+		}
+		return data.RuntimeValue{RuntimeType: t, Value: m}, nil
+	case *types.ObjectType:
+		fields, ok := tree.(map[string]interface{})
+		if !ok {
+			return mismatch()
+		}
+		object := data.NewRuntimeObject()
+		for name, defaultValue := range t.GetDefaults() {
+			fieldType, _ := t.GetFieldType(name)
+			item, ok := fields[name]
+			if !ok {
+				value, _ := defaultValue.(data.RuntimeValue)
+				object.SetField(name, data.Copy(value))
+				continue
+			}
+			value, err := vm.jsonValue(item, fieldType, jsonFieldPath(path, name))
+			if err != nil {
+				return data.RuntimeValue{}, err
+			}
+			object.SetField(name, value)
+		}
+		return data.RuntimeValue{RuntimeType: t, Value: object}, nil
+	case *types.ScalarType:
+		return vm.jsonScalar(tree, t, path)
+	}
+	return data.RuntimeValue{}, jsonPathError(path, "cannot decode type %s", t.GetName())
+}
+
+func (vm *Vm) jsonScalar(tree interface{}, t *types.ScalarType, path string) (data.RuntimeValue, error) {
+	mismatch := jsonPathError(path, "expected %s, got %s", t.GetName(), jsonKind(tree))
+	switch tree := tree.(type) {
+	case bool:
+		if !t.IsBoolean() {
+			return data.RuntimeValue{}, mismatch
+		}
+		return data.RuntimeValue{RuntimeType: t, Value: tree}, nil
+	case string:
+		if !t.IsString() {
+			return data.RuntimeValue{}, mismatch
+		}
+		return data.RuntimeValue{RuntimeType: t, Value: tree}, nil
+	case json.Number:
+		if !t.IsNumeric() {
+			return data.RuntimeValue{}, mismatch
+		}
+		if !t.IsFloat() {
+			value, err := tree.Int64()
+			if err == nil {
+				return data.RuntimeValue{RuntimeType: vm.types.GetOrPanic(types.INT_TYPE), Value: value}, nil
+			}
+			if t.IsInt() {
+				return data.RuntimeValue{}, jsonPathError(path, "expected int, got %s", tree.String())
+			}
+		}
+		value, err := tree.Float64()
+		if err != nil {
+			return data.RuntimeValue{}, jsonPathError(path, "invalid number %s", tree.String())
+		}
+		return data.RuntimeValue{RuntimeType: vm.types.GetOrPanic(types.FLOAT_TYPE), Value: value}, nil
+	}
+	return data.RuntimeValue{}, mismatch
+}
+
+// jsonKey converts the key of a json object to the key type of a map.
+func (vm *Vm) jsonKey(key string, t types.RuntimeType, path string) (data.RuntimeValue, error) {
+	scalar, err := types.ToScalarType(t)
+	if err != nil {
+		return data.RuntimeValue{}, jsonPathError(path, "cannot decode key of type %s", t.GetName())
+	}
+	if scalar.IsString() {
+		return data.RuntimeValue{RuntimeType: t, Value: key}, nil
+	}
+	if scalar.IsBoolean() {
+		value, err := strconv.ParseBool(key)
+		if err != nil {
+			return data.RuntimeValue{}, jsonPathError(path, "invalid bool key")
+		}
+		return data.RuntimeValue{RuntimeType: t, Value: value}, nil
+	}
+	return vm.jsonScalar(json.Number(key), scalar, path)
+}
@@ -0,0 +1,130 @@
+package vm_test
+
+import (
+	"testing"
+
+	"github.com/dani-gouken/nomad/interpreter"
+	"github.com/dani-gouken/nomad/vm"
+	"github.com/stretchr/testify/assert"
+)
+
+const jsonTypes = `
+import "json"
+type Header :: {
+    string name :: ""
+    string value :: ""
+}
+type HttpResponse :: {
+    int status :: 200
+    float latency :: 0.0
+    string body :: ""
+    ?string etag :: none
+    [Header] headers :: [Header]{}
+    {string: int} counters :: {string: int}{}
+}
+`
+
+func TestJsonEncode(t *testing.T) {
+	i := interpreter.NewInterpreter()
+	instance := interpret(t, vm.New(), jsonTypes+`
+auto res :: new HttpResponse{
+    body :: "<b>hello</b>"
+    latency :: 1.5
+    headers :: [Header]{
+        new Header { name :: "Content-Type", value :: "text/html" }
+    }
+    counters :: {string: int}{"b": 2, "a": 1}
+}
+auto compact :: json.encode(res)
+auto indented :: json.encode([int]{1, 2}, indent: "  ")
+auto scalar :: json.encode("quote \" {1 + 1}")
+`)
+
+	compact, _ := instance.GetGlobal("compact")
+	assert.Equal(t, `{"body":"<b>hello</b>","counters":{"a":1,"b":2},"etag":null,"headers":[{"name":"Content-Type","value":"text/html"}],"latency":1.5,"status":200}`, compact.Value)
+	indented, _ := instance.GetGlobal("indented")
+	assert.Equal(t, "[\n  1,\n  2\n]", indented.Value)
+	scalar, _ := instance.GetGlobal("scalar")
+	assert.Equal(t, `"quote \\\" 2"`, scalar.Value)
+
+	err := i.Interpret(`
+import "math"
+import "json"
+auto nan :: json.encode([float]{1.0, math.NAN})
+`, vm.New())
+	assert.ErrorContains(t, err, "json.encode: [1]: cannot encode NaN")
+
+	err = i.Interpret(`
+import "json"
+auto f :: func() int { return 1 }
+auto encoded :: json.encode(f)
+`, vm.New())
+	assert.ErrorContains(t, err, "json.encode: cannot encode value of type")
+}
+
+func TestJsonDecode(t *testing.T) {
+	instance := interpret(t, vm.New(), jsonTypes+`
+HttpResponse res :: json.decode('\{
+    "status": 404,
+    "latency": 2,
+    "etag": "abc",
+    "headers": [\{"name": "Accept", "value": "*/*"}],
+    "counters": \{"hits": 3},
+    "unknown": true
+}', HttpResponse)
+HttpResponse empty :: json.decode('\{}', HttpResponse)
+auto status :: res.status
+auto latency :: res.latency
+auto etag :: unwrap res.etag
+auto header :: res.headers[0].value
+auto hits :: res.counters["hits"]
+auto default_status :: empty.status
+auto default_etag :: Some(empty.etag)
+auto encoded :: json.encode(res)
+auto roundtrip :: json.encode(json.decode(encoded, HttpResponse))
+`)
+
+	assertGlobals(t, instance, map[string]interface{}{
+		"status":         int64(404),
+		"latency":        2.0,
+		"etag":           "abc",
+		"header":         "*/*",
+		"hits":           int64(3),
+		"default_status": int64(200),
+		"default_etag":   false,
+	})
+	encoded, _ := instance.GetGlobal("encoded")
+	roundtrip, _ := instance.GetGlobal("roundtrip")
+	assert.Equal(t, encoded.Value, roundtrip.Value)
+}
+
+func TestJsonDecodeErrors(t *testing.T) {
+	cases := map[string]string{
+		`'\{"headers": [\{"name": "a"}, \{"name": 1}]}'`: "json.decode: headers[1].name: expected string, got number",
+		`'\{"status": 1.5}'`:                             "json.decode: status: expected int, got 1.5",
+		`'\{"counters": \{"hits": "3"}}'`:                `json.decode: counters["hits"]: expected int, got string`,
+		`'\{"headers": \{}}'`:                            "json.decode: headers: expected [Header], got object",
+		`'[]'`:                                           "json.decode: expected HttpResponse, got array",
+		`'\{"status": }'`:                                "json.decode: invalid json",
+		`'\{} \{}'`:                                      "json.decode: invalid json: unexpected data after the value",
+	}
+	i := interpreter.NewInterpreter()
+	for text, message := range cases {
+		err := i.Interpret(jsonTypes+`auto res :: json.decode(`+text+`, HttpResponse)`, vm.New())
+		assert.ErrorContains(t, err, message, text)
+	}
+
+	instance := interpret(t, vm.New(), jsonTypes+`
+auto parse :: func(string text) string {
+    try {
+        HttpResponse res :: json.decode(text, HttpResponse)
+    } catch e {
+        return e.message
+    }
+    return ""
+}
+auto message :: parse('\{"status": "ok"}')
+`)
+	message, _ := instance.GetGlobal("message")
+	assert.Equal(t, "json.decode: status: expected int, got string", message.Value)
+}
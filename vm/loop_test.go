@@ -0,0 +1,139 @@
+package vm_test
+
+import (
+	"testing"
+
+	"github.com/dani-gouken/nomad/interpreter"
+	"github.com/dani-gouken/nomad/vm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreakAndContinue(t *testing.T) {
+	i := interpreter.NewInterpreter()
+	instance := interpret(t, vm.New(), `
+auto total :: 0
+auto iterations :: 0
+for int i :: 0; i < 10; i++ {
+    iterations++
+    if i = 2 {
+        continue
+    }
+    if i = 5 {
+        break
+    }
+    total :: total + i
+}
+auto pairs :: 0
+for int a :: 0; a < 3; a++ {
+    for int b :: 0; b < 3; b++ {
+        if b > a {
+            break
+        }
+        pairs++
+    }
+}
+auto caught :: false
+for int j :: 0; j < 3; j++ {
+    try {
+        if j = 1 {
+            break
+        }
+    } catch {
+        caught :: true
+    }
+}
+`)
+
+	assertGlobals(t, instance, map[string]interface{}{
+		"total":      int64(8),
+		"iterations": int64(6),
+		"pairs":      int64(6),
+		"caught":     false,
+	})
+
+	// leaving a try block with break no longer catches errors raised after the loop
+	err := i.Interpret(`
+for int j :: 0; j < 3; j++ {
+    try {
+        break
+    } catch {
+        print "unreachable"
+    }
+}
+throw new Error{ message :: "after loop" }
+`, vm.New())
+	assert.ErrorContains(t, err, "uncaught error: after loop")
+}
+
+func TestBreakOutsideOfALoop(t *testing.T) {
+	i := interpreter.NewInterpreter()
+	for _, code := range []string{
+		"break",
+		"if true {\n    continue\n}",
+		"for int i :: 0; i < 3; i++ {\n    auto f :: func() void {\n        break\n    }\n}",
+	} {
+		err := i.Interpret(code, vm.New())
+		assert.ErrorContains(t, err, "used outside of a loop")
+	}
+}
+
+func TestForIn(t *testing.T) {
+	i := interpreter.NewInterpreter()
+	instance := interpret(t, vm.New(), `
+auto names :: [string]{"alice", "bob", "carol"}
+auto joined :: ""
+auto indexes :: 0
+for index, name in names {
+    if name = "bob" {
+        continue
+    }
+    joined :: joined + name
+    indexes :: indexes + index
+}
+auto chars :: 0
+for char in "abc" {
+    chars++
+}
+auto ages :: {string: int}{"alice": 30, "bob": 20}
+auto total :: 0
+for name in ages {
+    total :: total + ages[name]
+}
+auto countdown :: 3
+for countdown > 0 {
+    countdown--
+}
+`)
+
+	assertGlobals(t, instance, map[string]interface{}{
+		"joined":    "alicecarol",
+		"indexes":   int64(2),
+		"chars":     int64(3),
+		"total":     int64(50),
+		"countdown": int64(0),
+	})
+
+	err := i.Interpret("for item in 3 {\n    print item\n}", vm.New())
+	assert.ErrorContains(t, err, "cannot iterate over value of type int")
+}
+
+func TestForInMapBindsKeysAndValues(t *testing.T) {
+	instance := interpret(t, vm.New(), `
+auto ages :: {string: int}{"bob": 20, "alice": 30}
+string pairs :: ""
+int total :: 0
+for name, age in ages {
+    pairs :: pairs + "{name}={age};"
+    total :: total + age
+}
+string names :: ""
+for name in ages {
+    names :: names + name
+}
+`)
+	assertGlobals(t, instance, map[string]interface{}{
+		"pairs": "alice=30;bob=20;",
+		"total": int64(50),
+		"names": "alicebob",
+	})
+}
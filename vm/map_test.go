@@ -0,0 +1,116 @@
+package vm_test
+
+import (
+	"testing"
+
+	"github.com/dani-gouken/nomad/interpreter"
+	"github.com/dani-gouken/nomad/runtime/data"
+	"github.com/dani-gouken/nomad/vm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMap(t *testing.T) {
+	instance := interpret(t, vm.New(), `
+{string: int} ages :: {string: int}{
+    "alice": 31,
+    "bob": 25,
+}
+ages["carol"] :: 40
+ages["bob"] :: ages["bob"] + 1
+auto count :: len ages
+auto hasBob :: "bob" in ages
+auto hasDan :: "dan" in ages
+auto names :: keys ages
+int total :: 0
+for int i :: 0; i < len names; i++ {
+    total :: total + ages[names[i]]
+}
+auto groups :: {int: [string]}{}
+groups[1] :: [string]{"a"}
+groups[1][0] :: "b"
+auto first :: groups[1][0]
+`)
+
+	assertGlobals(t, instance, map[string]interface{}{
+		"count":  int64(3),
+		"hasBob": true,
+		"hasDan": false,
+		"total":  int64(97),
+		"first":  "b",
+	})
+
+	names, err := instance.GetGlobal("names")
+	assert.NoError(t, err)
+	assert.Equal(t, "[string]", names.RuntimeType.GetName())
+	keys := []interface{}{}
+	for _, key := range names.Value.(data.RuntimeArray).Values {
+		keys = append(keys, key.Value)
+	}
+	assert.Equal(t, []interface{}{"alice", "bob", "carol"}, keys)
+
+	var ages map[string]int
+	value, err := instance.GetGlobal("ages")
+	assert.NoError(t, err)
+	assert.NoError(t, instance.FromRuntimeValue(value, &ages))
+	assert.Equal(t, map[string]int{"alice": 31, "bob": 26, "carol": 40}, ages)
+}
+
+func TestMapErrors(t *testing.T) {
+	i := interpreter.NewInterpreter()
+	err := i.Interpret(`
+auto ages :: {string: int}{"alice": 31}
+print ages["bob"]
+`, vm.New())
+	assert.ErrorContains(t, err, "key bob not found")
+
+	err = i.Interpret(`
+auto ages :: [int]{1}
+print ages[3]
+`, vm.New())
+	assert.ErrorContains(t, err, "index 3 out of range, array length is 1")
+
+	err = i.Interpret(`auto bad :: {[int]: int}{}`, vm.New())
+	assert.ErrorContains(t, err, "invalid map key type [int]")
+}
+
+func TestBindMap(t *testing.T) {
+	instance := vm.New()
+	assert.NoError(t, instance.Bind("scores", map[string]float64{"a": 1.5}))
+	i := interpreter.NewInterpreter()
+	err := i.Interpret(`scores["b"] :: scores["a"] * 2.0`, instance)
+	assert.NoError(t, err)
+
+	value, err := instance.GetGlobal("scores")
+	assert.NoError(t, err)
+	assert.Equal(t, "{string: float}", value.RuntimeType.GetName())
+	var scores map[string]float64
+	assert.NoError(t, instance.FromRuntimeValue(value, &scores))
+	assert.Equal(t, map[string]float64{"a": 1.5, "b": 3.0}, scores)
+}
+
+func TestObjectDefaultsAreNotShared(t *testing.T) {
+	instance := interpret(t, vm.New(), `
+import "json"
+type Bag :: {
+    {string: int} counts :: {string: int}{}
+    [int] values :: [int]{1}
+}
+auto first :: new Bag{}
+auto second :: new Bag{}
+first.counts["a"] :: 1
+first.values[0] :: 2
+auto count :: len second.counts
+auto value :: second.values[0]
+
+Bag decoded :: json.decode('\{}', Bag)
+Bag other :: json.decode('\{}', Bag)
+decoded.counts["b"] :: 1
+auto decodedCount :: len other.counts
+`)
+
+	assertGlobals(t, instance, map[string]interface{}{
+		"count":        int64(0),
+		"value":        int64(1),
+		"decodedCount": int64(0),
+	})
+}
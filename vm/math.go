@@ -0,0 +1,121 @@
+package vm
+
+import (
+	"math"
+
+	"github.com/dani-gouken/nomad/runtime/data"
+	"github.com/dani-gouken/nomad/runtime/types"
+)
+
+// registerMathModule declares the math module, backed by the go math package.
+func (vm *Vm) registerMathModule() error {
+	numType := vm.types.GetOrPanic(types.NUM_TYPE)
+	floatType := vm.types.GetOrPanic(types.FLOAT_TYPE)
+	boolType := vm.types.GetOrPanic(types.BOOL_TYPE)
+
+	float := func(value float64) data.RuntimeValue {
+		return data.RuntimeValue{RuntimeType: floatType, Value: value}
+	}
+	members := map[string]data.RuntimeValue{
+		"PI":  float(math.Pi),
+		"E":   float(math.E),
+		"INF": float(math.Inf(1)),
+		"NAN": float(math.NaN()),
+	}
+
+	unary := map[string]func(float64) float64{
+		"sqrt":  math.Sqrt,
+		"cbrt":  math.Cbrt,
+		"floor": math.Floor,
+		"ceil":  math.Ceil,
+		"round": math.Round,
+		"trunc": math.Trunc,
+		"sin":   math.Sin,
+		"cos":   math.Cos,
+		"tan":   math.Tan,
+		"asin":  math.Asin,
+		"acos":  math.Acos,
+		"atan":  math.Atan,
+		"exp":   math.Exp,
+		"log":   math.Log,
+		"log2":  math.Log2,
+		"log10": math.Log10,
+	}
+	for name, fn := range unary {
+		fn := fn
+		signature := data.NewFuncSignature(floatType)
+		signature.AddParam("x", floatType, data.RuntimeValue{})
+		members[name] = vm.nativeFunc("math."+name, signature, func(args []data.RuntimeValue) (data.RuntimeValue, error) {
+			return float(fn(args[0].Value.(float64))), nil
+		})
+	}
+
+	binary := map[string]struct {
+		params [2]string
+		fn     func(float64, float64) float64
+	}{
+		"pow":   {[2]string{"x", "y"}, math.Pow},
+		"atan2": {[2]string{"y", "x"}, math.Atan2},
+		"hypot": {[2]string{"x", "y"}, math.Hypot},
+	}
+	for name, binaryFunc := range binary {
+		fn := binaryFunc.fn
+		signature := data.NewFuncSignature(floatType)
+		for _, param := range binaryFunc.params {
+			signature.AddParam(param, floatType, data.RuntimeValue{})
+		}
+		members[name] = vm.nativeFunc("math."+name, signature, func(args []data.RuntimeValue) (data.RuntimeValue, error) {
+			return float(fn(args[0].Value.(float64), args[1].Value.(float64))), nil
+		})
+	}
+
+	checks := map[string]func(float64) bool{
+		"is_nan": math.IsNaN,
+		"is_inf": func(x float64) bool { return math.IsInf(x, 0) },
+	}
+	for name, fn := range checks {
+		fn := fn
+		signature := data.NewFuncSignature(boolType)
+		signature.AddParam("x", floatType, data.RuntimeValue{})
+		members[name] = vm.nativeFunc("math."+name, signature, func(args []data.RuntimeValue) (data.RuntimeValue, error) {
+			return data.RuntimeValue{RuntimeType: boolType, Value: fn(args[0].Value.(float64))}, nil
+		})
+	}
+
+	// abs, min and max keep the type of their operands, an int being
+	// promoted when compared to a float
+	abs := data.NewFuncSignature(numType)
+	abs.AddParam("x", numType, data.RuntimeValue{})
+	members["abs"] = vm.nativeFunc("math.abs", abs, func(args []data.RuntimeValue) (data.RuntimeValue, error) {
+		x := data.Number(args[0])
+		if value, ok := x.Value.(int64); ok && value < 0 {
+			x.Value = -value
+		}
+		if value, ok := x.Value.(float64); ok {
+			x.Value = math.Abs(value)
+		}
+		return x, nil
+	})
+	for name, less := range map[string]bool{"min": true, "max": false} {
+		less := less
+		signature := data.NewFuncSignature(numType)
+		signature.AddParam("a", numType, data.RuntimeValue{})
+		signature.AddParam("b", numType, data.RuntimeValue{})
+		members[name] = vm.nativeFunc("math."+name, signature, func(args []data.RuntimeValue) (data.RuntimeValue, error) {
+			a, b := data.Number(args[0]), data.Number(args[1])
+			if types.ExpectedFloatType(a.RuntimeType) == nil || types.ExpectedFloatType(b.RuntimeType) == nil {
+				a, b = data.Promote(a, floatType), data.Promote(b, floatType)
+				if (a.Value.(float64) < b.Value.(float64)) == less {
+					return a, nil
+				}
+				return b, nil
+			}
+			if (a.Value.(int64) < b.Value.(int64)) == less {
+				return a, nil
+			}
+			return b, nil
+		})
+	}
+
+	return vm.RegisterModule("math", members)
+}
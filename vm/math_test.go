@@ -0,0 +1,63 @@
+package vm_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/dani-gouken/nomad/interpreter"
+	"github.com/dani-gouken/nomad/vm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMathModule(t *testing.T) {
+	instance := interpret(t, vm.New(), `
+import "math"
+auto sqrt :: math.sqrt(16)
+auto pow :: math.pow(2, 10)
+auto abs_int :: math.abs(-3)
+auto abs_float :: math.abs(-2.5)
+auto min_int :: math.min(3, 7)
+auto max_mixed :: math.max(3, 4.5)
+auto floor :: math.floor(2.7)
+auto ceil :: math.ceil(2.1)
+auto round :: math.round(2.5)
+auto sin :: math.sin(math.PI / 2)
+auto angle :: math.atan2(1, 1) * 4
+auto log :: math.log(math.E)
+auto exp :: math.exp(0)
+auto nan :: math.is_nan(math.NAN)
+auto inf :: math.is_inf(-math.INF)
+auto finite :: math.is_inf(1.0)
+`)
+
+	assertGlobals(t, instance, map[string]interface{}{
+		"sqrt":      4.0,
+		"pow":       1024.0,
+		"abs_int":   int64(3),
+		"abs_float": 2.5,
+		"min_int":   int64(3),
+		"max_mixed": 4.5,
+		"floor":     2.0,
+		"ceil":      3.0,
+		"round":     3.0,
+		"sin":       1.0,
+		"angle":     math.Pi,
+		"log":       1.0,
+		"exp":       1.0,
+		"nan":       true,
+		"inf":       true,
+		"finite":    false,
+	})
+}
+
+func TestMathModuleErrors(t *testing.T) {
+	i := interpreter.NewInterpreter()
+	err := i.Interpret("import \"math\"\nprint math.sqrt(\"4\")", vm.New())
+	assert.ErrorContains(t, err, "type mismatch for parameter x, expected float, got string")
+
+	err = i.Interpret("import \"math\"\nprint math.cube(2)", vm.New())
+	assert.ErrorContains(t, err, "type module math has no field [cube]")
+
+	err = vm.New().RegisterModule("math", nil)
+	assert.ErrorContains(t, err, "cannot redeclare module [math]")
+}
@@ -0,0 +1,46 @@
+package vm
+
+import (
+	"fmt"
+
+	"github.com/dani-gouken/nomad/runtime/data"
+	"github.com/dani-gouken/nomad/runtime/types"
+)
+
+// declareMethod attaches the function fn to the object type held by
+// typeValue, its receiver being declared as receiverName when it is called.
+func (vm *Vm) declareMethod(typeValue *data.RuntimeValue, fn *data.RuntimeValue, receiverName string, name string) error {
+	err := types.ExpectedTypeType(typeValue.RuntimeType)
+	if err != nil {
+		return err
+	}
+	receiverType := typeValue.Value.(types.RuntimeType)
+	objectType, err := types.ToObjectType(receiverType)
+	if err != nil {
+		return fmt.Errorf("cannot declare method %s on type %s, only object types can have methods", name, receiverType.GetName())
+	}
+	f, ok := fn.Value.(*data.RuntimeFunc)
+	if !ok {
+		return fmt.Errorf("function expected, got %s", fn.RuntimeType.GetName())
+	}
+	f.Tag = objectType.GetName() + "." + name
+	f.ReceiverName = receiverName
+	return objectType.AddMethod(name, f)
+}
+
+// lookupMethod returns the method name of the type of object, bound to it.
+func lookupMethod(object data.RuntimeValue, name string) (data.RuntimeValue, bool) {
+	objectType, err := types.ToObjectType(object.RuntimeType)
+	if err != nil {
+		return data.RuntimeValue{}, false
+	}
+	method, ok := objectType.GetMethod(name)
+	if !ok {
+		return data.RuntimeValue{}, false
+	}
+	f := method.(*data.RuntimeFunc).Bind(object)
+	return data.RuntimeValue{
+		RuntimeType: f.Signature.AsType(),
+		Value:       f,
+	}, true
+}
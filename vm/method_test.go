@@ -0,0 +1,119 @@
+package vm_test
+
+import (
+	"testing"
+
+	"github.com/dani-gouken/nomad/interpreter"
+	"github.com/dani-gouken/nomad/runtime/data"
+	"github.com/dani-gouken/nomad/vm"
+	"github.com/stretchr/testify/assert"
+)
+
+const rectSource = `
+type Rect :: {
+    float width :: 0.0
+    float height :: 0.0
+}
+func (Rect r) area() float {
+    return r.width * r.height
+}
+func (Rect r) scale(float factor :: 2.0) Rect {
+    return new Rect{ width :: r.width * factor, height :: r.height * factor }
+}
+func (Rect r) halve(int times) Rect {
+    if times = 0 {
+        return r
+    }
+    return r.scale(0.5).halve(times - 1)
+}
+`
+
+func TestMethods(t *testing.T) {
+	instance := interpret(t, vm.New(), rectSource+`
+string unit :: "cm"
+func (Rect r) describe() string {
+    return "{r.width}x{r.height}{unit}"
+}
+func (Rect r) pick[T](T a, T b) T {
+    if r.width > r.height {
+        return a
+    }
+    return b
+}
+
+auto rect :: new Rect{ width :: 2.0, height :: 3.0 }
+auto area :: rect.area()
+auto scaled :: rect.scale().area()
+auto named :: rect.scale(factor: 0.5).area()
+auto halved :: rect.halve(2).width
+auto described :: rect.describe()
+auto picked :: rect.pick("wide", "tall")
+auto bound :: rect.area
+auto called :: bound()
+`)
+
+	assertGlobals(t, instance, map[string]interface{}{
+		"area":      6.0,
+		"scaled":    24.0,
+		"named":     1.5,
+		"halved":    0.5,
+		"described": "2x3cm",
+		"picked":    "tall",
+		"called":    6.0,
+	})
+	bound, _ := instance.GetGlobal("bound")
+	assert.Equal(t, "func -> (float)", bound.RuntimeType.GetName())
+	// methods are not fields of the objects
+	rect, _ := instance.GetGlobal("rect")
+	assert.Equal(t, "Rect{height: 3, width: 2}", data.ToString(rect))
+}
+
+func TestMethodErrors(t *testing.T) {
+	cases := map[string]string{
+		`auto a :: new Rect{}.perimeter()`:               "type Rect has no field [perimeter]",
+		`func (Rect r) area() float { return 0.0 }`:      "cannot redeclare method area of type Rect",
+		`func (Shape s) area() float { return 0.0 }`:     "unknown type [Shape]",
+		`func (Rect r) width() float { return r.width }`: "cannot declare method width, type Rect has a field named width",
+		`func (string s) shout() string { return s }`:    "cannot declare method shout on type string, only object types can have methods",
+	}
+	i := interpreter.NewInterpreter()
+	for source, message := range cases {
+		err := i.Interpret(rectSource+source, vm.New())
+		assert.ErrorContains(t, err, message, source)
+	}
+}
+
+func TestCallMethodFromGo(t *testing.T) {
+	instance := interpret(t, vm.New(), rectSource+`auto rect :: new Rect{ width :: 2.0, height :: 3.0 }`)
+	rect, err := instance.GetGlobal("rect")
+	assert.NoError(t, err)
+
+	result, err := instance.CallMethod(rect, "scale", data.RuntimeValue{RuntimeType: instance.Types().GetOrPanic("float"), Value: 3.0})
+	assert.NoError(t, err)
+	area, err := instance.CallMethod(result, "area")
+	assert.NoError(t, err)
+	assert.Equal(t, 54.0, area.Value)
+
+	_, err = instance.CallMethod(rect, "perimeter")
+	assert.ErrorContains(t, err, "type Rect has no method [perimeter]")
+	_, err = instance.CallMethod(data.RuntimeValue{RuntimeType: instance.Types().GetOrPanic("float"), Value: 1.0}, "area")
+	assert.ErrorContains(t, err, "cannot call method [area] of non-object value")
+}
+
+func TestMethodCallsLaterMethod(t *testing.T) {
+	instance := interpret(t, vm.New(), `
+type Node :: {
+    int value :: 0
+}
+func (Node n) total() int {
+    return n.helper(2)
+}
+func (Node n) helper(int factor) int {
+    return n.value * factor
+}
+int total :: new Node{ value :: 3 }.total()
+`)
+	assertGlobals(t, instance, map[string]interface{}{
+		"total": int64(6),
+	})
+}
@@ -0,0 +1,242 @@
+package vm
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	nomadError "github.com/dani-gouken/nomad/errors"
+	"github.com/dani-gouken/nomad/runtime/data"
+	"github.com/dani-gouken/nomad/runtime/types"
+	"github.com/dani-gouken/nomad/tokenizer"
+)
+
+// ModuleLoader finds and compiles the modules imported by the scripts.
+type ModuleLoader interface {
+	// Resolve returns the canonical path of the module imported as path
+	// from the module located at importer (empty for the entry program).
+	Resolve(path string, importer string) (string, error)
+	// Load compiles the module located at the resolved path.
+	Load(path string) ([]Instruction, error)
+}
+
+type Module struct {
+	Name string
+	// Id qualifies the types declared by the module. It is the name of the
+	// module, suffixed when another module with the same name is loaded.
+	Id      string
+	Path    string
+	frame   *Frame
+	value   data.RuntimeValue
+	loading bool
+	// native modules are registered from go, see RegisterModule
+	native bool
+}
+
+// RegisterModule declares a module implemented in Go, imported by the
+// scripts with its name, before any module loader is involved.
+func (vm *Vm) RegisterModule(name string, members map[string]data.RuntimeValue) error {
+	if _, ok := vm.modules[name]; ok {
+		return fmt.Errorf("cannot redeclare module [%s]", name)
+	}
+	namespaceType := types.NewObjectType()
+	namespaceType.SetName("module " + name)
+	namespace := data.NewRuntimeObject()
+	for memberName, value := range members {
+		namespaceType.AddField(memberName, value.RuntimeType, value)
+		namespace.SetField(memberName, value)
+	}
+	vm.modules[name] = &Module{
+		Name: name,
+		Id:   vm.moduleId(name),
+		Path: name,
+		value: data.RuntimeValue{
+			RuntimeType: namespaceType,
+			Value:       namespace,
+		},
+		native: true,
+	}
+	return nil
+}
+
+// NativeModules returns the namespaces of the modules registered from go,
+// by name.
+func (vm *Vm) NativeModules() map[string]data.RuntimeValue {
+	modules := map[string]data.RuntimeValue{}
+	for name, module := range vm.modules {
+		if module.native {
+			modules[name] = module.value
+		}
+	}
+	return modules
+}
+
+func (vm *Vm) SetModuleLoader(loader ModuleLoader) {
+	vm.moduleLoader = loader
+}
+
+// SetEntryPath sets the path of the entry program, imports made
+// by the entry program are resolved relatively to it.
+func (vm *Vm) SetEntryPath(path string) {
+	vm.entryPath = path
+}
+
+func (vm *Vm) currentModule() string {
+	frame, err := vm.callStack.Current()
+	if err != nil {
+		return ""
+	}
+	return frame.module
+}
+
+// currentPath returns the file of the module being executed, or "" when
+// the program does not originate from a file.
+func (vm *Vm) currentPath() string {
+	if module := vm.currentModule(); module != "" {
+		return module
+	}
+	return vm.entryPath
+}
+
+// newCallFrame creates the frame of a call to f. Functions resolve
+// their variables through the frame they were defined in, not through
+// the caller.
+func (vm *Vm) newCallFrame(returnAddr int, f *data.RuntimeFunc, t tokenizer.Token) *Frame {
+	parent, ok := f.Closure.(*Frame)
+	if !ok {
+		parent = vm.callStack.Get(0)
+	}
+	frame := NewFrame(returnAddr, f, t, parent)
+	frame.module = f.Module
+	if f.Receiver != nil {
+		frame.Env().DeclareVariable(f.ReceiverName, f.Receiver, f.Receiver.RuntimeType)
+	}
+	return frame
+}
+
+// moduleId returns a unique id for a module named name, two files
+// with the same base name being different modules.
+func (vm *Vm) moduleId(name string) string {
+	count := vm.moduleNames[name]
+	vm.moduleNames[name] = count + 1
+	if count == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s#%d", name, count+1)
+}
+
+func (vm *Vm) qualifyTypeName(name string) string {
+	module, ok := vm.modules[vm.currentModule()]
+	if !ok {
+		return name
+	}
+	return module.Id + "." + name
+}
+
+// namespaceModule returns the module imported under the namespace name.
+func (vm *Vm) namespaceModule(name string) (*Module, bool) {
+	value, _, err := vm.callStack.FindVariable(name)
+	if err != nil {
+		return nil, false
+	}
+	for _, module := range vm.modules {
+		if module.value.RuntimeType != nil && module.value.RuntimeType == value.RuntimeType {
+			return module, true
+		}
+	}
+	return nil, false
+}
+
+func (vm *Vm) lookupType(name string) (types.RuntimeType, error) {
+	if t, ok := vm.lookupTypeParam(name); ok {
+		return t, nil
+	}
+	// namespace.Type, the namespace being bound by an import
+	namespace, typeName, found := strings.Cut(name, ".")
+	if found {
+		if module, ok := vm.namespaceModule(namespace); ok {
+			return vm.types.Get(module.Id + "." + typeName)
+		}
+	}
+	qualified := vm.qualifyTypeName(name)
+	if qualified != name && vm.types.Has(qualified) {
+		return vm.types.Get(qualified)
+	}
+	return vm.types.Get(name)
+}
+
+func (vm *Vm) importModule(path string, debugToken tokenizer.Token) (data.RuntimeValue, error) {
+	if module, ok := vm.modules[path]; ok && module.native {
+		return module.value, nil
+	}
+	if vm.moduleLoader == nil {
+		return data.RuntimeValue{}, nomadError.RuntimeError(fmt.Sprintf("cannot import [%s], no module loader configured", path), debugToken)
+	}
+	importer := vm.currentModule()
+	if importer == "" {
+		importer = vm.entryPath
+	}
+	resolved, err := vm.moduleLoader.Resolve(path, importer)
+	if err != nil {
+		return data.RuntimeValue{}, nomadError.RuntimeError(err.Error(), debugToken)
+	}
+	module, ok := vm.modules[resolved]
+	if ok && module.loading {
+		cycle := append(vm.importStack, resolved)
+		if vm.entryPath != "" {
+			cycle = append([]string{vm.entryPath}, cycle...)
+		}
+		return data.RuntimeValue{}, nomadError.RuntimeError(fmt.Sprintf("import cycle detected: %s", strings.Join(cycle, " -> ")), debugToken)
+	}
+	if ok {
+		return module.value, nil
+	}
+
+	name := strings.TrimSuffix(filepath.Base(resolved), filepath.Ext(resolved))
+	module = &Module{
+		Name:    name,
+		Id:      vm.moduleId(name),
+		Path:    resolved,
+		loading: true,
+	}
+	vm.modules[resolved] = module
+	vm.importStack = append(vm.importStack, resolved)
+	defer func() {
+		vm.importStack = vm.importStack[:len(vm.importStack)-1]
+	}()
+
+	instructions, err := vm.moduleLoader.Load(resolved)
+	if err != nil {
+		delete(vm.modules, resolved)
+		return data.RuntimeValue{}, err
+	}
+
+	depth := vm.callStack.pointer
+	module.frame = NewFrame(-1, nil, debugToken, vm.callStack.Get(0))
+	module.frame.module = resolved
+	err = vm.callStack.Push(module.frame)
+	if err != nil {
+		delete(vm.modules, resolved)
+		return data.RuntimeValue{}, nomadError.RuntimeError(err.Error(), debugToken)
+	}
+	err = vm.run(vm.load(instructions), NO_RETURN_DEPTH)
+	vm.callStack.SetPointer(depth)
+	if err != nil {
+		delete(vm.modules, resolved)
+		return data.RuntimeValue{}, err
+	}
+
+	namespaceType := types.NewObjectType()
+	namespaceType.SetName("module " + module.Id)
+	namespace := data.NewRuntimeObject()
+	for varName, value := range module.frame.Env().Variables() {
+		namespaceType.AddField(varName, value.RuntimeType, *value)
+		namespace.SetField(varName, *value)
+	}
+	module.value = data.RuntimeValue{
+		RuntimeType: namespaceType,
+		Value:       namespace,
+	}
+	module.loading = false
+	return module.value, nil
+}
@@ -0,0 +1,98 @@
+package vm
+
+import (
+	"fmt"
+
+	nomadError "github.com/dani-gouken/nomad/errors"
+	"github.com/dani-gouken/nomad/runtime/data"
+	"github.com/dani-gouken/nomad/runtime/types"
+	"github.com/dani-gouken/nomad/tokenizer"
+)
+
+// Types exposes the type registrar so embedders can build signatures
+// using the same runtime types as the scripts.
+func (vm *Vm) Types() *types.Registrar {
+	return &vm.types
+}
+
+// RegisterNative declares a global function backed by Go code.
+func (vm *Vm) RegisterNative(name string, signature data.FuncSignature, fn data.NativeFunc) error {
+	if fn == nil {
+		return fmt.Errorf("cannot register native function [%s] without implementation", name)
+	}
+	value := vm.nativeFunc(name, signature, fn)
+	return vm.callStack.Get(0).Env().DeclareVariable(name, &value, value.RuntimeType)
+}
+
+// nativeFunc wraps go code in a function value.
+func (vm *Vm) nativeFunc(name string, signature data.FuncSignature, fn data.NativeFunc) data.RuntimeValue {
+	if signature.ReturnType == nil {
+		signature.ReturnType = vm.types.GetOrPanic(types.VOID_TYPE)
+	}
+	f := data.NewNativeFunc(name, signature, fn)
+	return data.RuntimeValue{
+		RuntimeType: f.Signature.AsType(),
+		Value:       f,
+	}
+}
+
+// bindArguments matches the pending positional and named arguments against
+// the signature of f, applying defaults. A generic function is instantiated
+// from the types of its arguments. The argument list is always cleared.
+func (vm *Vm) bindArguments(f *data.RuntimeFunc, debugToken tokenizer.Token) (*data.RuntimeFunc, []data.RuntimeValue, error) {
+	defer vm.ClearArguments()
+	if len(f.Signature.Parameters) < vm.ArgumentCount() {
+		return nil, nil, nomadError.RuntimeError(fmt.Sprintf(
+			"failed to call function %s :: %s, too much argument provided, %d declared, %d passed",
+			f.Tag, f.Signature.AsType().GetName(), len(f.Signature.Parameters), vm.ArgumentCount()), debugToken)
+	}
+	args := make([]data.RuntimeValue, 0, len(f.Signature.Parameters))
+	for _, pData := range f.Signature.Parameters {
+		value, err := vm.PopNamedArgument(pData.Name)
+		if err != nil {
+			value, err = vm.PopPositionalArgument()
+			if err != nil {
+				if !pData.HasDefault {
+					return nil, nil, nomadError.RuntimeError(err.Error(), debugToken)
+				}
+				value = pData.DefaultValue
+			}
+		}
+		args = append(args, value)
+	}
+	for name := range vm.namedArgument {
+		return nil, nil, nomadError.RuntimeError(fmt.Sprintf("failed to call %s, unknown argument [%s]", f.Tag, name), debugToken)
+	}
+	if f.IsGeneric() {
+		instance, err := inferTypeArgs(f, args)
+		if err != nil {
+			return nil, nil, nomadError.RuntimeError(fmt.Sprintf("failed to call %s, %s", f.Tag, err.Error()), debugToken)
+		}
+		f = instance
+	}
+	for i, pData := range f.Signature.Parameters {
+		args[i] = data.Promote(args[i], pData.RuntimeType)
+		err := pData.RuntimeType.Match(args[i].RuntimeType)
+		if err != nil {
+			return nil, nil, nomadError.RuntimeError(
+				fmt.Sprintf("failed to call %s, type mismatch for parameter \"%s\". %s", f.Tag, pData.Name, err.Error()), debugToken)
+		}
+	}
+	return f, args, nil
+}
+
+func (vm *Vm) callNative(f *data.RuntimeFunc, args []data.RuntimeValue, debugToken tokenizer.Token) (data.RuntimeValue, error) {
+	result, err := f.Native(args)
+	if err != nil {
+		return data.RuntimeValue{}, nomadError.RuntimeError(fmt.Sprintf("%s: %s", f.Tag, err.Error()), debugToken)
+	}
+	if result.RuntimeType == nil {
+		result.RuntimeType = vm.types.GetOrPanic(types.VOID_TYPE)
+	}
+	err = f.Signature.ReturnType.Match(result.RuntimeType)
+	if err != nil {
+		return data.RuntimeValue{}, nomadError.RuntimeError(
+			fmt.Sprintf("%s returned an invalid value. %s", f.Tag, err.Error()), debugToken)
+	}
+	return result, nil
+}
@@ -0,0 +1,83 @@
+package vm_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dani-gouken/nomad/interpreter"
+	"github.com/dani-gouken/nomad/runtime/data"
+	"github.com/dani-gouken/nomad/runtime/types"
+	"github.com/dani-gouken/nomad/vm"
+	"github.com/stretchr/testify/assert"
+)
+
+func registerRecorder(t *testing.T, instance *vm.Vm, recorded *[]data.RuntimeValue) {
+	reg := instance.Types()
+	signature := data.NewFuncSignature(reg.GetOrPanic(types.VOID_TYPE))
+	assert.NoError(t, signature.AddParam("value", reg.GetOrPanic(types.INT_TYPE), data.RuntimeValue{}))
+	err := instance.RegisterNative("record", signature, func(args []data.RuntimeValue) (data.RuntimeValue, error) {
+		*recorded = append(*recorded, args[0])
+		return data.RuntimeValue{}, nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestRegisterNative(t *testing.T) {
+	instance := vm.New()
+	reg := instance.Types()
+	recorded := []data.RuntimeValue{}
+	registerRecorder(t, instance, &recorded)
+
+	signature := data.NewFuncSignature(reg.GetOrPanic(types.INT_TYPE))
+	assert.NoError(t, signature.AddParam("a", reg.GetOrPanic(types.INT_TYPE), data.RuntimeValue{}))
+	assert.NoError(t, signature.AddParam("b", reg.GetOrPanic(types.INT_TYPE), data.RuntimeValue{
+		RuntimeType: reg.GetOrPanic(types.INT_TYPE),
+		Value:       int64(10),
+	}))
+	err := instance.RegisterNative("sub", signature, func(args []data.RuntimeValue) (data.RuntimeValue, error) {
+		return data.RuntimeValue{
+			RuntimeType: reg.GetOrPanic(types.INT_TYPE),
+			Value:       args[0].Value.(int64) - args[1].Value.(int64),
+		}, nil
+	})
+	assert.NoError(t, err)
+
+	i := interpreter.NewInterpreter()
+	err = i.Interpret(`
+record(sub(50, 8))
+record(sub(50))
+record(sub(b: 1, a: 3))
+`, instance)
+	assert.NoError(t, err)
+	assert.Len(t, recorded, 3)
+	assert.Equal(t, int64(42), recorded[0].Value)
+	assert.Equal(t, int64(40), recorded[1].Value)
+	assert.Equal(t, int64(2), recorded[2].Value)
+
+	err = i.Interpret("sub(a: 1, c: 2)", instance)
+	assert.ErrorContains(t, err, "unknown argument [c]")
+}
+
+func TestRegisterNativeErrors(t *testing.T) {
+	instance := vm.New()
+	reg := instance.Types()
+	recorded := []data.RuntimeValue{}
+	registerRecorder(t, instance, &recorded)
+
+	signature := data.NewFuncSignature(reg.GetOrPanic(types.INT_TYPE))
+	err := instance.RegisterNative("fail", signature, func(args []data.RuntimeValue) (data.RuntimeValue, error) {
+		return data.RuntimeValue{}, errors.New("host failure")
+	})
+	assert.NoError(t, err)
+	err = instance.RegisterNative("bad", signature, func(args []data.RuntimeValue) (data.RuntimeValue, error) {
+		return data.RuntimeValue{RuntimeType: reg.GetOrPanic(types.STRING_TYPE), Value: "oops"}, nil
+	})
+	assert.NoError(t, err)
+
+	i := interpreter.NewInterpreter()
+	assert.ErrorContains(t, i.Interpret("fail()", instance), "host failure")
+	assert.ErrorContains(t, i.Interpret("bad()", instance), "returned an invalid value")
+	assert.ErrorContains(t, i.Interpret(`record("nope")`, instance), "type mismatch for parameter value, expected int, got string")
+	assert.ErrorContains(t, i.Interpret("record(1, 2)", instance), "too many arguments, 1 declared, 2 passed")
+	assert.Empty(t, recorded)
+}
@@ -0,0 +1,72 @@
+package vm_test
+
+import (
+	"testing"
+
+	"github.com/dani-gouken/nomad/interpreter"
+	"github.com/dani-gouken/nomad/vm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNumericPromotion(t *testing.T) {
+	instance := interpret(t, vm.New(), `
+auto sum :: 1 + 0.5
+auto product :: 2.5 * 2
+float declared :: 3
+auto half :: func(float value) float { return value / 2 }
+auto argument :: half(3)
+auto four :: func() float { return 4 }
+auto returned :: four()
+float assigned :: 1.5
+assigned :: 2
+num generic :: 7
+num generic_sum :: generic + 0.5
+auto compared :: 1 < 1.5
+auto equal :: 2 = 2.0
+auto negative :: -generic
+auto floats :: [float]{1, 2.5}
+floats[1] :: 3
+auto first :: floats[0]
+auto second :: floats[1]
+auto weights :: {string: float}{"a": 1}
+weights["b"] :: 2
+auto weight :: weights["a"] + weights["b"]
+`)
+
+	assertGlobals(t, instance, map[string]interface{}{
+		"sum":         1.5,
+		"product":     5.0,
+		"declared":    3.0,
+		"argument":    1.5,
+		"returned":    4.0,
+		"assigned":    2.0,
+		"generic":     int64(7),
+		"generic_sum": 7.5,
+		"compared":    true,
+		"equal":       true,
+		"negative":    int64(-7),
+		"first":       1.0,
+		"second":      3.0,
+		"weight":      3.0,
+	})
+}
+
+func TestNumericConversions(t *testing.T) {
+	i := interpreter.NewInterpreter()
+	instance := interpret(t, vm.New(), `
+auto truncated :: int(3.9)
+auto negative :: int(-3.9)
+auto same :: int(4)
+auto converted :: float(2)
+`)
+
+	assertGlobals(t, instance, map[string]interface{}{
+		"truncated": int64(3),
+		"negative":  int64(-3),
+		"same":      int64(4),
+		"converted": 2.0,
+	})
+
+	err := i.Interpret("auto zero :: 0.0\nprint int(1.0 / zero)", vm.New())
+	assert.ErrorContains(t, err, "cannot convert +Inf to int")
+}
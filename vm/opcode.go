@@ -28,8 +28,10 @@ const (
 	OP_ADD                   = "ADD"
 	OP_DIV                   = "DIV"
 	OP_ARR_LOAD              = "ARR_LOAD"
+	OP_ARR_STORE             = "ARR_STORE"
 	OP_SUB                   = "SUB"
 	OP_DECL_TYPE             = "DECL_TYPE"
+	OP_DECL_METHOD           = "DECL_METHOD"
 	OP_RETURN                = "RETURN"
 	OP_HALT                  = "HALT"
 	OP_DEBUG_PRINT           = "DEBUG_PRINT"
@@ -55,6 +57,9 @@ const (
 	OP_FUNC_SET_PARAM              = "FUNC_SET_PARAM"
 	OP_FUNC_SET_PARAM_WITH_DEFAULT = "FUNC_SET_PARAM_WITH_DEFAULT"
 	OP_FUNC_SET_RET                = "FUNC_SET_RET"
+	OP_FUNC_TYPE_PARAMS            = "FUNC_TYPE_PARAMS"
+	OP_FUNC_TYPE_PARAMS_END        = "FUNC_TYPE_PARAMS_END"
+	OP_FUNC_INSTANTIATE            = "FUNC_INSTANTIATE"
 	OP_CALL                        = "CALL"
 
 	OP_PUSH_ARG       = "PUSH_ARG"
@@ -63,4 +68,53 @@ const (
 	OP_FUNC_TYPE           = "FUNC_TYPE"
 	OP_FUNC_TYPE_SET_RET   = "FUNC_TYPE_SET_RET"
 	OP_FUNC_TYPE_SET_PARAM = "FUNC_TYPE_SET_PARAM"
+
+	OP_IMPORT = "IMPORT"
+
+	OP_MAP_TYPE = "MAP_TYPE"
+	OP_MAP_INIT = "MAP_INIT"
+	OP_MAP_SET  = "MAP_SET"
+	OP_MAP_HAS  = "MAP_HAS"
+	OP_MAP_KEYS = "MAP_KEYS"
+
+	OP_ENUM_TYPE             = "ENUM_TYPE"
+	OP_ENUM_TYPE_ADD_VARIANT = "ENUM_TYPE_ADD_VARIANT"
+	OP_ENUM_TYPE_SET_FIELD   = "ENUM_TYPE_SET_FIELD"
+	OP_ENUM_IS               = "ENUM_IS"
+	OP_ENUM_BIND             = "ENUM_BIND"
+	OP_MATCH_FAIL            = "MATCH_FAIL"
+
+	OP_OPTIONAL_TYPE = "OPTIONAL_TYPE"
+	OP_IS_SOME       = "IS_SOME"
+	OP_UNWRAP        = "UNWRAP"
+
+	OP_TRY     = "TRY"
+	OP_TRY_END = "TRY_END"
+	OP_CATCH   = "CATCH"
+	OP_THROW   = "THROW"
+
+	OP_ITER_INIT  = "ITER_INIT"
+	OP_ITER_NEXT  = "ITER_NEXT"
+	OP_ITER_ITEM  = "ITER_ITEM"
+	OP_ITER_INDEX = "ITER_INDEX"
+
+	OP_MOD     = "MOD"
+	OP_BIT_AND = "BIT_AND"
+	OP_BIT_OR  = "BIT_OR"
+	OP_BIT_XOR = "BIT_XOR"
+	OP_BIT_NOT = "BIT_NOT"
+	OP_SHL     = "SHL"
+	OP_SHR     = "SHR"
+
+	OP_TO_STRING = "TO_STRING"
 )
+
+// HasAddressArg reports whether the first argument of the instruction is
+// an instruction address.
+func HasAddressArg(code string) bool {
+	switch code {
+	case OP_JUMP, OP_JUMP_NOT, OP_JUMP_IF, OP_FUNC_INIT, OP_TRY:
+		return true
+	}
+	return false
+}
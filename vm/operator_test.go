@@ -0,0 +1,49 @@
+package vm_test
+
+import (
+	"testing"
+
+	"github.com/dani-gouken/nomad/interpreter"
+	"github.com/dani-gouken/nomad/vm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntegerOperators(t *testing.T) {
+	instance := interpret(t, vm.New(), `
+auto mod :: 17 % 5
+auto and :: 12 & 10
+auto or :: 12 | 10
+auto xor :: 12 ^ 10
+auto not :: ~12
+auto left :: 3 << 2
+auto right :: 48 >> 4
+auto precedence :: 10 - 4 / 2 + 7 % 4
+auto even :: 6 % 2 = 0 && 6 & 1 = 0
+auto logical :: true & false | true
+`)
+
+	assertGlobals(t, instance, map[string]interface{}{
+		"mod":        int64(2),
+		"and":        int64(8),
+		"or":         int64(14),
+		"xor":        int64(6),
+		"not":        int64(-13),
+		"left":       int64(12),
+		"right":      int64(3),
+		"precedence": int64(11),
+		"even":       true,
+		"logical":    true,
+	})
+}
+
+func TestIntegerOperatorErrors(t *testing.T) {
+	i := interpreter.NewInterpreter()
+	err := i.Interpret("auto zero :: 0\nprint 1 % zero", vm.New())
+	assert.ErrorContains(t, err, "integer division by zero")
+
+	err = i.Interpret("auto zero :: 0\nprint 1 / zero", vm.New())
+	assert.ErrorContains(t, err, "integer division by zero")
+
+	err = i.Interpret("auto count :: -1\nprint 1 << count", vm.New())
+	assert.ErrorContains(t, err, "negative shift count -1")
+}
@@ -0,0 +1,31 @@
+package vm
+
+import (
+	"fmt"
+
+	"github.com/dani-gouken/nomad/runtime/data"
+	"github.com/dani-gouken/nomad/runtime/types"
+)
+
+// isNone reports whether value is none, either the none literal or an empty optional.
+func isNone(value data.RuntimeValue) bool {
+	if types.IsNoneType(value.RuntimeType) {
+		return true
+	}
+	return types.IsOptionalType(value.RuntimeType) && value.Value == nil
+}
+
+// unwrap returns the value held by an optional, with the type of the optional subtype.
+func unwrap(value data.RuntimeValue) (data.RuntimeValue, error) {
+	if isNone(value) {
+		return data.RuntimeValue{}, fmt.Errorf("cannot unwrap none")
+	}
+	optionalType, err := types.ToOptionalType(value.RuntimeType)
+	if err != nil {
+		return value, nil
+	}
+	return data.RuntimeValue{
+		RuntimeType: optionalType.GetSubtype(),
+		Value:       value.Value,
+	}, nil
+}
@@ -0,0 +1,63 @@
+package vm_test
+
+import (
+	"testing"
+
+	"github.com/dani-gouken/nomad/interpreter"
+	"github.com/dani-gouken/nomad/runtime/types"
+	"github.com/dani-gouken/nomad/vm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptional(t *testing.T) {
+	instance := interpret(t, vm.New(), `
+auto find :: func([string] names, string name) ?int {
+    for int i :: 0; i < len names; i++ {
+        if names[i] = name {
+            return i
+        }
+    }
+    return none
+}
+auto names :: [string]{"a", "b"}
+?int found :: find(names, "b")
+?int missing :: find(names, "c")
+auto hasFound :: Some(found)
+auto hasMissing :: Some(missing)
+auto isNone :: missing = none
+int index :: unwrap found + 1
+missing :: 3
+auto assigned :: Some(missing)
+`)
+
+	assertGlobals(t, instance, map[string]interface{}{
+		"hasFound":   true,
+		"hasMissing": false,
+		"isNone":     true,
+		"index":      int64(2),
+		"assigned":   true,
+	})
+	found, err := instance.GetGlobal("found")
+	assert.NoError(t, err)
+	assert.Equal(t, "?int", found.RuntimeType.GetName())
+}
+
+func TestOptionalErrors(t *testing.T) {
+	i := interpreter.NewInterpreter()
+	err := i.Interpret("?int a :: none\nprint unwrap a", vm.New())
+	assert.ErrorContains(t, err, "cannot unwrap none")
+
+	err = i.Interpret("?int a :: 1\nauto b :: 2\nb :: unwrap a\nb :: a", vm.New())
+	assert.ErrorContains(t, err, "cannot assign value of type ?int to variable b of type int")
+
+	err = i.Interpret("string a :: none", vm.New())
+	assert.ErrorContains(t, err, "cannot assign value of type none to variable a declared as string")
+
+	optional, err := types.NewOptionalType(types.MakeIntType())
+	assert.NoError(t, err)
+	assert.NoError(t, optional.Match(types.MakeIntType()))
+	assert.NoError(t, optional.Match(types.MakeNoneType()))
+	assert.Error(t, types.MakeIntType().Match(optional))
+	_, err = types.NewOptionalType(optional)
+	assert.ErrorContains(t, err, "invalid optional type ??int")
+}
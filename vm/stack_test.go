@@ -16,5 +16,5 @@ func TestPush(t *testing.T) {
 
 	v, err := stack.Current()
 	assert.NoError(t, err)
-	assert.Equal(t, 4, v)
+	assert.Equal(t, int64(4), v.Value)
 }
@@ -0,0 +1,108 @@
+package vm_test
+
+import (
+	"testing"
+
+	"github.com/dani-gouken/nomad/interpreter"
+	"github.com/dani-gouken/nomad/runtime/data"
+	"github.com/dani-gouken/nomad/vm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterpolation(t *testing.T) {
+	instance := interpret(t, vm.New(), `
+type Point :: {
+    int x :: 0
+    int y :: 0
+}
+auto port :: 8080
+auto name :: "nomad"
+auto point :: new Point{ x :: 1, y :: 2 }
+?int missing :: none
+auto message :: "{name} listens on port {port + 1}"
+auto values :: "{[int]{1, 2}} {{string: bool}{"on": true}} {1.5} {point} {missing}"
+auto nested :: "outer {"inner {port}"}"
+auto escaped :: "\{port}"
+auto single :: 'single {name}'
+`)
+
+	assertGlobals(t, instance, map[string]interface{}{
+		"message": "nomad listens on port 8081",
+		"values":  "[1, 2] {on: true} 1.5 Point{x: 1, y: 2} none",
+		"nested":  "outer inner 8080",
+		"escaped": "{port}",
+		"single":  "single nomad",
+	})
+}
+
+func TestStringMethods(t *testing.T) {
+	instance := interpret(t, vm.New(), `
+auto name :: "  Stéphane Nghokeng  "
+auto trimmed :: name.trim()
+auto trimmed_start :: name.trim_start()
+auto trimmed_end :: name.trim_end()
+auto parts :: "a,b,,c".split(",")
+auto chars :: "été".split("")
+auto joined :: ", ".join(parts)
+auto contains :: trimmed.contains("phane")
+auto missing :: trimmed.contains("x")
+auto starts :: trimmed.starts_with("Sté")
+auto ends :: trimmed.ends_with("keng")
+auto replaced :: "a-b-c".replace("-", "+")
+auto index :: trimmed.index_of("N")
+auto not_found :: trimmed.index_of("z")
+auto upper :: trimmed.upper()
+auto lower :: trimmed.lower()
+auto repeated :: "ab".repeat(3)
+auto sub :: trimmed.substring(2, 8)
+auto chained :: "  Hello ".trim().lower().replace("l", "L")
+auto length :: len trimmed
+auto char :: trimmed[2]
+`)
+
+	assertGlobals(t, instance, map[string]interface{}{
+		"trimmed":       "Stéphane Nghokeng",
+		"trimmed_start": "Stéphane Nghokeng  ",
+		"trimmed_end":   "  Stéphane Nghokeng",
+		"joined":        "a, b, , c",
+		"contains":      true,
+		"missing":       false,
+		"starts":        true,
+		"ends":          true,
+		"replaced":      "a+b+c",
+		"index":         int64(9),
+		"not_found":     int64(-1),
+		"upper":         "STÉPHANE NGHOKENG",
+		"lower":         "stéphane nghokeng",
+		"repeated":      "ababab",
+		"sub":           "éphane",
+		"chained":       "heLLo",
+		"length":        int64(17),
+		"char":          "é",
+	})
+
+	for name, length := range map[string]int{"parts": 4, "chars": 3} {
+		actual, err := instance.GetGlobal(name)
+		assert.NoError(t, err)
+		assert.Len(t, actual.Value.(data.RuntimeArray).Values, length, name)
+	}
+}
+
+func TestStringMethodErrors(t *testing.T) {
+	i := interpreter.NewInterpreter()
+	err := i.Interpret(`print "abc".substring(2, 5)`, vm.New())
+	assert.ErrorContains(t, err, "string.substring: invalid range [2:5] for string of length 3")
+
+	err = i.Interpret("auto count :: -1\nprint \"ab\".repeat(count)", vm.New())
+	assert.ErrorContains(t, err, "string.repeat: negative repeat count -1")
+
+	err = i.Interpret(`print "ab".repeat(4611686018427387904)`, vm.New())
+	assert.ErrorContains(t, err, "string.repeat: repeat count 4611686018427387904 exceeds the maximum string length of 268435456 bytes")
+	assert.NoError(t, i.Interpret(`print "".repeat(4611686018427387904)`, vm.New()))
+
+	err = i.Interpret("auto i :: 3\nprint \"abc\"[i]", vm.New())
+	assert.ErrorContains(t, err, "index 3 out of range, string length is 3")
+
+	err = i.Interpret(`print "abc".reverse()`, vm.New())
+	assert.ErrorContains(t, err, "type string has no method [reverse]")
+}
@@ -0,0 +1,140 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+
+	nomadError "github.com/dani-gouken/nomad/errors"
+	"github.com/dani-gouken/nomad/runtime/data"
+	"github.com/dani-gouken/nomad/runtime/types"
+	"github.com/dani-gouken/nomad/tokenizer"
+)
+
+// handler is the catch block of a try statement being executed, along with
+// the state of the vm to restore before running it.
+type handler struct {
+	catchAddr    int
+	frameDepth   int
+	stackPointer int
+	scope        int
+}
+
+// thrownError is the error raised by a throw statement, holding the thrown
+// value. It is located like the other runtime errors.
+type thrownError struct {
+	*nomadError.ExecutionError
+	value data.RuntimeValue
+}
+
+func newThrownError(value data.RuntimeValue, debugToken tokenizer.Token) *thrownError {
+	err := nomadError.RuntimeError(fmt.Sprintf("uncaught error: %s", errorMessage(value)), debugToken)
+	return &thrownError{ExecutionError: err.(*nomadError.ExecutionError), value: value}
+}
+
+func errorMessage(value data.RuntimeValue) string {
+	obj, ok := value.Value.(*data.RuntimeObject)
+	if !ok {
+		return fmt.Sprintf("%v", value.Value)
+	}
+	message, err := obj.GetField("message")
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", message.Value)
+}
+
+func (vm *Vm) pushHandler(catchAddr int) error {
+	f, err := vm.callStack.Current()
+	if err != nil {
+		return err
+	}
+	vm.handlers = append(vm.handlers, handler{
+		catchAddr:    catchAddr,
+		frameDepth:   vm.callStack.pointer,
+		stackPointer: f.stack.pointer,
+		scope:        f.Env().currentScope,
+	})
+	return nil
+}
+
+func (vm *Vm) popHandler() error {
+	if len(vm.handlers) == 0 {
+		return fmt.Errorf("no try block to leave")
+	}
+	vm.handlers = vm.handlers[:len(vm.handlers)-1]
+	return nil
+}
+
+// dropFrameHandlers removes the handlers of the frames that are no longer on the call stack.
+func (vm *Vm) dropFrameHandlers() {
+	for len(vm.handlers) > 0 && vm.handlers[len(vm.handlers)-1].frameDepth > vm.callStack.pointer {
+		vm.handlers = vm.handlers[:len(vm.handlers)-1]
+	}
+}
+
+// unwind restores the vm to the state of the nearest handler, pushes the
+// caught error on its stack and returns the address of its catch block.
+func (vm *Vm) unwind(cause error) (int, error) {
+	h := vm.handlers[len(vm.handlers)-1]
+	vm.handlers = vm.handlers[:len(vm.handlers)-1]
+	vm.callStack.SetPointer(h.frameDepth)
+	vm.ClearArguments()
+	f, err := vm.callStack.Current()
+	if err != nil {
+		return 0, err
+	}
+	f.stack.pointer = h.stackPointer
+	f.Env().popScopesTo(h.scope)
+	err = f.stack.Push(vm.errorValue(cause))
+	if err != nil {
+		return 0, err
+	}
+	return h.catchAddr, nil
+}
+
+// errorValue converts an error raised while running a script to the Error value seen by the catch block.
+func (vm *Vm) errorValue(cause error) data.RuntimeValue {
+	var thrown *thrownError
+	if errors.As(cause, &thrown) {
+		return thrown.value
+	}
+	message := cause.Error()
+	var executionError *nomadError.ExecutionError
+	if errors.As(cause, &executionError) {
+		message = executionError.Message()
+	}
+	obj := data.NewRuntimeObject()
+	obj.SetField("message", data.RuntimeValue{
+		RuntimeType: vm.types.GetOrPanic(types.STRING_TYPE),
+		Value:       message,
+	})
+	return data.RuntimeValue{
+		RuntimeType: vm.types.GetOrPanic(types.ERROR_TYPE),
+		Value:       obj,
+	}
+}
+
+// throw raises the value on top of the stack, which should be an Error.
+func (vm *Vm) throw(debugToken tokenizer.Token) error {
+	value, err := vm.stack().Pop()
+	if err != nil {
+		return err
+	}
+	err = vm.types.GetOrPanic(types.ERROR_TYPE).Match(value.RuntimeType)
+	if err != nil {
+		return nomadError.RuntimeError(fmt.Sprintf("cannot throw value of type %s, Error expected", value.RuntimeType.GetName()), debugToken)
+	}
+	return newThrownError(*value, debugToken)
+}
+
+// catch declares the variable holding the caught error, discarding it when the catch block has none.
+func (vm *Vm) catch(name string) error {
+	value, err := vm.stack().Pop()
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		return nil
+	}
+	return vm.Env().DeclareVariable(name, value, vm.types.GetOrPanic(types.ERROR_TYPE))
+}
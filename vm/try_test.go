@@ -0,0 +1,88 @@
+package vm_test
+
+import (
+	"testing"
+
+	"github.com/dani-gouken/nomad/interpreter"
+	"github.com/dani-gouken/nomad/vm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTryCatch(t *testing.T) {
+	instance := interpret(t, vm.New(), `
+auto divide :: func(int a, int b) int {
+    if b = 0 {
+        throw new Error{ message :: "division by zero" }
+    }
+    return a / b
+}
+auto wrap :: func(int a, int b) int {
+    try {
+        return divide(a, b)
+    } catch e {
+        throw new Error{ message :: "wrapped: " + e.message }
+    }
+    return 0
+}
+auto thrown :: ""
+auto result :: 0
+try {
+    result :: divide(4, 2)
+    result :: divide(1, 0)
+    result :: 10
+} catch e {
+    thrown :: e.message
+}
+auto runtime :: ""
+try {
+    auto items :: [int]{1}
+    print items[3]
+} catch e {
+    runtime :: e.message
+}
+auto rethrown :: ""
+try {
+    wrap(1, 0)
+} catch e {
+    rethrown :: e.message
+}
+auto caught :: false
+try {
+    wrap(4, 2)
+} catch {
+    caught :: true
+}
+`)
+
+	assertGlobals(t, instance, map[string]interface{}{
+		"thrown":   "division by zero",
+		"result":   int64(2),
+		"runtime":  "index 3 out of range, array length is 1",
+		"rethrown": "wrapped: division by zero",
+		"caught":   false,
+	})
+}
+
+func TestUncaughtErrors(t *testing.T) {
+	i := interpreter.NewInterpreter()
+	err := i.Interpret(`throw new Error{ message :: "boom" }`, vm.New())
+	assert.ErrorContains(t, err, "uncaught error: boom")
+
+	// the try block of a returned function no longer catches errors
+	err = i.Interpret(`
+auto f :: func() int {
+    try {
+        return 1
+    } catch {
+        print "unreachable"
+    }
+    return 0
+}
+f()
+throw new Error{ message :: "after return" }
+`, vm.New())
+	assert.ErrorContains(t, err, "uncaught error: after return")
+
+	err = i.Interpret(`throw 1`, vm.New())
+	assert.ErrorContains(t, err, "cannot throw value of type int, Error expected")
+}
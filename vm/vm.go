@@ -2,7 +2,9 @@ package vm
 
 import (
 	"fmt"
+	"reflect"
 	"strconv"
+	"unicode/utf8"
 
 	nomadError "github.com/dani-gouken/nomad/errors"
 	"github.com/dani-gouken/nomad/runtime/data"
@@ -15,11 +17,28 @@ const (
 	OP_CONST_FALSE = "FALSE"
 )
 
+const NO_RETURN_DEPTH = -1
+
 type Vm struct {
 	callStack     *CallStack
 	arguments     []data.RuntimeValue
 	namedArgument map[string]data.RuntimeValue
 	types         types.Registrar
+	stringMethods data.StringMethods
+	program       []Instruction
+	modules       map[string]*Module
+	// moduleNames counts the modules loaded under each name
+	moduleNames  map[string]int
+	importStack  []string
+	moduleLoader ModuleLoader
+	entryPath    string
+	boundTypes   map[reflect.Type]*types.ObjectType
+	handlers     []handler
+	http         *httpModule
+	fsPermission FsPermission
+	// typeParams holds the type parameters of the generic functions whose
+	// signature is being built, the innermost last.
+	typeParams [][]*types.TypeVar
 }
 
 func (vm *Vm) stack() *Stack {
@@ -86,12 +105,24 @@ type Instruction struct {
 }
 
 func New() *Vm {
-	return &Vm{
-		types:         types.NewRegistrar(),
+	registrar := types.NewRegistrar()
+	registrar.Add(types.MakeErrorType(data.RuntimeValue{
+		RuntimeType: registrar.GetOrPanic(types.STRING_TYPE),
+		Value:       "",
+	}), tokenizer.Token{})
+	vm := &Vm{
+		types:         registrar,
 		namedArgument: make(map[string]data.RuntimeValue),
 		arguments:     []data.RuntimeValue{},
 		callStack:     NewCallStack(),
+		boundTypes:    make(map[reflect.Type]*types.ObjectType),
+		modules:       make(map[string]*Module),
+		moduleNames:   make(map[string]int),
+		fsPermission:  FS_PERMISSION_NONE,
+		stringMethods: data.NewStringMethods(registrar),
 	}
+	vm.registerBuiltins()
+	return vm
 }
 
 func (vm *Vm) pushConst(runtimeType string, value string) error {
@@ -124,16 +155,91 @@ func (vm *Vm) pushConst(runtimeType string, value string) error {
 			Value:       value,
 			RuntimeType: vm.types.GetOrPanic(runtimeType),
 		})
+	case types.NONE_TYPE:
+		return vm.stack().Push(data.RuntimeValue{
+			RuntimeType: vm.types.GetOrPanic(runtimeType),
+		})
 
 	default:
 		return fmt.Errorf("runtime error: unable to store value of runtime type %s", runtimeType)
 	}
 }
 
+// Interpret loads the instructions after the ones previously interpreted
+// and runs them, so functions declared by earlier calls remain callable.
 func (vm *Vm) Interpret(instructions []Instruction) error {
+	start := vm.load(instructions)
+	return vm.run(start, NO_RETURN_DEPTH)
+}
+
+// load appends instructions to the program, relocating their addresses,
+// and returns the address of the first one.
+func (vm *Vm) load(instructions []Instruction) int {
+	offset := len(vm.program)
+	for _, instruction := range instructions {
+		if HasAddressArg(instruction.Code) {
+			addr, err := strconv.Atoi(instruction.Arg1)
+			if err == nil {
+				instruction.Arg1 = strconv.Itoa(addr + offset)
+			}
+		}
+		vm.program = append(vm.program, instruction)
+	}
+	// every chunk is terminated so that execution never runs into the next one
+	vm.program = append(vm.program, Instruction{Code: OP_HALT})
+	return offset
+}
+
+// run executes the program from start. When returnDepth is reached by a
+// function return, run stops and leaves the returned value on the stack.
+// On failure the call stack and the type parameters in scope are restored
+// to their state before the run.
+func (vm *Vm) run(start int, returnDepth int) error {
+	depth := vm.callStack.pointer
+	typeParams := len(vm.typeParams)
+	err := vm.execute(start, returnDepth)
+	if err != nil {
+		err = nomadError.WithPath(err, vm.currentPath())
+		vm.callStack.SetPointer(depth)
+		vm.typeParams = vm.typeParams[:typeParams]
+		vm.ClearArguments()
+	}
+	return err
+}
+
+func (vm *Vm) returnFromFrame(value data.RuntimeValue) (int, error) {
+	f, err := vm.callStack.Pop()
+	if err != nil {
+		return 0, err
+	}
+	vm.dropFrameHandlers()
+	err = vm.stack().Push(value)
+	if err != nil {
+		return 0, err
+	}
+	return f.returnAddr, nil
+}
+
+// execute runs the program from start. An error raised inside a try block
+// entered by this execution resumes it at the matching catch block.
+func (vm *Vm) execute(start int, returnDepth int) error {
+	handlersBase := len(vm.handlers)
+	for {
+		err := vm.executeInstructions(start, returnDepth)
+		if err == nil || len(vm.handlers) <= handlersBase {
+			return err
+		}
+		start, err = vm.unwind(err)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (vm *Vm) executeInstructions(start int, returnDepth int) error {
 loop:
-	for i := 0; i < len(instructions); i++ {
-		instruction := instructions[i]
+	for i := start; i < len(vm.program); i++ {
+		instruction := vm.program[i]
 		// fmt.Println(fmt.Sprintf("executing %s %s %s", instruction.Code, instruction.Arg1, instruction.Arg2))
 		switch instruction.Code {
 		case OP_HALT:
@@ -151,7 +257,9 @@ loop:
 				return err
 			}
 			_, err = types.ToObjectType(value.RuntimeType)
-			if err != nil {
+			if isNone(*value) {
+				fmt.Printf("<%s> none\n", value.RuntimeType.GetName())
+			} else if err != nil {
 				fmt.Printf("<%s> %v\n", value.RuntimeType.GetName(), value.Value)
 			} else {
 				vObj := value.Value.(*data.RuntimeObject)
@@ -219,6 +327,7 @@ loop:
 			if err != nil {
 				return err
 			}
+			*value = data.Number(*value)
 			switch value.RuntimeType.GetName() {
 			case types.INT_TYPE:
 				intValue := value.Value.(int64)
@@ -234,6 +343,11 @@ loop:
 			if err != nil {
 				return err
 			}
+			runtimeMap, _, mapErr := toMap(value)
+			if mapErr == nil {
+				vm.stack().PushInt(vm.types, int64(runtimeMap.Len()))
+				continue
+			}
 			_, arrayTypeErr := types.ToArrayType(value.RuntimeType)
 			scalarType, scalarTypeErr := types.ToScalarType(value.RuntimeType)
 
@@ -246,7 +360,7 @@ loop:
 			}
 			if scalarTypeErr == nil {
 				stringValue := value.Value.(string)
-				vm.stack().PushInt(vm.types, int64(len(stringValue)))
+				vm.stack().PushInt(vm.types, int64(utf8.RuneCountInString(stringValue)))
 			}
 		case OP_EQ:
 			rhs, err := vm.stack().Pop()
@@ -257,7 +371,7 @@ loop:
 			if err != nil {
 				return err
 			}
-			vm.stack().PushBool(vm.types, rhs.Value == lhs.Value)
+			vm.stack().PushBool(vm.types, data.Equal(*lhs, *rhs))
 		case OP_EQ_2:
 			rhs, err := vm.stack().Pop()
 			if err != nil {
@@ -271,8 +385,8 @@ loop:
 			if err != nil {
 				return err
 			}
-			vm.stack().PushBool(vm.types, (rhs.Value == lhs1.Value) || (rhs.Value == lhs2.Value))
-		case OP_ADD, OP_SUB, OP_MULT, OP_DIV, OP_CMP:
+			vm.stack().PushBool(vm.types, data.Equal(*rhs, *lhs1) || data.Equal(*rhs, *lhs2))
+		case OP_ADD, OP_SUB, OP_MULT, OP_DIV, OP_CMP, OP_MOD, OP_BIT_XOR, OP_SHL, OP_SHR:
 			rhs, err := vm.stack().Pop()
 			if err != nil {
 				return err
@@ -293,6 +407,39 @@ loop:
 				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
 			}
 			vm.stack().Push(*result)
+		case OP_BIT_AND, OP_BIT_OR:
+			rhs, err := vm.stack().Pop()
+			if err != nil {
+				return err
+			}
+			lhs, err := vm.stack().Pop()
+			if err != nil {
+				return err
+			}
+			result, err := bitwise(vm.types, instruction.Code, lhs, rhs)
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
+			vm.stack().Push(*result)
+		case OP_TO_STRING:
+			value, err := vm.stack().Pop()
+			if err != nil {
+				return err
+			}
+			vm.stack().Push(data.RuntimeValue{
+				RuntimeType: vm.types.GetOrPanic(types.STRING_TYPE),
+				Value:       data.ToString(*value),
+			})
+		case OP_BIT_NOT:
+			value, err := vm.stack().Pop()
+			if err != nil {
+				return err
+			}
+			err = types.ExpectedIntType(value.RuntimeType)
+			if err != nil {
+				return nomadError.RuntimeErrorUnsupportedOperand("bitwise not(~)", value.RuntimeType.GetName(), instruction.DebugToken)
+			}
+			vm.stack().PushInt(vm.types, ^value.Value.(int64))
 		case OP_OR, OP_AND:
 			rhs, err := vm.stack().Pop()
 			if err != nil {
@@ -328,13 +475,30 @@ loop:
 			if err != nil {
 				return err
 			}
-			vm.PushPositionalArgument(data.RuntimeValue{
+			// arguments are pushed from the last one, as they are popped from the stack
+			vm.arguments = append(vm.arguments, data.RuntimeValue{
 				Value:       value.Value,
 				RuntimeType: value.RuntimeType,
 			})
 		case OP_FUNC_BEGIN:
 		case OP_FUNC_END:
-			vm.Env().PopScope()
+			f, err := vm.callStack.Current()
+			if err != nil {
+				return err
+			}
+			if !types.IsVoidType(f.CurrentFunc.Signature.ReturnType) {
+				return nomadError.RuntimeError(fmt.Sprintf(
+					"function %s reached its end without returning a value of type %s", f.CurrentFunc.Tag, f.CurrentFunc.Signature.ReturnType.GetName()), f.DebugToken)
+			}
+			i, err = vm.returnFromFrame(data.RuntimeValue{
+				RuntimeType: f.CurrentFunc.Signature.ReturnType,
+			})
+			if err != nil {
+				return err
+			}
+			if vm.callStack.pointer == returnDepth {
+				return nil
+			}
 		case OP_RETURN:
 			returnedValue, err := vm.stack().Pop()
 			if err != nil {
@@ -342,11 +506,18 @@ loop:
 			}
 			f, err := vm.callStack.Current()
 			if err != nil {
-				panic(err)
+				return err
+			}
+			if f.CurrentFunc != nil {
+				*returnedValue = data.Promote(*returnedValue, f.CurrentFunc.Signature.ReturnType)
+			}
+			i, err = vm.returnFromFrame(*returnedValue)
+			if err != nil {
+				return err
+			}
+			if vm.callStack.pointer == returnDepth {
+				return nil
 			}
-			i = f.returnAddr
-			vm.callStack.Pop()
-			vm.stack().Push(*returnedValue)
 		case OP_CALL:
 			value, err := vm.stack().Pop()
 			if err != nil {
@@ -356,37 +527,22 @@ loop:
 			if err != nil {
 				return err
 			}
-			f := value.Value.(*data.RuntimeFunc)
-			if len(f.Signature.Parameters) < vm.ArgumentCount() {
-				return nomadError.RuntimeError(fmt.Sprintf(
-					"failed to call function %s :: %s, too much argument provided, %d declared, %d passed",
-					f.Tag, f.Signature.AsType().GetName(), len(f.Signature.Parameters), vm.ArgumentCount()), instruction.DebugToken)
-			}
-			currentFrame, err := vm.callStack.Current()
+			f, args, err := vm.bindArguments(value.Value.(*data.RuntimeFunc), instruction.DebugToken)
 			if err != nil {
 				return err
 			}
-			//we move to the func begining
-			frame := NewFrame(i, f, instruction.DebugToken, currentFrame)
-			for _, pData := range f.Signature.Parameters {
-				value, err := vm.PopNamedArgument(pData.Name)
-				if err != nil {
-					value, err = vm.PopPositionalArgument()
-					if err != nil {
-						if pData.HasDefault {
-							value = pData.DefaultValue
-						} else {
-							vm.Env().PopScope()
-							return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
-						}
-					}
-				}
-				err = pData.RuntimeType.Match(value.RuntimeType)
+			if f.IsNative() {
+				result, err := vm.callNative(f, args, instruction.DebugToken)
 				if err != nil {
-					return nomadError.RuntimeError(
-						fmt.Sprintf("failed to call %s, type mismatch for parameter \"%s\". %s", f.Tag, pData.Name, err.Error()), instruction.DebugToken)
+					return err
 				}
-				frame.Env().DeclareVariable(pData.Name, &value, pData.RuntimeType)
+				vm.stack().Push(result)
+				continue
+			}
+			//we move to the func begining
+			frame := vm.newCallFrame(i, f, instruction.DebugToken)
+			for index, pData := range f.Signature.Parameters {
+				frame.Env().DeclareVariable(pData.Name, &args[index], pData.RuntimeType)
 			}
 			vm.callStack.Push(frame)
 			i = f.Begin - 1
@@ -396,6 +552,15 @@ loop:
 				return err
 			}
 			vm.PushNamedArgument(instruction.Arg1, *value)
+		case OP_IMPORT:
+			namespace, err := vm.importModule(instruction.Arg1, instruction.DebugToken)
+			if err != nil {
+				return err
+			}
+			err = vm.Env().DeclareVariable(instruction.Arg2, &namespace, namespace.RuntimeType)
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
 		case OP_LABEL:
 			continue
 		case OP_POP_SCOPE:
@@ -407,16 +572,22 @@ loop:
 				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
 			}
 			variableName := instruction.Arg1
-			variable, err := vm.Env().GetVariable(variableName)
+			// functions assign the variables of the frames they were defined in
+			variable, env, err := vm.callStack.FindVariable(variableName)
 			if err != nil {
 				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
 			}
+			declaration, isConstant := env.GetConstant(variableName)
+			if isConstant {
+				return nomadError.RuntimeError(fmt.Sprintf("cannot assign to constant %s declared at %d:%d", variableName, declaration.Loc.Line, declaration.Loc.Start), instruction.DebugToken)
+			}
+			*value = data.Promote(*value, variable.RuntimeType)
 			err = variable.RuntimeType.Match(value.RuntimeType)
 			if err != nil {
 				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
 			}
 			variable.Value = value.Value
-		case OP_DECL_VAR:
+		case OP_DECL_VAR, OP_DECL_CONST:
 			t, err := vm.stack().Pop()
 			if err != nil {
 				return err
@@ -441,11 +612,20 @@ loop:
 				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
 			}
 			name := instruction.Arg2
-			err = vm.Env().DeclareVariable(
-				name,
-				value,
-				declaredType,
-			)
+			if instruction.Code == OP_DECL_CONST {
+				err = vm.Env().DeclareConstant(
+					name,
+					value,
+					declaredType,
+					instruction.DebugToken,
+				)
+			} else {
+				err = vm.Env().DeclareVariable(
+					name,
+					value,
+					declaredType,
+				)
+			}
 			if err != nil {
 				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
 			}
@@ -509,6 +689,7 @@ loop:
 				return nomadError.RuntimeError("cannot push to non-array types", instruction.DebugToken)
 			}
 			runtimeArray, _ := array.Value.(data.RuntimeArray)
+			*value = data.Promote(*value, t.GetSubtype())
 			err = t.MatchSubtype(value.RuntimeType)
 			if err != nil {
 				return nomadError.RuntimeError(fmt.Sprintf("type mismatch, %s expected, %s given", t.GetSubtype().GetName(), value.RuntimeType.GetName()), instruction.DebugToken)
@@ -520,36 +701,146 @@ loop:
 			if err != nil {
 				return err
 			}
-			array, err := vm.stack().Pop()
+			container, err := vm.stack().Pop()
 			if err != nil {
 				return err
 			}
-			_, err = types.ToArrayType(array.RuntimeType)
+			if types.IsFuncType(container.RuntimeType) {
+				if t, err := vm.lookupType(instruction.Arg1); instruction.Arg1 != "" && err == nil {
+					index = &data.RuntimeValue{RuntimeType: vm.types.GetOrPanic(types.TYPE_TYPE), Value: t}
+				}
+				value, err := vm.instantiate(*container, []data.RuntimeValue{*index})
+				if err != nil {
+					return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+				}
+				vm.stack().Push(value)
+				break
+			}
+			value, err := loadIndex(container, index)
 			if err != nil {
-				return nomadError.RuntimeError("cannot push to non-array types", instruction.DebugToken)
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
 			}
-
-			err = types.ExpectedIntType(index.RuntimeType)
+			vm.stack().Push(value)
+		case OP_ARR_STORE:
+			value, err := vm.stack().Pop()
 			if err != nil {
-				return nomadError.RuntimeError("index should be an integer", instruction.DebugToken)
+				return err
 			}
-
-			runtimeArray, _ := array.Value.(data.RuntimeArray)
-			i, _ := index.Value.(int64)
+			index, err := vm.stack().Pop()
+			if err != nil {
+				return err
+			}
+			container, err := vm.stack().Pop()
+			if err != nil {
+				return err
+			}
+			err = storeIndex(container, index, value)
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
+		case OP_MAP_TYPE:
+			valueType, err := vm.stack().Pop()
+			if err != nil {
+				return err
+			}
+			keyType, err := vm.stack().Pop()
+			if err != nil {
+				return err
+			}
+			err = types.ExpectedTypeType(keyType.RuntimeType)
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
+			err = types.ExpectedTypeType(valueType.RuntimeType)
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
+			mapType, err := types.NewMapType(keyType.Value.(types.RuntimeType), valueType.Value.(types.RuntimeType))
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
+			vm.stack().PushType(vm.types, mapType)
+		case OP_MAP_INIT:
+			t, err := vm.stack().Pop()
+			if err != nil {
+				return err
+			}
+			err = types.ExpectedTypeType(t.RuntimeType)
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
+			mapType, err := types.ToMapType(t.Value.(types.RuntimeType))
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
+			vm.stack().Push(data.RuntimeValue{
+				RuntimeType: mapType,
+				Value:       data.NewRuntimeMap(),
+			})
+		case OP_MAP_SET:
+			value, err := vm.stack().Pop()
+			if err != nil {
+				return err
+			}
+			key, err := vm.stack().Pop()
+			if err != nil {
+				return err
+			}
+			container, err := vm.stack().Current()
+			if err != nil {
+				return err
+			}
+			err = storeIndex(container, key, value)
 			if err != nil {
-				return nomadError.RuntimeError("index should be an integer", instruction.DebugToken)
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
+		case OP_MAP_HAS:
+			container, err := vm.stack().Pop()
+			if err != nil {
+				return err
+			}
+			key, err := vm.stack().Pop()
+			if err != nil {
+				return err
+			}
+			runtimeMap, mapType, err := toMap(container)
+			if err != nil {
+				return nomadError.RuntimeErrorUnsupportedOperand("in", container.RuntimeType.GetName(), instruction.DebugToken)
+			}
+			err = mapType.GetKeyType().Match(key.RuntimeType)
+			if err != nil {
+				return nomadError.RuntimeError(fmt.Sprintf("invalid key. %s", err.Error()), instruction.DebugToken)
+			}
+			vm.stack().PushBool(vm.types, runtimeMap.Has(*key))
+		case OP_MAP_KEYS:
+			container, err := vm.stack().Pop()
+			if err != nil {
+				return err
+			}
+			runtimeMap, mapType, err := toMap(container)
+			if err != nil {
+				return nomadError.RuntimeErrorUnsupportedOperand("keys", container.RuntimeType.GetName(), instruction.DebugToken)
 			}
-			vm.stack().Push(runtimeArray.Values[i])
+			vm.stack().Push(data.RuntimeValue{
+				RuntimeType: types.NewArrayType(mapType.GetKeyType()),
+				Value:       data.RuntimeArray{Values: runtimeMap.Keys()},
+			})
 		case OP_POP_CONST:
 			vm.stack().Pop()
 		case OP_LOAD_VAR:
 			value, err := vm.callStack.GetVariable(instruction.Arg1)
 			if err != nil {
-				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+				// a type name used as a value, like in json.decode(text, Config)
+				t, typeErr := vm.lookupType(instruction.Arg1)
+				if typeErr != nil {
+					return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+				}
+				vm.stack().PushType(vm.types, t)
+				break
 			}
 			vm.stack().Push(*value)
 		case OP_LOAD_TYPE:
-			value, err := vm.types.Get(instruction.Arg1)
+			value, err := vm.lookupType(instruction.Arg1)
 			if err != nil {
 				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
 			}
@@ -570,16 +861,161 @@ loop:
 				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
 			}
 			vType := value.Value.(types.RuntimeType)
+			enumType, err := types.ToEnumType(vType)
+			if err == nil && enumType.IsAnonymous() {
+				enumType.SetName(vm.qualifyTypeName(instruction.Arg1))
+				vm.types.Add(enumType, instruction.DebugToken)
+				continue
+			}
 			objectType, err := types.ToObjectType(vType)
 			if err == nil && objectType.IsAnonymous() {
-				objectType.SetName(instruction.Arg1)
+				objectType.SetName(vm.qualifyTypeName(instruction.Arg1))
 				vm.types.Add(objectType, instruction.DebugToken)
 			} else {
 				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
 			}
+		case OP_DECL_METHOD:
+			t, err := vm.stack().Pop()
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
+			f, err := vm.stack().Pop()
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
+			err = vm.declareMethod(t, f, instruction.Arg1, instruction.Arg2)
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
 		case OP_OBJ_TYPE:
 			obj := types.NewObjectType()
 			vm.stack().PushType(vm.types, obj)
+		case OP_ENUM_TYPE:
+			vm.stack().PushType(vm.types, types.NewEnumType())
+		case OP_ENUM_TYPE_ADD_VARIANT:
+			enumType, err := vm.declaredEnumType()
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
+			err = enumType.AddVariant(instruction.Arg1)
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
+		case OP_ENUM_TYPE_SET_FIELD:
+			fieldType, err := vm.stack().Pop()
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
+			err = types.ExpectedTypeType(fieldType.RuntimeType)
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
+			enumType, err := vm.declaredEnumType()
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
+			err = enumType.AddField(instruction.Arg1, fieldType.Value.(types.RuntimeType))
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
+		case OP_ENUM_IS:
+			isVariant, err := vm.isVariant(instruction.Arg1)
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
+			vm.stack().PushBool(vm.types, isVariant)
+		case OP_ENUM_BIND:
+			err := vm.bindVariantField(instruction.Arg1, instruction.Arg2)
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
+		case OP_MATCH_FAIL:
+			enumType, value, err := vm.matchSubject()
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
+			return nomadError.RuntimeError(fmt.Sprintf("no arm matches variant %s of %s", value.Variant, enumType.GetName()), instruction.DebugToken)
+		case OP_OPTIONAL_TYPE:
+			t, err := vm.stack().Pop()
+			if err != nil {
+				return err
+			}
+			err = types.ExpectedTypeType(t.RuntimeType)
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
+			optionalType, err := types.NewOptionalType(t.Value.(types.RuntimeType))
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
+			vm.stack().PushType(vm.types, optionalType)
+		case OP_IS_SOME:
+			value, err := vm.stack().Pop()
+			if err != nil {
+				return err
+			}
+			vm.stack().PushBool(vm.types, !isNone(*value))
+		case OP_UNWRAP:
+			value, err := vm.stack().Pop()
+			if err != nil {
+				return err
+			}
+			unwrapped, err := unwrap(*value)
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
+			vm.stack().Push(unwrapped)
+		case OP_TRY:
+			catchAddr, err := strconv.Atoi(instruction.Arg1)
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
+			err = vm.pushHandler(catchAddr)
+			if err != nil {
+				return err
+			}
+		case OP_TRY_END:
+			err := vm.popHandler()
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
+		case OP_THROW:
+			return vm.throw(instruction.DebugToken)
+		case OP_ITER_INIT:
+			err := vm.initIterator(instruction.Arg1, instruction.Arg2 == "true")
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
+		case OP_ITER_NEXT:
+			it, err := vm.getIterator(instruction.Arg1)
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
+			vm.stack().PushBool(vm.types, it.next())
+		case OP_ITER_ITEM:
+			it, err := vm.getIterator(instruction.Arg1)
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
+			err = vm.Env().DeclareVariable(instruction.Arg2, it.current(), it.itemType)
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
+		case OP_ITER_INDEX:
+			it, err := vm.getIterator(instruction.Arg1)
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
+			index, indexType := it.index(vm.types)
+			err = vm.Env().DeclareVariable(instruction.Arg2, index, indexType)
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
+		case OP_CATCH:
+			err := vm.catch(instruction.Arg1)
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
 		case OP_FUNC_TYPE:
 			obj := types.NewFuncType()
 			vm.stack().PushType(vm.types, obj)
@@ -618,7 +1054,7 @@ loop:
 			}
 		case OP_OBJ_INIT:
 			typeName := instruction.Arg1
-			t, err := vm.types.Get(typeName)
+			t, err := vm.lookupType(typeName)
 			if err != nil {
 				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
 			}
@@ -634,7 +1070,7 @@ loop:
 				if !ok {
 					return nomadError.RuntimeError("object default is expected to be a runtime value", instruction.DebugToken)
 				}
-				obj.SetField(k, vValue)
+				obj.SetField(k, data.Copy(vValue))
 			}
 
 			vm.stack().Push(data.RuntimeValue{
@@ -648,10 +1084,50 @@ loop:
 			}
 
 			f := data.NewRuntimeFunc(&vm.types, pointer)
+			currentFrame, err := vm.callStack.Current()
+			if err != nil {
+				return err
+			}
+			f.Module = currentFrame.module
+			f.Closure = currentFrame
 			vm.stack().Push(data.RuntimeValue{
 				RuntimeType: f.Signature.AsType(),
 				Value:       f,
 			})
+		case OP_FUNC_TYPE_PARAMS:
+			f, err := vm.stack().Pop()
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
+			value, err := vm.declareTypeParams(f, instruction.Arg1)
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
+			vm.stack().Push(value)
+		case OP_FUNC_TYPE_PARAMS_END:
+			vm.endTypeParams()
+		case OP_FUNC_INSTANTIATE:
+			count, err := strconv.Atoi(instruction.Arg1)
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
+			typeArgs := make([]data.RuntimeValue, count)
+			for j := count - 1; j >= 0; j-- {
+				typeArg, err := vm.stack().Pop()
+				if err != nil {
+					return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+				}
+				typeArgs[j] = *typeArg
+			}
+			f, err := vm.stack().Pop()
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
+			value, err := vm.instantiate(*f, typeArgs)
+			if err != nil {
+				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+			}
+			vm.stack().Push(value)
 		case OP_FUNC_SET_RET:
 			returnType, err := vm.stack().Pop()
 			if err != nil {
@@ -798,6 +1274,18 @@ loop:
 				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
 			}
 
+			if types.ExpectedStringType(objectValue.RuntimeType) == nil {
+				method, err := vm.stringMethods.Bind(*objectValue, instruction.Arg1)
+				if err != nil {
+					return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+				}
+				vm.stack().Push(data.RuntimeValue{
+					RuntimeType: method.Signature.AsType(),
+					Value:       method,
+				})
+				continue
+			}
+
 			_, err = types.ToObjectType(objectValue.RuntimeType)
 			if err != nil {
 				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
@@ -807,7 +1295,12 @@ loop:
 			field := instruction.Arg1
 			v, err := object.GetField(field)
 			if err != nil {
-				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+				method, ok := lookupMethod(*objectValue, field)
+				if !ok {
+					return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+				}
+				vm.stack().Push(method)
+				continue
 			}
 
 			vm.stack().Push(*v)
@@ -822,6 +1315,15 @@ loop:
 				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
 			}
 			t := objectTypeValue.Value.(types.RuntimeType)
+			enumType, err := types.ToEnumType(t)
+			if err == nil {
+				variant, err := vm.loadVariant(enumType, instruction.Arg1)
+				if err != nil {
+					return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
+				}
+				vm.stack().Push(variant)
+				continue
+			}
 			objectType, err := types.ToObjectType(t)
 			if err != nil {
 				return nomadError.RuntimeError(err.Error(), instruction.DebugToken)
@@ -856,6 +1358,18 @@ func OpToSymbol(op string) (string, error) {
 		return "|", nil
 	case OP_DIV:
 		return "/", nil
+	case OP_MOD:
+		return "%", nil
+	case OP_BIT_AND:
+		return "&", nil
+	case OP_BIT_OR:
+		return "|", nil
+	case OP_BIT_XOR:
+		return "^", nil
+	case OP_SHL:
+		return "<<", nil
+	case OP_SHR:
+		return ">>", nil
 	}
 	return "", fmt.Errorf("unknown operator %s", op)
 